@@ -2,25 +2,28 @@ package billingstat
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"main/config"
-	"main/internal/fileutil"
 	m "main/internal/model"
+	"main/internal/source"
 	"main/sl"
+	"net/http"
 	"reflect"
 )
 
+// ErrBadState — в файле billing не битовая маска из 0/1.
+var ErrBadState = errors.New("error by converting string state to bool")
+
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=readfile
-func Fetch(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) (m.BillingData, error) {
+func Fetch(ctx context.Context, logger *slog.Logger, client *http.Client, cfg *config.CfgApp) (m.BillingData, error) {
 
-	// файл c voice
-	path := cfg.FileBillingState
-	rf, err := fileutil.FileOpener(path)
+	// файл c billing; либо http(s):// / exec:// с той же битовой маской (см. config.Location)
+	rf, err := source.Read(ctx, logger, client, cfg.FileBillingState, "billingstat.Fetch", cfg.MaxFile())
 	bd := &m.BillingData{}
 
 	if err != nil {
-		logger.Error("Error by opening file "+path, sl.Err(err))
 		return *bd, err
 	}
 
@@ -70,7 +73,7 @@ func byteToBool(b byte) (bool, error) {
 		return false, nil
 	default:
 		// Обработка ошибки для некорректных значений
-		return false, fmt.Errorf("error by converting string state to bool: invalid byte '%c'", b)
+		return false, fmt.Errorf("%w: invalid byte '%c'", ErrBadState, b)
 	}
 }
 
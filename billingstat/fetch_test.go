@@ -32,7 +32,7 @@ func BillingDataToString(data m.BillingData) string {
 }
 
 // Mock FileOpener для тестов
-func mockFileOpener(filename string) ([]byte, error) {
+func mockFileOpener(filename string, _ int64) ([]byte, error) {
 	// Мокирование содержимого файла
 	switch filename {
 	case "valid_file":
@@ -106,7 +106,7 @@ func TestGet(t *testing.T) {
 			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 			defer cancel()
 
-			got, err := Fetch(ctx, logger, cfg)
+			got, err := Fetch(ctx, logger, nil, cfg)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Get() error = %v, wantErr %v", err, tt.wantErr)
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"log/slog"
 	"main/config"
+	"main/internal/health"
 	m "main/internal/model"
+	"net/http"
 	"sync"
 	"time"
 
@@ -20,6 +22,7 @@ func GoFetch(
 	parentCtx context.Context,
 	logger *slog.Logger,
 	timeout time.Duration,
+	client *http.Client,
 	cfg *config.CfgApp,
 	rs *m.ResultSetT,
 	mu *sync.Mutex,
@@ -33,9 +36,10 @@ func GoFetch(
 		}
 
 		start := time.Now()
-		data, err := fetchBills(ctx, logger, cfg)
+		data, err := fetchBills(ctx, logger, client, cfg)
 
 		if err != nil {
+			health.Record(ctx, config.SourceBilling, start, 0, err)
 			// отличаем отмену от реальной ошибки
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				logger.Info("billing cancelled", slog.Duration("dur", time.Since(start)))
@@ -48,6 +52,7 @@ func GoFetch(
 		// перед публикацией ещё раз убеждаемся, что не отменено
 		select {
 		case <-ctx.Done():
+			health.Record(ctx, config.SourceBilling, start, 0, ctx.Err())
 			logger.Info("billing cancelled before publish", slog.Duration("dur", time.Since(start)))
 			return nil
 		default:
@@ -58,6 +63,7 @@ func GoFetch(
 		rs.Billing = data
 		mu.Unlock()
 
+		health.Record(ctx, config.SourceBilling, start, 1, nil) // биллинг — одна запись
 		logger.Info("billing fetched",
 			slog.Duration("dur", time.Since(start)),
 		)
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"main/config"
 	m "main/internal/model"
+	"net/http"
 	"sync"
 	"testing"
 	"time"
@@ -30,7 +31,7 @@ func TestGoFetch_SuccessPublishes(t *testing.T) {
 		FraudControl:   false,
 		CheckoutPage:   true,
 	}
-	fetchBills = func(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) (m.BillingData, error) {
+	fetchBills = func(ctx context.Context, logger *slog.Logger, _ *http.Client, cfg *config.CfgApp) (m.BillingData, error) {
 		return want, nil
 	}
 
@@ -40,7 +41,7 @@ func TestGoFetch_SuccessPublishes(t *testing.T) {
 	var mu sync.Mutex
 
 	g, ctx := errgroup.WithContext(context.Background())
-	GoFetch(g, ctx, logger, 200*time.Millisecond, cfg, &rs, &mu)
+	GoFetch(g, ctx, logger, 200*time.Millisecond, nil, cfg, &rs, &mu)
 
 	if err := g.Wait(); err != nil {
 		t.Fatalf("group returned error: %v", err)
@@ -59,7 +60,7 @@ func TestGoFetch_FetchError_NoPublish(t *testing.T) {
 	orig := fetchBills
 	defer func() { fetchBills = orig }()
 
-	fetchBills = func(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) (m.BillingData, error) {
+	fetchBills = func(ctx context.Context, logger *slog.Logger, _ *http.Client, cfg *config.CfgApp) (m.BillingData, error) {
 		return m.BillingData{}, errors.New("boom")
 	}
 
@@ -69,7 +70,7 @@ func TestGoFetch_FetchError_NoPublish(t *testing.T) {
 	var mu sync.Mutex
 
 	g, ctx := errgroup.WithContext(context.Background())
-	GoFetch(g, ctx, logger, 100*time.Millisecond, cfg, &rs, &mu)
+	GoFetch(g, ctx, logger, 100*time.Millisecond, nil, cfg, &rs, &mu)
 
 	if err := g.Wait(); err != nil {
 		t.Fatalf("group returned error: %v", err)
@@ -86,7 +87,7 @@ func TestGoFetch_FetchReturnsContextCanceled_NoPublish(t *testing.T) {
 	orig := fetchBills
 	defer func() { fetchBills = orig }()
 
-	fetchBills = func(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) (m.BillingData, error) {
+	fetchBills = func(ctx context.Context, logger *slog.Logger, _ *http.Client, cfg *config.CfgApp) (m.BillingData, error) {
 		return m.BillingData{}, context.Canceled
 	}
 
@@ -96,7 +97,7 @@ func TestGoFetch_FetchReturnsContextCanceled_NoPublish(t *testing.T) {
 	var mu sync.Mutex
 
 	g, ctx := errgroup.WithContext(context.Background())
-	GoFetch(g, ctx, logger, 50*time.Millisecond, cfg, &rs, &mu)
+	GoFetch(g, ctx, logger, 50*time.Millisecond, nil, cfg, &rs, &mu)
 
 	if err := g.Wait(); err != nil {
 		t.Fatalf("group returned error: %v", err)
@@ -114,7 +115,7 @@ func TestGoFetch_CancelBeforePublish_NoPublish(t *testing.T) {
 	defer func() { fetchBills = orig }()
 
 	// фетч «успешный», но ответ приходит ПОСЛЕ истечения timeout в GoFetch
-	fetchBills = func(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) (m.BillingData, error) {
+	fetchBills = func(ctx context.Context, logger *slog.Logger, _ *http.Client, cfg *config.CfgApp) (m.BillingData, error) {
 		time.Sleep(40 * time.Millisecond) // дольше, чем timeout ниже
 		return m.BillingData{
 			CreateCustomer: true,
@@ -128,7 +129,7 @@ func TestGoFetch_CancelBeforePublish_NoPublish(t *testing.T) {
 	var mu sync.Mutex
 
 	g, ctx := errgroup.WithContext(context.Background())
-	GoFetch(g, ctx, logger, 10*time.Millisecond, cfg, &rs, &mu) // маленький timeout
+	GoFetch(g, ctx, logger, 10*time.Millisecond, nil, cfg, &rs, &mu) // маленький timeout
 
 	if err := g.Wait(); err != nil {
 		t.Fatalf("group returned error: %v", err)
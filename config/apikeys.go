@@ -0,0 +1,290 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Доступ к самому HTTP API сервиса (не путать с Auth — авторизацией сервиса у источников).
+Пока ключей нет, API открыт, как раньше. Ключ — имя клиента, секции, которые ему можно читать (scopes),
+и способ проверки:
+
+	http:
+	  auth:
+	    keys:
+	      - { name: dashboard, scopes: [sms, mms], key: "env:DASH_KEY" }   # Authorization: Bearer <ключ> или X-API-Key
+	      - { name: billing-bot, scopes: [billing], hmac: "file:/run/secrets/bot" } # подписанные запросы
+	      - { name: reports, scopes: ["*"], cert_cn: reports.internal }    # клиентский сертификат (mTLS)
+	    hmac_skew: 5m
+
+В config.cfg/env/флагах — одной строкой через запятую, каждый ключ как name:scopes:ref
+(scopes через "+", ref — env:/file: для key, hmac:env:/hmac:file: для hmac, cn:<CN> для cert_cn):
+
+	APIKeys = "dashboard:sms+mms:env:DASH_KEY, billing-bot:billing:hmac:file:/run/secrets/bot, reports:*:cn:reports.internal"
+
+Секреты ключей, как и у источников, только ссылками и читаются на каждый запрос — ротация без reload.
+*/
+
+// Способы проверки ключа API
+const (
+	KeyStatic = "key"
+	KeyHMAC   = "hmac"
+	KeyCert   = "cert"
+)
+
+// ScopeAll — ключу доступны все секции.
+const ScopeAll = "*"
+
+// APIKey — клиент HTTP API.
+type APIKey struct {
+	Name   string
+	Scopes []string  // имена источников (Sources) или ScopeAll
+	Kind   string    // KeyStatic | KeyHMAC | KeyCert
+	Secret SecretRef // для KeyStatic и KeyHMAC
+	CertCN string    // для KeyCert: CN проверенного клиентского сертификата
+}
+
+// Allows — можно ли ключу читать секцию source.
+func (k APIKey) Allows(source string) bool {
+	return slices.Contains(k.Scopes, ScopeAll) || slices.Contains(k.Scopes, source)
+}
+
+// encode — ключ в виде name:scopes:ref, как в config.cfg.
+func (k APIKey) encode() string {
+	ref := string(k.Secret)
+	switch k.Kind {
+	case KeyHMAC:
+		ref = "hmac:" + ref
+	case KeyCert:
+		ref = "cn:" + k.CertCN
+	}
+	return k.Name + ":" + strings.Join(k.Scopes, "+") + ":" + ref
+}
+
+// parseAPIKeys разбирает строку ключей; ссылки на секреты проверяются только синтаксически.
+func parseAPIKeys(s string) ([]APIKey, error) {
+	var (
+		keys []APIKey
+		errs []error
+	)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			errs = append(errs, fmt.Errorf("api key %q: want name:scopes:ref", entry))
+			continue
+		}
+		k := APIKey{Name: parts[0], Scopes: strings.Split(parts[1], "+")}
+		ref := parts[2]
+		switch {
+		case strings.HasPrefix(ref, "cn:"):
+			k.Kind, k.CertCN = KeyCert, strings.TrimPrefix(ref, "cn:")
+		case strings.HasPrefix(ref, "hmac:"):
+			k.Kind, k.Secret = KeyHMAC, SecretRef(strings.TrimPrefix(ref, "hmac:"))
+		default:
+			k.Kind, k.Secret = KeyStatic, SecretRef(ref)
+		}
+		keys = append(keys, k)
+	}
+	return keys, errors.Join(errs...)
+}
+
+// APIKeyList — ключи HTTP API; пустой список — API открыт.
+// Конфиг после Load/Validate уже проверен, поэтому ошибки разбора здесь не возвращаются.
+func (c *CfgApp) APIKeyList() []APIKey {
+	if c == nil {
+		return nil
+	}
+	keys, _ := parseAPIKeys(c.APIKeys)
+	return keys
+}
+
+// validateAPIKeys — ошибки разбора, неизвестные секции, повторы имён и незаполненные ref.
+func validateAPIKeys(s string) []error {
+	keys, err := parseAPIKeys(s)
+	var errs []error
+	if err != nil {
+		errs = append(errs, fmt.Errorf("APIKeys: %w", err))
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if seen[k.Name] {
+			errs = append(errs, fmt.Errorf("APIKeys: duplicate key name %q", k.Name))
+		}
+		seen[k.Name] = true
+		for _, scope := range k.Scopes {
+			if scope != ScopeAll && !slices.Contains(Sources, scope) {
+				errs = append(errs, fmt.Errorf("APIKeys: key %q: unknown scope %q, want one of %s or %q",
+					k.Name, scope, strings.Join(Sources, "|"), ScopeAll))
+			}
+		}
+		if k.Kind == KeyCert {
+			if k.CertCN == "" {
+				errs = append(errs, fmt.Errorf("APIKeys: key %q: empty certificate CN", k.Name))
+			}
+			continue
+		}
+		if _, _, err := k.Secret.parse(); err != nil {
+			errs = append(errs, fmt.Errorf("APIKeys: key %q: %w", k.Name, err))
+		}
+	}
+	return errs
+}
+
+// keyList — ключи API в структурированном конфиге: список объектов (см. apiKeyItem) или строка, как в config.cfg.
+// В CfgApp хранится строкой (см. pathList).
+type keyList string
+
+// apiKeyItem — один ключ в yaml/json/toml; задаётся ровно одно из key, hmac, cert_cn.
+type apiKeyItem struct {
+	Name   string    `yaml:"name" json:"name" toml:"name"`
+	Scopes []string  `yaml:"scopes" json:"scopes" toml:"scopes"`
+	Key    SecretRef `yaml:"key,omitempty" json:"key,omitempty" toml:"key,omitempty"`
+	HMAC   SecretRef `yaml:"hmac,omitempty" json:"hmac,omitempty" toml:"hmac,omitempty"`
+	CertCN string    `yaml:"cert_cn,omitempty" json:"cert_cn,omitempty" toml:"cert_cn,omitempty"`
+}
+
+func (it apiKeyItem) encode() (string, error) {
+	k := APIKey{Name: it.Name, Scopes: it.Scopes}
+	n := 0
+	if it.Key != "" {
+		k.Kind, k.Secret, n = KeyStatic, it.Key, n+1
+	}
+	if it.HMAC != "" {
+		k.Kind, k.Secret, n = KeyHMAC, it.HMAC, n+1
+	}
+	if it.CertCN != "" {
+		k.Kind, k.CertCN, n = KeyCert, it.CertCN, n+1
+	}
+	if it.Name == "" || len(it.Scopes) == 0 || n != 1 {
+		return "", fmt.Errorf("api key %q: want name, scopes and exactly one of key, hmac, cert_cn", it.Name)
+	}
+	return k.encode(), nil
+}
+
+func joinKeys(items []apiKeyItem) (keyList, error) {
+	list := make([]string, 0, len(items))
+	for _, it := range items {
+		s, err := it.encode()
+		if err != nil {
+			return "", err
+		}
+		list = append(list, s)
+	}
+	return keyList(strings.Join(list, ", ")), nil
+}
+
+func (l *keyList) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.SequenceNode {
+		var items []apiKeyItem
+		if err := n.Decode(&items); err != nil {
+			return err
+		}
+		v, err := joinKeys(items)
+		*l = v
+		return err
+	}
+	var s string
+	if err := n.Decode(&s); err != nil {
+		return err
+	}
+	*l = keyList(s)
+	return nil
+}
+
+func (l *keyList) UnmarshalJSON(b []byte) error {
+	var items []apiKeyItem
+	if err := json.Unmarshal(b, &items); err == nil {
+		v, err := joinKeys(items)
+		*l = v
+		return err
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("want a list of api keys, got %s", b)
+	}
+	*l = keyList(s)
+	return nil
+}
+
+// UnmarshalTOML — массив таблиц [[http.auth.keys]] приходит как []map[string]any.
+func (l *keyList) UnmarshalTOML(v any) error {
+	if s, ok := v.(string); ok {
+		*l = keyList(s)
+		return nil
+	}
+	var tables []any
+	switch v := v.(type) {
+	case []any:
+		tables = v
+	case []map[string]any:
+		for _, t := range v {
+			tables = append(tables, t)
+		}
+	default:
+		return fmt.Errorf("want a list of api keys, got %v", v)
+	}
+	items := make([]apiKeyItem, 0, len(tables))
+	for _, t := range tables {
+		m, ok := t.(map[string]any)
+		if !ok {
+			return fmt.Errorf("want a list of api keys, got %v", v)
+		}
+		var it apiKeyItem
+		for key, val := range m {
+			var err error
+			switch key {
+			case "name":
+				it.Name, err = tomlString(key, val)
+			case "key":
+				var s string
+				s, err = tomlString(key, val)
+				it.Key = SecretRef(s)
+			case "hmac":
+				var s string
+				s, err = tomlString(key, val)
+				it.HMAC = SecretRef(s)
+			case "cert_cn":
+				it.CertCN, err = tomlString(key, val)
+			case "scopes":
+				list, ok := val.([]any)
+				if !ok {
+					return fmt.Errorf("scopes: want a list, got %v", val)
+				}
+				for _, e := range list {
+					s, err := tomlString(key, e)
+					if err != nil {
+						return err
+					}
+					it.Scopes = append(it.Scopes, s)
+				}
+			default:
+				err = fmt.Errorf("unknown api key field %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		items = append(items, it)
+	}
+	s, err := joinKeys(items)
+	*l = s
+	return err
+}
+
+func tomlString(key string, v any) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%s: want a string, got %v", key, v)
+	}
+	return s, nil
+}
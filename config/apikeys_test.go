@@ -0,0 +1,124 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const wantAPIKeys = "dashboard:sms+mms:env:DASH_KEY, bot:billing:hmac:file:/run/secrets/bot, reports:*:cn:reports.internal"
+
+// все форматы должны давать тот же CfgApp, что и legacy config.cfg
+func TestLoad_APIKeys_AllFormats(t *testing.T) {
+	legacy := validCfg + `APIKeys = "` + wantAPIKeys + `"
+AuthHMACSkew = "1m"
+TLSCertFile = "tls.crt"
+TLSKeyFile = "tls.key"
+TLSClientCA = "clients.pem"
+`
+	yaml := validYAML + `  tls: { cert: tls.crt, key: tls.key, client_ca: clients.pem }
+  auth:
+    hmac_skew: 1m
+    keys:
+      - { name: dashboard, scopes: [sms, mms], key: "env:DASH_KEY" }
+      - { name: bot, scopes: [billing], hmac: "file:/run/secrets/bot" }
+      - { name: reports, scopes: ["*"], cert_cn: reports.internal }
+`
+	json := strings.Replace(validJSON, `"http":     {"addr": "127.0.0.1:8282"}`, `"http":     {"addr": "127.0.0.1:8282", "tls": {"cert": "tls.crt", "key": "tls.key", "client_ca": "clients.pem"},
+    "auth": {"hmac_skew": "1m", "keys": [
+    {"name": "dashboard", "scopes": ["sms", "mms"], "key": "env:DASH_KEY"},
+    {"name": "bot", "scopes": ["billing"], "hmac": "file:/run/secrets/bot"},
+    {"name": "reports", "scopes": ["*"], "cert_cn": "reports.internal"}
+  ]}}`, 1)
+	toml := validTOML + `
+[http.tls]
+cert = "tls.crt"
+key = "tls.key"
+client_ca = "clients.pem"
+
+[http.auth]
+hmac_skew = "1m"
+
+[[http.auth.keys]]
+name = "dashboard"
+scopes = ["sms", "mms"]
+key = "env:DASH_KEY"
+
+[[http.auth.keys]]
+name = "bot"
+scopes = ["billing"]
+hmac = "file:/run/secrets/bot"
+
+[[http.auth.keys]]
+name = "reports"
+scopes = ["*"]
+cert_cn = "reports.internal"
+`
+	want := wantValid
+	want.APIKeys = wantAPIKeys
+	want.AuthHMACSkew = time.Minute
+	want.TLSCertFile, want.TLSKeyFile, want.TLSClientCA = "tls.crt", "tls.key", "clients.pem"
+
+	for _, tt := range []struct{ name, content string }{
+		{"config.cfg", legacy},
+		{"config.yaml", yaml},
+		{"config.json", json},
+		{"config.toml", toml},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeNamed(t, tt.name, tt.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *cfg != want {
+				t.Fatalf("cfg mismatch:\n got: %+v\nwant: %+v", *cfg, want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyList(t *testing.T) {
+	got := (&CfgApp{APIKeys: wantAPIKeys}).APIKeyList()
+	want := []APIKey{
+		{Name: "dashboard", Scopes: []string{SourceSMS, SourceMMS}, Kind: KeyStatic, Secret: "env:DASH_KEY"},
+		{Name: "bot", Scopes: []string{SourceBilling}, Kind: KeyHMAC, Secret: "file:/run/secrets/bot"},
+		{Name: "reports", Scopes: []string{ScopeAll}, Kind: KeyCert, CertCN: "reports.internal"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("APIKeyList:\n got: %+v\nwant: %+v", got, want)
+	}
+	if !want[0].Allows(SourceMMS) || want[0].Allows(SourceBilling) || !want[2].Allows(SourceIncident) {
+		t.Fatalf("Allows mismatch")
+	}
+	if keys := (*CfgApp)(nil).APIKeyList(); keys != nil {
+		t.Fatalf("nil config keys = %v", keys)
+	}
+}
+
+func TestLoad_APIKeys_Invalid(t *testing.T) {
+	content := validCfg + `APIKeys = "a:sms:env:A, a:fax:env:B, b:sms:plaintext, c:*:cn:, broken"
+AuthHMACSkew = "-1s"
+`
+	_, err := Load(writeCfg(t, content))
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	for _, want := range []string{
+		`api key "broken": want name:scopes:ref`,
+		`duplicate key name "a"`,
+		`key "a": unknown scope "fax"`,
+		`key "b": secret must be a reference`,
+		`key "c": empty certificate CN`,
+		"AuthHMACSkew must be >= 0",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err.Error(), want)
+		}
+	}
+
+	yaml := validYAML + "  auth:\n    keys:\n      - { name: x, scopes: [sms], key: \"env:A\", cert_cn: host }\n"
+	if _, err := Load(writeNamed(t, "config.yaml", yaml)); err == nil || !strings.Contains(err.Error(), "exactly one of key, hmac, cert_cn") {
+		t.Fatalf("yaml error = %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+)
+
+const commandUsage = `usage:
+  statecollector config validate [-config path] [overrides...]
+  statecollector config print [-config path] [-format yaml|json|toml] [overrides...]
+
+overrides — те же флаги, что и у сервиса (например -http.addr=0.0.0.0:8282), учитывается и STATECOLLECTOR_*
+`
+
+// RunCommand — подкоманды `statecollector config validate|print`. Конфиг собирается тем же Resolve,
+// что и при старте сервиса, поэтому validate/print показывают ровно то, с чем сервис запустится.
+// Возвращает код выхода: 0 — ок, 1 — конфиг с ошибками, 2 — неверные аргументы.
+func RunCommand(args []string, lookupEnv func(string) (string, bool), stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = io.WriteString(stderr, commandUsage)
+		return 2
+	}
+	cmd := args[0]
+	if cmd != "validate" && cmd != "print" {
+		fmt.Fprintf(stderr, "unknown config command %q\n%s", cmd, commandUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config "+cmd, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	cfgFlags := RegisterFlags(fs)
+	format := "yaml"
+	if cmd == "print" {
+		fs.StringVar(&format, "format", format, "output format: yaml|json|toml")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	path := cfgFlags.Path(lookupEnv)
+	cfgApp, err := Resolve(lookupEnv, cfgFlags)
+	if err != nil {
+		// errors.Join — по ошибке на строку, выводим все разом
+		fmt.Fprintf(stderr, "config %s is invalid:\n%v\n", path, err)
+		return 1
+	}
+
+	if cmd == "validate" {
+		fmt.Fprintf(stdout, "config %s is valid\n", path)
+		return 0
+	}
+	if err := Write(stdout, cfgApp, format); err != nil {
+		fmt.Fprintf(stderr, "config print: %v\n", err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCommand(t *testing.T) {
+	valid := writeCfg(t, validCfg)
+	invalid := writeCfg(t, strings.Replace(validCfg, "QuantSMSDataCol = 4", "QuantSMSDataCol = 1", 1)+
+		"FetchConcurrency = -1\n")
+	noEnv := fakeEnv(nil)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout []string
+		wantStderr []string
+	}{
+		{"no command", nil, 2, nil, []string{"usage:"}},
+		{"unknown command", []string{"dump"}, 2, nil, []string{`unknown config command "dump"`}},
+		{"validate ok", []string{"validate", "-config", valid}, 0, []string{"is valid"}, nil},
+		{"validate reports all errors", []string{"validate", "-config", invalid}, 1, nil,
+			[]string{"QuantSMSDataCol must be >= 4, got 1", "FetchConcurrency must be >= 0, got -1"}},
+		{"validate missing file", []string{"validate", "-config", filepath.Join(t.TempDir(), "nope.cfg")}, 1, nil,
+			[]string{"is invalid"}},
+		{"validate bad flag override", []string{"validate", "-config", valid, "-http.addr", "nope"}, 1, nil,
+			[]string{"HTTPAddr"}},
+		{"print yaml with override", []string{"print", "-config", valid, "-http.addr", "0.0.0.0:9000"}, 0,
+			[]string{"addr: 0.0.0.0:9000", "file: sms.data", "timeout: 3s"}, nil},
+		{"print json", []string{"print", "-config", valid, "-format", "json"}, 0, []string{`"addr": "127.0.0.1:8282"`}, nil},
+		{"print unknown format", []string{"print", "-config", valid, "-format", "ini"}, 2, nil, []string{"unknown format"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := RunCommand(tt.args, noEnv, &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("code = %d, want %d\nstdout: %s\nstderr: %s", code, tt.wantCode, stdout.String(), stderr.String())
+			}
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("stdout %q does not contain %q", stdout.String(), want)
+				}
+			}
+			for _, want := range tt.wantStderr {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("stderr %q does not contain %q", stderr.String(), want)
+				}
+			}
+		})
+	}
+}
+
+// секреты в print не разыменовываются: видна только ссылка
+func TestRunCommand_PrintMasksSecrets(t *testing.T) {
+	path := writeCfg(t, validCfg+"AuthMmsType = \"bearer\"\nAuthMmsToken = \"env:MMS_TOKEN\"\n")
+	env := fakeEnv(map[string]string{"MMS_TOKEN": "topsecret"})
+
+	var stdout, stderr bytes.Buffer
+	if code := RunCommand([]string{"print", "-config", path}, env, &stdout, &stderr); code != 0 {
+		t.Fatalf("code = %d, stderr: %s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "topsecret") {
+		t.Fatalf("secret leaked:\n%s", stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "env:MMS_TOKEN") {
+		t.Fatalf("secret reference missing:\n%s", stdout.String())
+	}
+}
@@ -1,34 +1,17 @@
 package config
 
-/*var (
-	//Config: read SMS data
-	FileSms         = "sms.data"
-
-	//кол-во колонок в таблице смс
-	QuantSMSDataCol = 4
-
-	//Config: read MMS data
-	PathMmsData = "http://127.0.0.1:8383/mms"
-)
-*/
-
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
-// Глобальные переменные, которые будут заполнены из файла.
-/*var (
-	FileSms           string
-	QuantSMSDataCol   int
-	PathMmsData       string
-	FileVoiceCall     string
-	QuantVoiceDataCol int
-)*/
-
 type CfgApp struct {
 	FileSms           string
 	QuantSMSDataCol   int
@@ -41,20 +24,413 @@ type CfgApp struct {
 	PathSupportData   string
 	PathIncidentData  string
 	HTTPAddr          string
+
+	// Таймауты и лимиты; 0 — значение по умолчанию (см. limits.go и методы SourceTimeout, ClientTimeout...).
+	FetchTimeout             time.Duration // таймаут источника, если для него не задан свой
+	TimeoutSms               time.Duration
+	TimeoutMms               time.Duration
+	TimeoutVoice             time.Duration
+	TimeoutEmail             time.Duration
+	TimeoutBilling           time.Duration
+	TimeoutSupport           time.Duration
+	TimeoutIncident          time.Duration
+	HTTPClientTimeout        time.Duration
+	FetchConcurrency         int
+	HandlerTimeout           time.Duration
+	CacheTTL                 time.Duration
+	StreamHeartbeat          time.Duration // пауза между ping в /api/v1/stream, чтобы прокси не рвали соединение
+	StreamOrigins            string        // Origin-ы других хостов, которым разрешён WebSocket-поток, через запятую (см. stream.go)
+	ServerReadTimeout        time.Duration
+	ServerWriteTimeout       time.Duration
+	ServerReadHeaderTimeout  time.Duration
+	ServerIdleTimeout        time.Duration
+	MaxFileSize              int64
+	SupportThroughputPerHour float64
+	ConfigPollInterval       time.Duration
+
+	// Фоновый сбор (см. internal/scheduler): 0 — выключен, данные собираются по запросу, как раньше.
+	CollectInterval  time.Duration
+	CollectJitter    time.Duration // случайная добавка к интервалу, чтобы источники не опрашивались синхронно
+	IntervalSms      time.Duration // свой интервал источника; 0 — CollectInterval
+	IntervalMms      time.Duration
+	IntervalVoice    time.Duration
+	IntervalEmail    time.Duration
+	IntervalBilling  time.Duration
+	IntervalSupport  time.Duration
+	IntervalIncident time.Duration
+
+	// PartialResults — "/" отдаёт в result.data все собранные секции, даже если часть не собрана
+	// (status degraded); false — строгий режим: data только при полностью успешном сборе.
+	PartialResults bool
+
+	// Авторизация к HTTP-источникам; секреты — только ссылками env:/file: (см. secret.go).
+	AuthMms      Auth
+	AuthSupport  Auth
+	AuthIncident Auth
+
+	// Ключи доступа к HTTP API сервиса (см. apikeys.go); пусто — API открыт.
+	APIKeys      string
+	AuthHMACSkew time.Duration // допустимое расхождение часов у подписанных (HMAC) запросов
+
+	// Ограничение нагрузки на API (см. RateClient, RateGlobal); 0 — без ограничения.
+	RateLimitClient float64 // запросов в секунду с одного IP клиента
+	RateBurstClient int     // запас запросов сверх скорости; 0 — округлённая вверх скорость
+	RateLimitGlobal float64 // запросов в секунду на весь сервис
+	RateBurstGlobal int
+	MaxInFlight     int // одновременно обрабатываемых запросов (кроме потоков /api/v1/stream)
+
+	// TLS для HTTP API (см. tls.go); без TLSCertFile — обычный HTTP.
+	TLSCertFile   string
+	TLSKeyFile    string
+	TLSClientCA   string // CA клиентских сертификатов (mTLS)
+	TLSMinVersion string // 1.2 | 1.3
+	TLSCiphers    string // наборы TLS 1.2 через запятую
+
+	// Трассировка (см. tracing.go); без TraceEndpoint — выключена.
+	TraceEndpoint    string   // OTLP/HTTP endpoint span-ов
+	TraceService     string   // service.name; пусто — DefaultTraceService
+	TraceSampleRatio *float64 // доля записываемых трасс, 0..1; nil — не задана (все), 0 — ни одной
+
+	// Выключенные источники не опрашиваются и не считаются ошибкой сбора (см. Enabled).
+	// Храним именно Disable*, чтобы нулевое значение (и &CfgApp{} в тестах) означало «всё включено».
+	DisableSms      bool
+	DisableMms      bool
+	DisableVoice    bool
+	DisableEmail    bool
+	DisableBilling  bool
+	DisableSupport  bool
+	DisableIncident bool
+}
+
+// setter переносит строковое значение из файла в нужное поле CfgApp.
+// Ошибка setter-а означает, что значение не приводится к типу поля.
+type setter func(c *CfgApp, val string) error
+
+func setString(field func(c *CfgApp) *string) setter {
+	return func(c *CfgApp, val string) error {
+		*field(c) = val
+		return nil
+	}
+}
+
+func setInt(field func(c *CfgApp) *int) setter {
+	return func(c *CfgApp, val string) error {
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("not an integer: %q", val)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func setInt64(field func(c *CfgApp) *int64) setter {
+	return func(c *CfgApp, val string) error {
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return fmt.Errorf("not an integer: %q", val)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func setFloat(field func(c *CfgApp) *float64) setter {
+	return func(c *CfgApp, val string) error {
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("not a number: %q", val)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+// setFloatRef — для полей, где 0 — осмысленное значение, а «не задано» хранится как nil.
+func setFloatRef(field func(c *CfgApp) **float64) setter {
+	return func(c *CfgApp, val string) error {
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("not a number: %q", val)
+		}
+		*field(c) = &n
+		return nil
+	}
 }
 
-// Load читает ключ-значение вида `key = "value"` или `key = 123`.
-// Комментарии начинающиеся с `//` и пустые строки пропускаются.
+func setSecret(field func(c *CfgApp) *SecretRef) setter {
+	return func(c *CfgApp, val string) error {
+		r := SecretRef(val)
+		if _, _, err := r.parse(); err != nil {
+			return err
+		}
+		*field(c) = r
+		return nil
+	}
+}
+
+// setEnabled — ключ в конфиге «включён ли источник», а хранится обратное значение Disable*.
+func setEnabled(disabled func(c *CfgApp) *bool) setter {
+	return func(c *CfgApp, val string) error {
+		on, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("not a boolean: %q", val)
+		}
+		*disabled(c) = !on
+		return nil
+	}
+}
+
+func setBool(field func(c *CfgApp) *bool) setter {
+	return func(c *CfgApp, val string) error {
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("not a boolean: %q", val)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+// setDuration принимает значения вида "3s", "1m30s", "500ms".
+func setDuration(field func(c *CfgApp) *time.Duration) setter {
+	return func(c *CfgApp, val string) error {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return fmt.Errorf("not a duration: %q", val)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// field описывает одну настройку CfgApp во всех источниках: ключ config.cfg,
+// флаг командной строки и переменная окружения (STATECOLLECTOR_ + флаг в верхнем регистре, "." → "_").
+type field struct {
+	key   string // ключ в config.cfg
+	flag  string // флаг командной строки (без "-"), он же путь "секция.ключ" в yaml/json/toml
+	usage string
+	set   setter
+}
+
+// env — имя переменной окружения для поля: sms.file → STATECOLLECTOR_SMS_FILE.
+func (f field) env() string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(f.flag, ".", "_"))
+}
+
+const envPrefix = "STATECOLLECTOR_"
+
+// authFields — настройки авторизации источника: AuthMmsType → -mms.auth.type и т.д.
+func authFields(key, source string, auth func(c *CfgApp) *Auth) []field {
+	return []field{
+		{key + "Type", source + ".auth.type", source + " auth type: bearer|basic",
+			setString(func(c *CfgApp) *string { return &auth(c).Type })},
+		{key + "Token", source + ".auth.token", source + " bearer token reference env:NAME|file:/path",
+			setSecret(func(c *CfgApp) *SecretRef { return &auth(c).Token })},
+		{key + "Username", source + ".auth.username", source + " basic auth username",
+			setString(func(c *CfgApp) *string { return &auth(c).Username })},
+		{key + "Password", source + ".auth.password", source + " basic auth password reference env:NAME|file:/path",
+			setSecret(func(c *CfgApp) *SecretRef { return &auth(c).Password })},
+	}
+}
+
+// fields — все настройки в порядке config.cfg.
+var fields = slices.Concat([]field{
+	{"FileSms", "sms.file", "SMS data files: paths or glob patterns, comma separated",
+		setString(func(c *CfgApp) *string { return &c.FileSms })},
+	{"QuantSMSDataCol", "sms.columns", "number of columns in SMS data",
+		setInt(func(c *CfgApp) *int { return &c.QuantSMSDataCol })},
+	{"PathMmsData", "mms.url", "MMS data URL",
+		setString(func(c *CfgApp) *string { return &c.PathMmsData })},
+	{"FileVoice", "voice.file", "voice call data files: paths or glob patterns, comma separated",
+		setString(func(c *CfgApp) *string { return &c.FileVoiceCall })},
+	{"QuantVoiceDataCol", "voice.columns", "number of columns in voice call data",
+		setInt(func(c *CfgApp) *int { return &c.QuantVoiceDataCol })},
+	{"FileEmail", "email.file", "email data files: paths or glob patterns, comma separated",
+		setString(func(c *CfgApp) *string { return &c.FileEmail })},
+	{"QuantEmailDataCol", "email.columns", "number of columns in email data",
+		setInt(func(c *CfgApp) *int { return &c.QuantEmailDataCol })},
+	{"FileBillingState", "billing.file", "path to billing state file",
+		setString(func(c *CfgApp) *string { return &c.FileBillingState })},
+	{"PathSupportData", "support.url", "support data URL",
+		setString(func(c *CfgApp) *string { return &c.PathSupportData })},
+	{"PathIncidentData", "incident.url", "incident data URL",
+		setString(func(c *CfgApp) *string { return &c.PathIncidentData })},
+	{"HTTPAddr", "http.addr", "HTTP listen address host:port",
+		setString(func(c *CfgApp) *string { return &c.HTTPAddr })},
+
+	// таймауты и лимиты
+	{"FetchTimeout", "fetch.timeout", "default per-source fetch timeout",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.FetchTimeout })},
+	{"TimeoutSms", "sms.timeout", "SMS fetch timeout (default fetch.timeout)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.TimeoutSms })},
+	{"TimeoutMms", "mms.timeout", "MMS fetch timeout (default fetch.timeout)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.TimeoutMms })},
+	{"TimeoutVoice", "voice.timeout", "voice call fetch timeout (default fetch.timeout)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.TimeoutVoice })},
+	{"TimeoutEmail", "email.timeout", "email fetch timeout (default fetch.timeout)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.TimeoutEmail })},
+	{"TimeoutBilling", "billing.timeout", "billing fetch timeout (default fetch.timeout)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.TimeoutBilling })},
+	{"TimeoutSupport", "support.timeout", "support fetch timeout (default fetch.timeout)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.TimeoutSupport })},
+	{"TimeoutIncident", "incident.timeout", "incident fetch timeout (default fetch.timeout)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.TimeoutIncident })},
+	{"HTTPClientTimeout", "fetch.client_timeout", "HTTP client timeout for upstream requests",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.HTTPClientTimeout })},
+	{"FetchConcurrency", "fetch.concurrency", "max sources fetched in parallel",
+		setInt(func(c *CfgApp) *int { return &c.FetchConcurrency })},
+	{"MaxFileSize", "fetch.max_file_size", "max size of a data file in bytes",
+		setInt64(func(c *CfgApp) *int64 { return &c.MaxFileSize })},
+	{"SupportThroughputPerHour", "support.throughput_per_hour", "support team throughput, tickets per hour",
+		setFloat(func(c *CfgApp) *float64 { return &c.SupportThroughputPerHour })},
+	{"HandlerTimeout", "http.handler_timeout", "time budget of one HTTP request",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.HandlerTimeout })},
+	{"CacheTTL", "http.cache_ttl", "lifetime of cached collection result",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.CacheTTL })},
+	{"StreamHeartbeat", "http.stream_heartbeat", "interval of keep-alive pings in update stream",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.StreamHeartbeat })},
+	{"StreamOrigins", "http.stream_origins", "origins (scheme://host[:port]) of other hosts allowed to open the WebSocket stream, comma separated",
+		setString(func(c *CfgApp) *string { return &c.StreamOrigins })},
+	{"APIKeys", "http.auth.keys", "HTTP API keys name:scopes:ref, comma separated (empty — API is open)",
+		setString(func(c *CfgApp) *string { return &c.APIKeys })},
+	{"AuthHMACSkew", "http.auth.hmac_skew", "allowed clock skew of HMAC-signed requests",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.AuthHMACSkew })},
+	{"RateLimitClient", "http.limits.client_rate", "requests per second from one client IP (0 — unlimited)",
+		setFloat(func(c *CfgApp) *float64 { return &c.RateLimitClient })},
+	{"RateBurstClient", "http.limits.client_burst", "burst of requests from one client IP (default client_rate rounded up)",
+		setInt(func(c *CfgApp) *int { return &c.RateBurstClient })},
+	{"RateLimitGlobal", "http.limits.global_rate", "requests per second to the whole service (0 — unlimited)",
+		setFloat(func(c *CfgApp) *float64 { return &c.RateLimitGlobal })},
+	{"RateBurstGlobal", "http.limits.global_burst", "burst of requests to the whole service (default global_rate rounded up)",
+		setInt(func(c *CfgApp) *int { return &c.RateBurstGlobal })},
+	{"MaxInFlight", "http.limits.max_in_flight", "max requests handled at once, streams excluded (0 — unlimited)",
+		setInt(func(c *CfgApp) *int { return &c.MaxInFlight })},
+	{"TLSCertFile", "http.tls.cert", "TLS certificate file (PEM); enables HTTPS and HTTP/2",
+		setString(func(c *CfgApp) *string { return &c.TLSCertFile })},
+	{"TLSKeyFile", "http.tls.key", "TLS private key file (PEM)",
+		setString(func(c *CfgApp) *string { return &c.TLSKeyFile })},
+	{"TLSClientCA", "http.tls.client_ca", "CA bundle (PEM) to verify client certificates",
+		setString(func(c *CfgApp) *string { return &c.TLSClientCA })},
+	{"TLSMinVersion", "http.tls.min_version", "minimal TLS version: 1.2|1.3",
+		setString(func(c *CfgApp) *string { return &c.TLSMinVersion })},
+	{"TLSCiphers", "http.tls.ciphers", "allowed TLS 1.2 cipher suites, comma separated (empty — Go defaults)",
+		setString(func(c *CfgApp) *string { return &c.TLSCiphers })},
+	{"TraceEndpoint", "tracing.endpoint", "OTLP/HTTP traces endpoint, e.g. http://otel-collector:4318/v1/traces (empty — tracing off)",
+		setString(func(c *CfgApp) *string { return &c.TraceEndpoint })},
+	{"TraceService", "tracing.service", "service.name of exported spans (default statecollector)",
+		setString(func(c *CfgApp) *string { return &c.TraceService })},
+	{"TraceSampleRatio", "tracing.sample_ratio", "fraction of traces to record, 0..1 (default 1; 0 — none)",
+		setFloatRef(func(c *CfgApp) **float64 { return &c.TraceSampleRatio })},
+	{"PartialResults", "http.partial_results", "return collected sections even if some sources failed (true|false)",
+		setBool(func(c *CfgApp) *bool { return &c.PartialResults })},
+	{"ServerReadTimeout", "http.read_timeout", "HTTP server read timeout",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.ServerReadTimeout })},
+	{"ServerWriteTimeout", "http.write_timeout", "HTTP server write timeout",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.ServerWriteTimeout })},
+	{"ServerReadHeaderTimeout", "http.read_header_timeout", "HTTP server read header timeout",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.ServerReadHeaderTimeout })},
+	{"ServerIdleTimeout", "http.idle_timeout", "HTTP server keep-alive idle timeout",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.ServerIdleTimeout })},
+	{"ConfigPollInterval", "config.poll_interval", "how often to check config file for changes",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.ConfigPollInterval })},
+
+	// фоновый сбор
+	{"CollectInterval", "collect.interval", "background collection interval (0 — collect on request)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.CollectInterval })},
+	{"CollectJitter", "collect.jitter", "random delay added to each collection interval",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.CollectJitter })},
+	{"IntervalSms", "sms.interval", "SMS collection interval (default collect.interval)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.IntervalSms })},
+	{"IntervalMms", "mms.interval", "MMS collection interval (default collect.interval)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.IntervalMms })},
+	{"IntervalVoice", "voice.interval", "voice call collection interval (default collect.interval)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.IntervalVoice })},
+	{"IntervalEmail", "email.interval", "email collection interval (default collect.interval)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.IntervalEmail })},
+	{"IntervalBilling", "billing.interval", "billing collection interval (default collect.interval)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.IntervalBilling })},
+	{"IntervalSupport", "support.interval", "support collection interval (default collect.interval)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.IntervalSupport })},
+	{"IntervalIncident", "incident.interval", "incident collection interval (default collect.interval)",
+		setDuration(func(c *CfgApp) *time.Duration { return &c.IntervalIncident })},
+
+	// включение/выключение источников
+	{"EnableSms", "sms.enabled", "collect SMS data (true|false)",
+		setEnabled(func(c *CfgApp) *bool { return &c.DisableSms })},
+	{"EnableMms", "mms.enabled", "collect MMS data (true|false)",
+		setEnabled(func(c *CfgApp) *bool { return &c.DisableMms })},
+	{"EnableVoice", "voice.enabled", "collect voice call data (true|false)",
+		setEnabled(func(c *CfgApp) *bool { return &c.DisableVoice })},
+	{"EnableEmail", "email.enabled", "collect email data (true|false)",
+		setEnabled(func(c *CfgApp) *bool { return &c.DisableEmail })},
+	{"EnableBilling", "billing.enabled", "collect billing state (true|false)",
+		setEnabled(func(c *CfgApp) *bool { return &c.DisableBilling })},
+	{"EnableSupport", "support.enabled", "collect support data (true|false)",
+		setEnabled(func(c *CfgApp) *bool { return &c.DisableSupport })},
+	{"EnableIncident", "incident.enabled", "collect incident data (true|false)",
+		setEnabled(func(c *CfgApp) *bool { return &c.DisableIncident })},
+},
+	authFields("AuthMms", SourceMMS, func(c *CfgApp) *Auth { return &c.AuthMms }),
+	authFields("AuthSupport", SourceSupport, func(c *CfgApp) *Auth { return &c.AuthSupport }),
+	authFields("AuthIncident", SourceIncident, func(c *CfgApp) *Auth { return &c.AuthIncident }),
+)
+
+// keys — все допустимые ключи config.cfg. Ключ, которого здесь нет, считается опечаткой.
+var keys = func() map[string]setter {
+	m := make(map[string]setter, len(fields))
+	for _, f := range fields {
+		m[f.key] = f.set
+	}
+	return m
+}()
+
+// Load читает конфиг; формат определяется по расширению файла:
+//   - .yaml/.yml, .json, .toml — структурированный формат с секциями по источникам (см. format.go);
+//   - всё остальное (config.cfg) — ключ-значение вида `key = "value"` или `key = 123`,
+//     комментарии начинающиеся с `//` и пустые строки пропускаются.
+//
+// Неизвестные ключи, некорректные значения и незаполненные обязательные поля
+// собираются в одну ошибку (errors.Join), чтобы за один запуск увидеть все проблемы файла.
 func Load(path string) (*CfgApp, error) {
 	cfgApp := &CfgApp{}
+	var errs []error
+	if err := readFile(path, cfgApp); err != nil {
+		errs = append(errs, err)
+	}
+	// обязательные поля и диапазоны проверяем даже при ошибках разбора — пусть пользователь увидит всё сразу
+	if err := cfgApp.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("config %s: %w", path, errors.Join(errs...))
+	}
+	return cfgApp, nil
+}
+
+// readFile накладывает содержимое файла поверх cfgApp: ключи, которых нет в файле, не трогаются.
+func readFile(path string, cfgApp *CfgApp) error {
+	decode := decoderFor(path)
+
 	f, err := os.Open(path)
 	if err != nil {
-		return cfgApp, fmt.Errorf("open config: %w", err)
+		return fmt.Errorf("open config: %w", err)
 	}
 	defer f.Close()
 
-	sc := bufio.NewScanner(f)
+	return decode(f, cfgApp)
+}
+
+// parseLegacy разбирает содержимое config.cfg в cfgApp.
+// Ошибки разбора всех строк возвращаются вместе, корректные строки при этом применяются.
+func parseLegacy(r io.Reader, cfgApp *CfgApp) error {
+	var errs []error
+	seen := make(map[string]int, len(keys)) // ключ → номер строки, где он впервые встретился
+
+	sc := bufio.NewScanner(r)
+	lineNo := 0
 	for sc.Scan() {
+		lineNo++
 		line := strings.TrimSpace(sc.Text())
 		if line == "" || strings.HasPrefix(line, "//") {
 			continue
@@ -62,53 +438,32 @@ func Load(path string) (*CfgApp, error) {
 
 		i := strings.Index(line, "=")
 		if i == -1 {
-			continue // или вернуть ошибку «некорректная строка»
+			errs = append(errs, fmt.Errorf("line %d: expected `key = value`, got %q", lineNo, line))
+			continue
 		}
 		key := strings.TrimSpace(line[:i])
 		val := strings.TrimSpace(line[i+1:])
 		// убираем возможные кавычки
 		val = strings.Trim(val, `"'`)
 
-		switch key {
-		case "FileSms":
-			cfgApp.FileSms = val
-		case "QuantSMSDataCol":
-			n, err := strconv.Atoi(val)
-			if err != nil {
-				return cfgApp, fmt.Errorf("QuantSMSDataCol: %w", err)
-			}
-			cfgApp.QuantSMSDataCol = n
-		case "PathMmsData":
-			cfgApp.PathMmsData = val
-		case "FileVoice":
-			cfgApp.FileVoiceCall = val
-		case "QuantVoiceDataCol":
-			n, err := strconv.Atoi(val)
-			if err != nil {
-				return cfgApp, fmt.Errorf("QuantVoiceDataCol: %w", err)
-			}
-			cfgApp.QuantVoiceDataCol = n
-		case "FileEmail":
-			cfgApp.FileEmail = val
-		case "QuantEmailDataCol":
-			n, err := strconv.Atoi(val)
-			if err != nil {
-				return cfgApp, fmt.Errorf("QuantEmailDataCol: %w", err)
-			}
-			cfgApp.QuantEmailDataCol = n
-		case "FileBillingState":
-			cfgApp.FileBillingState = val
-		case "PathSupportData":
-			cfgApp.PathSupportData = val
-		case "PathIncidentData":
-			cfgApp.PathIncidentData = val
-		case "HTTPAddr":
-			cfgApp.HTTPAddr = val
+		set, ok := keys[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("line %d: unknown key %q", lineNo, key))
+			continue
 		}
+		if first, dup := seen[key]; dup {
+			errs = append(errs, fmt.Errorf("line %d: duplicate key %q (first set on line %d)", lineNo, key, first))
+			continue
+		}
+		seen[key] = lineNo
 
+		if err := set(cfgApp, val); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %s: %w", lineNo, key, err))
+		}
 	}
 	if err := sc.Err(); err != nil {
-		return nil, fmt.Errorf("scan config: %w", err)
+		return fmt.Errorf("scan config: %w", err)
 	}
-	return cfgApp, nil
+
+	return errors.Join(errs...)
 }
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validCfg — содержимое, совпадающее с рабочим config.cfg
+const validCfg = `//Config: read SMS data
+FileSms         = "sms.data"
+
+//кол-во колонок в таблице смс
+QuantSMSDataCol = 4
+
+PathMmsData = "http://127.0.0.1:8383/mms"
+FileVoice        = "voice.data"
+QuantVoiceDataCol = 8
+FileEmail        = "email.data"
+QuantEmailDataCol = 3
+FileBillingState = "billing.data"
+PathSupportData = "http://127.0.0.1:8383/support"
+PathIncidentData = "http://127.0.0.1:8383/accendent"
+HTTPAddr = "127.0.0.1:8282"
+`
+
+// writeCfg пишет содержимое во временный config.cfg и возвращает путь к нему.
+func writeCfg(t *testing.T, content string) string {
+	t.Helper()
+	return writeNamed(t, "config.cfg", content)
+}
+
+// writeNamed — то же, но с заданным именем файла (расширение выбирает формат).
+func writeNamed(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoad_Valid(t *testing.T) {
+	cfg, err := Load(writeCfg(t, validCfg))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if *cfg != wantValid {
+		t.Fatalf("cfg mismatch:\n got: %+v\nwant: %+v", *cfg, wantValid)
+	}
+}
+
+// wantValid — CfgApp, соответствующий validCfg
+var wantValid = CfgApp{
+	FileSms:           "sms.data",
+	QuantSMSDataCol:   4,
+	PathMmsData:       "http://127.0.0.1:8383/mms",
+	FileVoiceCall:     "voice.data",
+	QuantVoiceDataCol: 8,
+	FileEmail:         "email.data",
+	QuantEmailDataCol: 3,
+	FileBillingState:  "billing.data",
+	PathSupportData:   "http://127.0.0.1:8383/support",
+	PathIncidentData:  "http://127.0.0.1:8383/accendent",
+	HTTPAddr:          "127.0.0.1:8282",
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "absent.cfg")); err == nil {
+		t.Fatalf("expected error for absent file")
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace [2]string // что заменить в validCfg, чтобы сломать файл
+		wantErr []string  // подстроки, которые обязаны быть в ошибке
+	}{
+		{
+			name:    "unknown_key_with_line",
+			replace: [2]string{`FileEmail        = "email.data"`, `FileEmial = "email.data"`},
+			wantErr: []string{`line 10: unknown key "FileEmial"`, "FileEmail is required"},
+		},
+		{
+			name:    "line_without_equal",
+			replace: [2]string{`HTTPAddr = "127.0.0.1:8282"`, `HTTPAddr "127.0.0.1:8282"`},
+			wantErr: []string{"line 15: expected `key = value`", "HTTPAddr is required"},
+		},
+		{
+			name:    "not_integer",
+			replace: [2]string{`QuantSMSDataCol = 4`, `QuantSMSDataCol = four`},
+			wantErr: []string{`line 5: QuantSMSDataCol: not an integer: "four"`},
+		},
+		{
+			name:    "columns_out_of_range",
+			replace: [2]string{`QuantVoiceDataCol = 8`, `QuantVoiceDataCol = 0`},
+			wantErr: []string{"QuantVoiceDataCol must be >= 8, got 0"},
+		},
+		{
+			name:    "bad_url_scheme",
+			replace: [2]string{`"http://127.0.0.1:8383/mms"`, `"127.0.0.1:8383/mms"`},
+			wantErr: []string{"PathMmsData: invalid URL"},
+		},
+		{
+			name:    "bad_host_port",
+			replace: [2]string{`"127.0.0.1:8282"`, `"127.0.0.1:99999"`},
+			wantErr: []string{"HTTPAddr: invalid port"},
+		},
+		{
+			name:    "duplicate_key",
+			replace: [2]string{`FileBillingState = "billing.data"`, "FileBillingState = \"billing.data\"\nFileBillingState = \"other.data\""},
+			wantErr: []string{`line 13: duplicate key "FileBillingState" (first set on line 12)`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := strings.Replace(validCfg, tt.replace[0], tt.replace[1], 1)
+			if content == validCfg {
+				t.Fatalf("replace %q did not change config", tt.replace[0])
+			}
+
+			cfg, err := Load(writeCfg(t, content))
+			if err == nil {
+				t.Fatalf("expected error, got cfg=%+v", cfg)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err.Error(), want)
+				}
+			}
+		})
+	}
+}
+
+// все проблемы должны прийти одной ошибкой, а не только первая
+func TestLoad_AggregatesAllErrors(t *testing.T) {
+	const content = `FileSms = "sms.data"
+Foo = 1
+QuantSMSDataCol = x
+garbage line
+`
+	_, err := Load(writeCfg(t, content))
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	for _, want := range []string{
+		`line 2: unknown key "Foo"`,
+		"line 3: QuantSMSDataCol: not an integer",
+		"line 4: expected `key = value`",
+		"PathMmsData is required",
+		"HTTPAddr is required",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err.Error(), want)
+		}
+	}
+}
+
+// адрес источника: file:// / http(s):// / exec:// в любой секции
+func TestLoad_SourceLocations(t *testing.T) {
+	ok := strings.NewReplacer(
+		`FileSms         = "sms.data"`, `FileSms = "https://feeds.example.com/sms.data"`,
+		`FileBillingState = "billing.data"`, `FileBillingState = "exec:///usr/local/bin/billing-state --raw"`,
+		`"http://127.0.0.1:8383/mms"`, `"file:///var/lib/feeds/mms.json"`,
+	).Replace(validCfg)
+	cfg, err := Load(writeCfg(t, ok))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if scheme, target, _ := Location(cfg.PathMmsData); scheme != SchemeFile || target != "/var/lib/feeds/mms.json" {
+		t.Fatalf("Location(mms) = %s %q", scheme, target)
+	}
+
+	bad := strings.NewReplacer(
+		`FileSms         = "sms.data"`, `FileSms = "exec://"`,
+		`FileVoice        = "voice.data"`, `FileVoice = "exec:///usr/local/bin/get-voice '/data/my feeds'"`,
+		`FileEmail        = "email.data"`, `FileEmail = "https://user:pw@feeds/email"`,
+		`"http://127.0.0.1:8383/support"`, `"/var/lib/feeds/support.json"`,
+		`"http://127.0.0.1:8383/accendent"`, `"file://"`,
+		`FileBillingState = "billing.data"`, `FileBillingState = "billing.data, billing-*.data"`,
+	).Replace(validCfg)
+	_, err = Load(writeCfg(t, bad))
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	for _, want := range []string{
+		`FileSms: invalid URL "exec://": empty command`,
+		`quotes and escapes are not supported, arguments are split on spaces`,
+		"FileEmail: invalid URL",
+		`PathSupportData: invalid URL "/var/lib/feeds/support.json": scheme must be http, https, file or exec`,
+		`PathIncidentData: invalid URL "file://": empty path`,
+		`FileBillingState: invalid path "billing.data, billing-*.data": exactly one file expected`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err.Error(), want)
+		}
+	}
+}
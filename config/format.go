@@ -0,0 +1,411 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Структурированный конфиг (yaml/json/toml) — те же настройки, что и в config.cfg,
+только сгруппированные по источникам. Пример config.yaml:
+
+	sms:      { file: sms.data, columns: 4 }
+	voice:    { file: voice.data, columns: 8 }
+	email:    { file: email.data, columns: 3 }
+	billing:  { file: billing.data }
+	mms:      { url: "http://127.0.0.1:8383/mms" }
+	support:  { url: "http://127.0.0.1:8383/support" }
+	incident: { url: "http://127.0.0.1:8383/accendent" }
+	http:     { addr: "127.0.0.1:8282" }
+
+У sms/voice/email файлов может быть несколько (по файлу на провайдера или на час) — список и/или glob-шаблоны,
+строки всех файлов склеиваются; в config.cfg/env/флагах — через запятую:
+
+	sms:      { file: ["sms.data", "incoming/sms-*.data"], columns: 4 }
+
+Таймауты/лимиты (все необязательные, длительности — строкой "3s"):
+
+	fetch:    { timeout: 3s, client_timeout: 5s, concurrency: 7, max_file_size: 40960 }
+	sms:      { timeout: 1s }          # свой таймаут есть у каждой секции-источника
+	support:  { throughput_per_hour: 18 }
+	http:     { handler_timeout: 10s, cache_ttl: 10s, stream_heartbeat: 15s, read_timeout: 15s, write_timeout: 15s,
+	            read_header_timeout: 5s, idle_timeout: 60s,
+	            limits: { client_rate: 5, client_burst: 10, global_rate: 50, max_in_flight: 32 } }
+	config:   { poll_interval: 2s }
+
+Фоновый сбор по расписанию (по умолчанию выключен — данные собираются на запрос):
+
+	collect:  { interval: 30s, jitter: 5s }
+	billing:  { interval: 5m }         # свой интервал есть у каждой секции-источника
+
+Любой источник можно выключить — он не опрашивается, а его обязательные ключи не проверяются:
+
+	billing:  { enabled: false }
+
+Авторизация HTTP-источников (mms, support, incident), секреты — только ссылками:
+
+	mms:      { url: "https://mms/api", auth: { type: bearer, token: "env:MMS_TOKEN" } }
+	support:  { auth: { type: basic, username: collector, password: "file:/run/secrets/support" } }
+
+Доступ к самому API — ключи в http.auth (см. apikeys.go):
+
+	http:     { auth: { keys: [{ name: dashboard, scopes: [sms, mms], key: "env:DASH_KEY" }], hmac_skew: 5m } }
+
+HTTPS и HTTP/2 (см. tls.go):
+
+	http:     { tls: { cert: tls.crt, key: tls.key, min_version: "1.2" } }
+*/
+
+// decodeFn разбирает файл конкретного формата поверх уже заполненного CfgApp (без Validate).
+type decodeFn func(r io.Reader, c *CfgApp) error
+
+// decoderFor выбирает разборщик по расширению файла; неизвестное расширение — legacy config.cfg.
+func decoderFor(path string) decodeFn {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return decodeYAML
+	case ".json":
+		return decodeJSON
+	case ".toml":
+		return decodeTOML
+	default:
+		return parseLegacy
+	}
+}
+
+// duration — time.Duration, который в файле пишется строкой ("3s", "1m30s"); нужен для json,
+// т.к. encoding/json не умеет time.Duration из строки. yaml и toml используют тот же TextUnmarshaler.
+type duration time.Duration
+
+func (d *duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return fmt.Errorf("not a duration: %q", b)
+	}
+	*d = duration(v)
+	return nil
+}
+
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// pathList — путь к файлам источника: строка "a.data, sms-*.data" или список ["a.data", "sms-*.data"].
+// В CfgApp хранится строкой через запятую (см. fileutil.ExpandPaths), чтобы структура оставалась плоской.
+type pathList string
+
+func joinPaths(list []string) pathList { return pathList(strings.Join(list, ", ")) }
+
+func (p *pathList) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.SequenceNode {
+		var list []string
+		if err := n.Decode(&list); err != nil {
+			return err
+		}
+		*p = joinPaths(list)
+		return nil
+	}
+	var s string
+	if err := n.Decode(&s); err != nil {
+		return err
+	}
+	*p = pathList(s)
+	return nil
+}
+
+func (p *pathList) UnmarshalJSON(b []byte) error {
+	var list []string
+	if err := json.Unmarshal(b, &list); err == nil {
+		*p = joinPaths(list)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("want a path or a list of paths, got %s", b)
+	}
+	*p = pathList(s)
+	return nil
+}
+
+func (p *pathList) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*p = pathList(v)
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return fmt.Errorf("want a list of paths, got %v", v)
+			}
+			list = append(list, s)
+		}
+		*p = joinPaths(list)
+	default:
+		return fmt.Errorf("want a path or a list of paths, got %v", v)
+	}
+	return nil
+}
+
+// fileSection — источник из локальных файлов с построчным форматом "a;b;c".
+type fileSection struct {
+	File     pathList `yaml:"file" json:"file" toml:"file"`
+	Columns  int      `yaml:"columns" json:"columns" toml:"columns"`
+	Timeout  duration `yaml:"timeout" json:"timeout" toml:"timeout"`
+	Interval duration `yaml:"interval" json:"interval" toml:"interval"`
+	Enabled  *bool    `yaml:"enabled,omitempty" json:"enabled,omitempty" toml:"enabled,omitempty"`
+}
+
+// billingSection — битовая маска в файле, колонок нет.
+type billingSection struct {
+	File     string   `yaml:"file" json:"file" toml:"file"`
+	Timeout  duration `yaml:"timeout" json:"timeout" toml:"timeout"`
+	Interval duration `yaml:"interval" json:"interval" toml:"interval"`
+	Enabled  *bool    `yaml:"enabled,omitempty" json:"enabled,omitempty" toml:"enabled,omitempty"`
+}
+
+// urlSection — источник, который отдаёт JSON по HTTP.
+type urlSection struct {
+	URL      string      `yaml:"url" json:"url" toml:"url"`
+	Timeout  duration    `yaml:"timeout" json:"timeout" toml:"timeout"`
+	Interval duration    `yaml:"interval" json:"interval" toml:"interval"`
+	Auth     authSection `yaml:"auth,omitempty" json:"auth" toml:"auth,omitempty"`
+	Enabled  *bool       `yaml:"enabled,omitempty" json:"enabled,omitempty" toml:"enabled,omitempty"`
+}
+
+// authSection — token/password только ссылками env:NAME или file:/path.
+type authSection struct {
+	Type     string    `yaml:"type,omitempty" json:"type,omitempty" toml:"type,omitempty"`
+	Token    SecretRef `yaml:"token,omitempty" json:"token,omitempty" toml:"token,omitempty"`
+	Username string    `yaml:"username,omitempty" json:"username,omitempty" toml:"username,omitempty"`
+	Password SecretRef `yaml:"password,omitempty" json:"password,omitempty" toml:"password,omitempty"`
+}
+
+type supportSection struct {
+	urlSection        `yaml:",inline"`
+	ThroughputPerHour float64 `yaml:"throughput_per_hour" json:"throughput_per_hour" toml:"throughput_per_hour"`
+}
+
+type fetchSection struct {
+	Timeout       duration `yaml:"timeout" json:"timeout" toml:"timeout"`
+	ClientTimeout duration `yaml:"client_timeout" json:"client_timeout" toml:"client_timeout"`
+	Concurrency   int      `yaml:"concurrency" json:"concurrency" toml:"concurrency"`
+	MaxFileSize   int64    `yaml:"max_file_size" json:"max_file_size" toml:"max_file_size"`
+}
+
+type httpSection struct {
+	Addr              string          `yaml:"addr" json:"addr" toml:"addr"`
+	HandlerTimeout    duration        `yaml:"handler_timeout" json:"handler_timeout" toml:"handler_timeout"`
+	CacheTTL          duration        `yaml:"cache_ttl" json:"cache_ttl" toml:"cache_ttl"`
+	StreamHeartbeat   duration        `yaml:"stream_heartbeat" json:"stream_heartbeat" toml:"stream_heartbeat"`
+	StreamOrigins     pathList        `yaml:"stream_origins" json:"stream_origins" toml:"stream_origins"`
+	PartialResults    bool            `yaml:"partial_results" json:"partial_results" toml:"partial_results"`
+	ReadTimeout       duration        `yaml:"read_timeout" json:"read_timeout" toml:"read_timeout"`
+	WriteTimeout      duration        `yaml:"write_timeout" json:"write_timeout" toml:"write_timeout"`
+	ReadHeaderTimeout duration        `yaml:"read_header_timeout" json:"read_header_timeout" toml:"read_header_timeout"`
+	IdleTimeout       duration        `yaml:"idle_timeout" json:"idle_timeout" toml:"idle_timeout"`
+	Auth              httpAuthSection `yaml:"auth" json:"auth" toml:"auth"`
+	TLS               tlsSection      `yaml:"tls" json:"tls" toml:"tls"`
+	Limits            limitsSection   `yaml:"limits" json:"limits" toml:"limits"`
+}
+
+// limitsSection — ограничение нагрузки на API; 0 — без ограничения.
+type limitsSection struct {
+	ClientRate  float64 `yaml:"client_rate" json:"client_rate" toml:"client_rate"`
+	ClientBurst int     `yaml:"client_burst" json:"client_burst" toml:"client_burst"`
+	GlobalRate  float64 `yaml:"global_rate" json:"global_rate" toml:"global_rate"`
+	GlobalBurst int     `yaml:"global_burst" json:"global_burst" toml:"global_burst"`
+	MaxInFlight int     `yaml:"max_in_flight" json:"max_in_flight" toml:"max_in_flight"`
+}
+
+// tlsSection — HTTPS для API (см. tls.go); ciphers — строка через запятую или список, как file.
+type tlsSection struct {
+	Cert       string   `yaml:"cert" json:"cert" toml:"cert"`
+	Key        string   `yaml:"key" json:"key" toml:"key"`
+	ClientCA   string   `yaml:"client_ca" json:"client_ca" toml:"client_ca"`
+	MinVersion string   `yaml:"min_version" json:"min_version" toml:"min_version"`
+	Ciphers    pathList `yaml:"ciphers" json:"ciphers" toml:"ciphers"`
+}
+
+// httpAuthSection — доступ к HTTP API сервиса (см. apikeys.go).
+type httpAuthSection struct {
+	Keys     keyList  `yaml:"keys" json:"keys" toml:"keys"`
+	HMACSkew duration `yaml:"hmac_skew" json:"hmac_skew" toml:"hmac_skew"`
+}
+
+// tracingSection — трассировка (см. tracing.go).
+type tracingSection struct {
+	Endpoint    string   `yaml:"endpoint" json:"endpoint" toml:"endpoint"`
+	Service     string   `yaml:"service" json:"service" toml:"service"`
+	SampleRatio *float64 `yaml:"sample_ratio,omitempty" json:"sample_ratio,omitempty" toml:"sample_ratio,omitempty"` // nil — не задана
+}
+
+type configSection struct {
+	PollInterval duration `yaml:"poll_interval" json:"poll_interval" toml:"poll_interval"`
+}
+
+type collectSection struct {
+	Interval duration `yaml:"interval" json:"interval" toml:"interval"`
+	Jitter   duration `yaml:"jitter" json:"jitter" toml:"jitter"`
+}
+
+// fileCfg — корень структурированного конфига.
+type fileCfg struct {
+	SMS      fileSection    `yaml:"sms" json:"sms" toml:"sms"`
+	Voice    fileSection    `yaml:"voice" json:"voice" toml:"voice"`
+	Email    fileSection    `yaml:"email" json:"email" toml:"email"`
+	Billing  billingSection `yaml:"billing" json:"billing" toml:"billing"`
+	MMS      urlSection     `yaml:"mms" json:"mms" toml:"mms"`
+	Support  supportSection `yaml:"support" json:"support" toml:"support"`
+	Incident urlSection     `yaml:"incident" json:"incident" toml:"incident"`
+	HTTP     httpSection    `yaml:"http" json:"http" toml:"http"`
+	Fetch    fetchSection   `yaml:"fetch" json:"fetch" toml:"fetch"`
+	Config   configSection  `yaml:"config" json:"config" toml:"config"`
+	Collect  collectSection `yaml:"collect" json:"collect" toml:"collect"`
+	Tracing  tracingSection `yaml:"tracing" json:"tracing" toml:"tracing"`
+}
+
+// enabledRef — значение ключа enabled для секции: nil (ключ не выводится) у включённого источника.
+func enabledRef(disabled bool) *bool {
+	if !disabled {
+		return nil
+	}
+	off := false
+	return &off
+}
+
+func isDisabled(enabled *bool) bool { return enabled != nil && !*enabled }
+
+// newFileCfg заполняет секции текущими значениями CfgApp,
+// чтобы отсутствующие в файле ключи сохранили прежние (дефолтные) значения.
+func newFileCfg(c *CfgApp) fileCfg {
+	var f fileCfg
+	f.SMS = fileSection{pathList(c.FileSms), c.QuantSMSDataCol, duration(c.TimeoutSms), duration(c.IntervalSms), enabledRef(c.DisableSms)}
+	f.Voice = fileSection{pathList(c.FileVoiceCall), c.QuantVoiceDataCol, duration(c.TimeoutVoice), duration(c.IntervalVoice), enabledRef(c.DisableVoice)}
+	f.Email = fileSection{pathList(c.FileEmail), c.QuantEmailDataCol, duration(c.TimeoutEmail), duration(c.IntervalEmail), enabledRef(c.DisableEmail)}
+	f.Billing = billingSection{c.FileBillingState, duration(c.TimeoutBilling), duration(c.IntervalBilling), enabledRef(c.DisableBilling)}
+	f.MMS = urlSection{c.PathMmsData, duration(c.TimeoutMms), duration(c.IntervalMms), authSection(c.AuthMms), enabledRef(c.DisableMms)}
+	f.Support = supportSection{urlSection{c.PathSupportData, duration(c.TimeoutSupport), duration(c.IntervalSupport), authSection(c.AuthSupport), enabledRef(c.DisableSupport)},
+		c.SupportThroughputPerHour}
+	f.Incident = urlSection{c.PathIncidentData, duration(c.TimeoutIncident), duration(c.IntervalIncident), authSection(c.AuthIncident), enabledRef(c.DisableIncident)}
+	f.HTTP = httpSection{
+		Addr:              c.HTTPAddr,
+		HandlerTimeout:    duration(c.HandlerTimeout),
+		CacheTTL:          duration(c.CacheTTL),
+		StreamHeartbeat:   duration(c.StreamHeartbeat),
+		StreamOrigins:     pathList(c.StreamOrigins),
+		PartialResults:    c.PartialResults,
+		ReadTimeout:       duration(c.ServerReadTimeout),
+		WriteTimeout:      duration(c.ServerWriteTimeout),
+		ReadHeaderTimeout: duration(c.ServerReadHeaderTimeout),
+		IdleTimeout:       duration(c.ServerIdleTimeout),
+		Auth:              httpAuthSection{keyList(c.APIKeys), duration(c.AuthHMACSkew)},
+		Limits:            limitsSection{c.RateLimitClient, c.RateBurstClient, c.RateLimitGlobal, c.RateBurstGlobal, c.MaxInFlight},
+		TLS:               tlsSection{c.TLSCertFile, c.TLSKeyFile, c.TLSClientCA, c.TLSMinVersion, pathList(c.TLSCiphers)},
+	}
+	f.Fetch = fetchSection{
+		Timeout:       duration(c.FetchTimeout),
+		ClientTimeout: duration(c.HTTPClientTimeout),
+		Concurrency:   c.FetchConcurrency,
+		MaxFileSize:   c.MaxFileSize,
+	}
+	f.Config.PollInterval = duration(c.ConfigPollInterval)
+	f.Collect = collectSection{duration(c.CollectInterval), duration(c.CollectJitter)}
+	f.Tracing = tracingSection{c.TraceEndpoint, c.TraceService, c.TraceSampleRatio}
+	return f
+}
+
+// apply раскладывает секции по плоским полям CfgApp.
+func (f fileCfg) apply(c *CfgApp) {
+	c.FileSms, c.QuantSMSDataCol, c.TimeoutSms = string(f.SMS.File), f.SMS.Columns, time.Duration(f.SMS.Timeout)
+	c.FileVoiceCall, c.QuantVoiceDataCol, c.TimeoutVoice = string(f.Voice.File), f.Voice.Columns, time.Duration(f.Voice.Timeout)
+	c.FileEmail, c.QuantEmailDataCol, c.TimeoutEmail = string(f.Email.File), f.Email.Columns, time.Duration(f.Email.Timeout)
+	c.FileBillingState, c.TimeoutBilling = f.Billing.File, time.Duration(f.Billing.Timeout)
+	c.PathMmsData, c.TimeoutMms, c.AuthMms = f.MMS.URL, time.Duration(f.MMS.Timeout), Auth(f.MMS.Auth)
+	c.PathSupportData, c.TimeoutSupport, c.AuthSupport = f.Support.URL, time.Duration(f.Support.Timeout), Auth(f.Support.Auth)
+	c.SupportThroughputPerHour = f.Support.ThroughputPerHour
+	c.PathIncidentData, c.TimeoutIncident, c.AuthIncident = f.Incident.URL, time.Duration(f.Incident.Timeout), Auth(f.Incident.Auth)
+
+	c.DisableSms, c.DisableVoice, c.DisableEmail = isDisabled(f.SMS.Enabled), isDisabled(f.Voice.Enabled), isDisabled(f.Email.Enabled)
+	c.DisableBilling, c.DisableMms = isDisabled(f.Billing.Enabled), isDisabled(f.MMS.Enabled)
+	c.DisableSupport, c.DisableIncident = isDisabled(f.Support.Enabled), isDisabled(f.Incident.Enabled)
+
+	c.HTTPAddr = f.HTTP.Addr
+	c.HandlerTimeout = time.Duration(f.HTTP.HandlerTimeout)
+	c.CacheTTL = time.Duration(f.HTTP.CacheTTL)
+	c.StreamHeartbeat = time.Duration(f.HTTP.StreamHeartbeat)
+	c.StreamOrigins = string(f.HTTP.StreamOrigins)
+	c.PartialResults = f.HTTP.PartialResults
+	c.ServerReadTimeout = time.Duration(f.HTTP.ReadTimeout)
+	c.ServerWriteTimeout = time.Duration(f.HTTP.WriteTimeout)
+	c.ServerReadHeaderTimeout = time.Duration(f.HTTP.ReadHeaderTimeout)
+	c.ServerIdleTimeout = time.Duration(f.HTTP.IdleTimeout)
+	c.APIKeys, c.AuthHMACSkew = string(f.HTTP.Auth.Keys), time.Duration(f.HTTP.Auth.HMACSkew)
+	c.RateLimitClient, c.RateBurstClient = f.HTTP.Limits.ClientRate, f.HTTP.Limits.ClientBurst
+	c.RateLimitGlobal, c.RateBurstGlobal = f.HTTP.Limits.GlobalRate, f.HTTP.Limits.GlobalBurst
+	c.MaxInFlight = f.HTTP.Limits.MaxInFlight
+	c.TLSCertFile, c.TLSKeyFile, c.TLSClientCA = f.HTTP.TLS.Cert, f.HTTP.TLS.Key, f.HTTP.TLS.ClientCA
+	c.TLSMinVersion, c.TLSCiphers = f.HTTP.TLS.MinVersion, string(f.HTTP.TLS.Ciphers)
+
+	c.FetchTimeout = time.Duration(f.Fetch.Timeout)
+	c.HTTPClientTimeout = time.Duration(f.Fetch.ClientTimeout)
+	c.FetchConcurrency = f.Fetch.Concurrency
+	c.MaxFileSize = f.Fetch.MaxFileSize
+	c.ConfigPollInterval = time.Duration(f.Config.PollInterval)
+
+	c.CollectInterval, c.CollectJitter = time.Duration(f.Collect.Interval), time.Duration(f.Collect.Jitter)
+	c.IntervalSms, c.IntervalVoice = time.Duration(f.SMS.Interval), time.Duration(f.Voice.Interval)
+	c.IntervalEmail, c.IntervalBilling = time.Duration(f.Email.Interval), time.Duration(f.Billing.Interval)
+	c.IntervalMms, c.IntervalSupport = time.Duration(f.MMS.Interval), time.Duration(f.Support.Interval)
+	c.IntervalIncident = time.Duration(f.Incident.Interval)
+
+	c.TraceEndpoint, c.TraceService, c.TraceSampleRatio = f.Tracing.Endpoint, f.Tracing.Service, f.Tracing.SampleRatio
+}
+
+// Во всех трёх форматах неизвестные ключи — ошибка, как и в config.cfg.
+
+func decodeYAML(r io.Reader, c *CfgApp) error {
+	f := newFileCfg(c)
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && err != io.EOF { // io.EOF — пустой файл, дальше сработает Validate
+		return fmt.Errorf("yaml: %w", err)
+	}
+	f.apply(c)
+	return nil
+}
+
+func decodeJSON(r io.Reader, c *CfgApp) error {
+	f := newFileCfg(c)
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	f.apply(c)
+	return nil
+}
+
+func decodeTOML(r io.Reader, c *CfgApp) error {
+	f := newFileCfg(c)
+	md, err := toml.NewDecoder(r).Decode(&f)
+	if err != nil {
+		return fmt.Errorf("toml: %w", err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, 0, len(undecoded))
+		for _, k := range undecoded {
+			keys = append(keys, k.String())
+		}
+		return fmt.Errorf("toml: unknown keys %q", keys)
+	}
+	f.apply(c)
+	return nil
+}
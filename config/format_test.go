@@ -0,0 +1,192 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const validYAML = `
+sms:
+  file: sms.data
+  columns: 4
+voice:
+  file: voice.data
+  columns: 8
+email:
+  file: email.data
+  columns: 3
+billing:
+  file: billing.data
+mms:
+  url: "http://127.0.0.1:8383/mms"
+support:
+  url: "http://127.0.0.1:8383/support"
+incident:
+  url: "http://127.0.0.1:8383/accendent"
+http:
+  addr: "127.0.0.1:8282"
+`
+
+const validJSON = `{
+  "sms":      {"file": "sms.data", "columns": 4},
+  "voice":    {"file": "voice.data", "columns": 8},
+  "email":    {"file": "email.data", "columns": 3},
+  "billing":  {"file": "billing.data"},
+  "mms":      {"url": "http://127.0.0.1:8383/mms"},
+  "support":  {"url": "http://127.0.0.1:8383/support"},
+  "incident": {"url": "http://127.0.0.1:8383/accendent"},
+  "http":     {"addr": "127.0.0.1:8282"}
+}`
+
+const validTOML = `
+[sms]
+file = "sms.data"
+columns = 4
+
+[voice]
+file = "voice.data"
+columns = 8
+
+[email]
+file = "email.data"
+columns = 3
+
+[billing]
+file = "billing.data"
+
+[mms]
+url = "http://127.0.0.1:8383/mms"
+
+[support]
+url = "http://127.0.0.1:8383/support"
+
+[incident]
+url = "http://127.0.0.1:8383/accendent"
+
+[http]
+addr = "127.0.0.1:8282"
+`
+
+// все форматы должны давать тот же CfgApp, что и legacy config.cfg
+func TestLoad_StructuredFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"config.yaml", validYAML},
+		{"config.yml", validYAML},
+		{"config.json", validJSON},
+		{"config.toml", validTOML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeNamed(t, tt.name, tt.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *cfg != wantValid {
+				t.Fatalf("cfg mismatch:\n got: %+v\nwant: %+v", *cfg, wantValid)
+			}
+		})
+	}
+}
+
+func TestLoad_StructuredFormats_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "config.yaml",
+			content: strings.Replace(validYAML, "  columns: 4", "  colums: 4", 1),
+			wantErr: "field colums not found",
+		},
+		{
+			name:    "config.json",
+			content: strings.Replace(validJSON, `"columns": 4`, `"colums": 4`, 1),
+			wantErr: `unknown field "colums"`,
+		},
+		{
+			name:    "config.toml",
+			content: strings.Replace(validTOML, "columns = 4", "colums = 4", 1),
+			wantErr: "sms.colums",
+		},
+		{
+			// разбор прошёл, но обязательное поле пустое — ошибка Validate
+			name:    "config.yaml",
+			content: strings.Replace(validYAML, `addr: "127.0.0.1:8282"`, `addr: ""`, 1),
+			wantErr: "HTTPAddr is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeNamed(t, tt.name, tt.content))
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error %q does not contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+// sms/voice/email — несколько файлов: списком в yaml/json/toml, через запятую в config.cfg
+func TestLoad_FileLists(t *testing.T) {
+	const files = "sms.data, incoming/sms-*.data"
+	legacy := strings.Replace(validCfg, `FileSms         = "sms.data"`, `FileSms = "`+files+`"`, 1)
+	yaml := strings.Replace(validYAML, "  file: sms.data", `  file: ["sms.data", "incoming/sms-*.data"]`, 1)
+	json := strings.Replace(validJSON, `{"file": "sms.data", "columns": 4}`,
+		`{"file": ["sms.data", "incoming/sms-*.data"], "columns": 4}`, 1)
+	toml := strings.Replace(validTOML, "[sms]\nfile = \"sms.data\"", "[sms]\nfile = [\"sms.data\", \"incoming/sms-*.data\"]", 1)
+
+	want := wantValid
+	want.FileSms = files
+
+	for _, tt := range []struct{ name, content string }{
+		{"config.cfg", legacy},
+		{"config.yaml", yaml},
+		{"config.json", json},
+		{"config.toml", toml},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeNamed(t, tt.name, tt.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *cfg != want {
+				t.Fatalf("cfg mismatch:\n got: %+v\nwant: %+v", *cfg, want)
+			}
+		})
+	}
+}
+
+func TestLoad_FileLists_BadPattern(t *testing.T) {
+	content := strings.Replace(validCfg, `FileSms         = "sms.data"`, `FileSms = "sms.data, sms-[.data"`, 1)
+	_, err := Load(writeCfg(t, content))
+	if err == nil || !strings.Contains(err.Error(), `FileSms: bad pattern "sms-[.data"`) {
+		t.Fatalf("expected bad pattern error, got %v", err)
+	}
+}
+
+func TestLoad_PartialResults(t *testing.T) {
+	for _, tt := range []struct{ name, content string }{
+		{"config.cfg", validCfg + "PartialResults = true\n"},
+		{"config.yaml", strings.Replace(validYAML, `  addr: "127.0.0.1:8282"`, "  addr: \"127.0.0.1:8282\"\n  partial_results: true", 1)},
+		{"config.json", strings.Replace(validJSON, `{"addr": "127.0.0.1:8282"}`, `{"addr": "127.0.0.1:8282", "partial_results": true}`, 1)},
+		{"config.toml", validTOML + "partial_results = true\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeNamed(t, tt.name, tt.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := wantValid
+			want.PartialResults = true
+			if *cfg != want {
+				t.Fatalf("cfg mismatch:\n got: %+v\nwant: %+v", *cfg, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package config
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"main/internal/fileutil"
+)
+
+// Имена источников — используются в per-source настройках и логах.
+const (
+	SourceSMS      = "sms"
+	SourceMMS      = "mms"
+	SourceVoice    = "voice"
+	SourceEmail    = "email"
+	SourceBilling  = "billing"
+	SourceSupport  = "support"
+	SourceIncident = "incident"
+)
+
+// Sources — все источники в порядке секций ResultSetT.
+var Sources = []string{SourceSMS, SourceMMS, SourceVoice, SourceEmail, SourceBilling, SourceSupport, SourceIncident}
+
+// Enabled — опрашивается ли источник. nil-конфиг и неизвестное имя — включён.
+func (c *CfgApp) Enabled(source string) bool {
+	if c == nil {
+		return true
+	}
+	switch source {
+	case SourceSMS:
+		return !c.DisableSms
+	case SourceMMS:
+		return !c.DisableMms
+	case SourceVoice:
+		return !c.DisableVoice
+	case SourceEmail:
+		return !c.DisableEmail
+	case SourceBilling:
+		return !c.DisableBilling
+	case SourceSupport:
+		return !c.DisableSupport
+	case SourceIncident:
+		return !c.DisableIncident
+	}
+	return true
+}
+
+// Only — копия конфига, в которой из включённых источников остались только sources.
+// Планировщик так опрашивает источники, у которых подошёл срок, не трогая остальные.
+func (c *CfgApp) Only(sources ...string) *CfgApp {
+	cp := *c
+	for _, f := range []struct {
+		source  string
+		disable *bool
+	}{
+		{SourceSMS, &cp.DisableSms},
+		{SourceMMS, &cp.DisableMms},
+		{SourceVoice, &cp.DisableVoice},
+		{SourceEmail, &cp.DisableEmail},
+		{SourceBilling, &cp.DisableBilling},
+		{SourceSupport, &cp.DisableSupport},
+		{SourceIncident, &cp.DisableIncident},
+	} {
+		if !slices.Contains(sources, f.source) {
+			*f.disable = true
+		}
+	}
+	return &cp
+}
+
+// Значения по умолчанию для таймаутов и лимитов. Нулевое значение поля CfgApp означает «взять дефолт»,
+// поэтому &CfgApp{} в тестах и конфиги без этих ключей ведут себя как раньше.
+const (
+	DefaultFetchTimeout       = 3 * time.Second  // таймаут одного источника в GetResultData
+	DefaultHTTPClientTimeout  = 5 * time.Second  // http.Client для mms/support/incident
+	DefaultFetchConcurrency   = 7                // errgroup.SetLimit
+	DefaultHandlerTimeout     = 10 * time.Second // бюджет хендлера "/" на сбор данных
+	DefaultCacheTTL           = 10 * time.Second
+	DefaultStreamHeartbeat    = 15 * time.Second
+	DefaultReadTimeout        = 15 * time.Second
+	DefaultWriteTimeout       = 15 * time.Second
+	DefaultReadHeaderTimeout  = 5 * time.Second // защита от slowloris
+	DefaultIdleTimeout        = 60 * time.Second
+	DefaultMaxFileSize        = fileutil.DefaultMaxFile // 40 kB
+	DefaultSupportThroughput  = 18.0                    // тикетов в час на всю команду саппорта
+	DefaultConfigPollInterval = 2 * time.Second
+	DefaultHMACSkew           = 5 * time.Minute // расхождение часов клиента и сервиса для подписанных запросов
+)
+
+func orDefault[T int | int64 | float64 | time.Duration](v, def T) T {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+// SourceTimeout — таймаут источника: per-source значение, иначе FetchTimeout, иначе дефолт.
+// Безопасно вызывать на nil (тесты mainfetcher передают nil-конфиг).
+func (c *CfgApp) SourceTimeout(source string) time.Duration {
+	if c == nil {
+		return DefaultFetchTimeout
+	}
+	var v time.Duration
+	switch source {
+	case SourceSMS:
+		v = c.TimeoutSms
+	case SourceMMS:
+		v = c.TimeoutMms
+	case SourceVoice:
+		v = c.TimeoutVoice
+	case SourceEmail:
+		v = c.TimeoutEmail
+	case SourceBilling:
+		v = c.TimeoutBilling
+	case SourceSupport:
+		v = c.TimeoutSupport
+	case SourceIncident:
+		v = c.TimeoutIncident
+	}
+	return orDefault(v, orDefault(c.FetchTimeout, DefaultFetchTimeout))
+}
+
+func (c *CfgApp) ClientTimeout() time.Duration {
+	if c == nil {
+		return DefaultHTTPClientTimeout
+	}
+	return orDefault(c.HTTPClientTimeout, DefaultHTTPClientTimeout)
+}
+
+func (c *CfgApp) Concurrency() int {
+	if c == nil {
+		return DefaultFetchConcurrency
+	}
+	return orDefault(c.FetchConcurrency, DefaultFetchConcurrency)
+}
+
+func (c *CfgApp) HandlerBudget() time.Duration {
+	if c == nil {
+		return DefaultHandlerTimeout
+	}
+	return orDefault(c.HandlerTimeout, DefaultHandlerTimeout)
+}
+
+func (c *CfgApp) CacheLifetime() time.Duration {
+	if c == nil {
+		return DefaultCacheTTL
+	}
+	return orDefault(c.CacheTTL, DefaultCacheTTL)
+}
+
+func (c *CfgApp) Heartbeat() time.Duration {
+	if c == nil {
+		return DefaultStreamHeartbeat
+	}
+	return orDefault(c.StreamHeartbeat, DefaultStreamHeartbeat)
+}
+
+func (c *CfgApp) HMACSkew() time.Duration {
+	if c == nil {
+		return DefaultHMACSkew
+	}
+	return orDefault(c.AuthHMACSkew, DefaultHMACSkew)
+}
+
+// RateClient — скорость (запросов/с) и запас запросов с одного IP клиента; rate 0 — без ограничения.
+func (c *CfgApp) RateClient() (rate float64, burst int) {
+	if c == nil {
+		return 0, 0
+	}
+	return c.RateLimitClient, burstOf(c.RateLimitClient, c.RateBurstClient)
+}
+
+// RateGlobal — то же на весь сервис.
+func (c *CfgApp) RateGlobal() (rate float64, burst int) {
+	if c == nil {
+		return 0, 0
+	}
+	return c.RateLimitGlobal, burstOf(c.RateLimitGlobal, c.RateBurstGlobal)
+}
+
+// InFlightLimit — сколько запросов API обрабатывается одновременно; 0 — без ограничения.
+func (c *CfgApp) InFlightLimit() int {
+	if c == nil {
+		return 0
+	}
+	return c.MaxInFlight
+}
+
+// burstOf — запас по умолчанию: скорость, округлённая вверх (не меньше одного запроса).
+func burstOf(rate float64, burst int) int {
+	if burst > 0 || rate <= 0 {
+		return burst
+	}
+	return max(1, int(math.Ceil(rate)))
+}
+
+// ServerTimeouts — таймауты http.Server: read, write, read-header, idle.
+func (c *CfgApp) ServerTimeouts() (read, write, readHeader, idle time.Duration) {
+	if c == nil {
+		c = &CfgApp{}
+	}
+	return orDefault(c.ServerReadTimeout, DefaultReadTimeout),
+		orDefault(c.ServerWriteTimeout, DefaultWriteTimeout),
+		orDefault(c.ServerReadHeaderTimeout, DefaultReadHeaderTimeout),
+		orDefault(c.ServerIdleTimeout, DefaultIdleTimeout)
+}
+
+func (c *CfgApp) MaxFile() int64 {
+	if c == nil {
+		return DefaultMaxFileSize
+	}
+	return orDefault(c.MaxFileSize, DefaultMaxFileSize)
+}
+
+func (c *CfgApp) SupportThroughput() float64 {
+	if c == nil {
+		return DefaultSupportThroughput
+	}
+	return orDefault(c.SupportThroughputPerHour, DefaultSupportThroughput)
+}
+
+func (c *CfgApp) PollInterval() time.Duration {
+	if c == nil {
+		return DefaultConfigPollInterval
+	}
+	return orDefault(c.ConfigPollInterval, DefaultConfigPollInterval)
+}
+
+// Scheduled — включён ли фоновый сбор по расписанию (collect.interval > 0).
+func (c *CfgApp) Scheduled() bool {
+	return c != nil && c.CollectInterval > 0
+}
+
+// SourceInterval — интервал фонового сбора источника: per-source значение, иначе CollectInterval.
+// 0 — фоновый сбор выключен.
+func (c *CfgApp) SourceInterval(source string) time.Duration {
+	if !c.Scheduled() {
+		return 0
+	}
+	var v time.Duration
+	switch source {
+	case SourceSMS:
+		v = c.IntervalSms
+	case SourceMMS:
+		v = c.IntervalMms
+	case SourceVoice:
+		v = c.IntervalVoice
+	case SourceEmail:
+		v = c.IntervalEmail
+	case SourceBilling:
+		v = c.IntervalBilling
+	case SourceSupport:
+		v = c.IntervalSupport
+	case SourceIncident:
+		v = c.IntervalIncident
+	}
+	return orDefault(v, c.CollectInterval)
+}
@@ -0,0 +1,263 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// нулевой и nil-конфиг отдают дефолты — так ведут себя тесты с &CfgApp{}
+func TestLimits_Defaults(t *testing.T) {
+	for _, c := range []*CfgApp{nil, {}} {
+		if got := c.SourceTimeout(SourceSMS); got != DefaultFetchTimeout {
+			t.Errorf("SourceTimeout = %s, want %s", got, DefaultFetchTimeout)
+		}
+		if got := c.Concurrency(); got != DefaultFetchConcurrency {
+			t.Errorf("Concurrency = %d, want %d", got, DefaultFetchConcurrency)
+		}
+		if got := c.Heartbeat(); got != DefaultStreamHeartbeat {
+			t.Errorf("Heartbeat = %s, want %s", got, DefaultStreamHeartbeat)
+		}
+		if got := c.MaxFile(); got != DefaultMaxFileSize {
+			t.Errorf("MaxFile = %d, want %d", got, DefaultMaxFileSize)
+		}
+		r, w, rh, i := c.ServerTimeouts()
+		if r != DefaultReadTimeout || w != DefaultWriteTimeout || rh != DefaultReadHeaderTimeout || i != DefaultIdleTimeout {
+			t.Errorf("ServerTimeouts = %s %s %s %s", r, w, rh, i)
+		}
+	}
+}
+
+func TestLimits_SourceTimeoutOverride(t *testing.T) {
+	c := &CfgApp{FetchTimeout: 2 * time.Second, TimeoutMms: 500 * time.Millisecond}
+	if got := c.SourceTimeout(SourceMMS); got != 500*time.Millisecond {
+		t.Errorf("mms timeout = %s, want 500ms", got)
+	}
+	if got := c.SourceTimeout(SourceSMS); got != 2*time.Second {
+		t.Errorf("sms timeout = %s, want FetchTimeout 2s", got)
+	}
+}
+
+func TestLoad_Limits_AllFormats(t *testing.T) {
+	legacy := validCfg + `FetchTimeout = "2s"
+TimeoutSupport = "750ms"
+FetchConcurrency = 3
+CacheTTL = "1m"
+MaxFileSize = 1024
+SupportThroughputPerHour = 12.5
+`
+	yaml := validYAML + `fetch:
+  timeout: 2s
+  concurrency: 3
+  max_file_size: 1024
+`
+	yaml = strings.Replace(yaml, `  url: "http://127.0.0.1:8383/support"`, `  url: "http://127.0.0.1:8383/support"
+  timeout: 750ms
+  throughput_per_hour: 12.5`, 1)
+	yaml = strings.Replace(yaml, `  addr: "127.0.0.1:8282"`, `  addr: "127.0.0.1:8282"
+  cache_ttl: 1m`, 1)
+
+	json := strings.Replace(validJSON, `"support":  {"url": "http://127.0.0.1:8383/support"}`,
+		`"support":  {"url": "http://127.0.0.1:8383/support", "timeout": "750ms", "throughput_per_hour": 12.5}`, 1)
+	json = strings.Replace(json, `"http":     {"addr": "127.0.0.1:8282"}`,
+		`"http": {"addr": "127.0.0.1:8282", "cache_ttl": "1m"},
+  "fetch": {"timeout": "2s", "concurrency": 3, "max_file_size": 1024}`, 1)
+
+	toml := strings.Replace(validTOML, `url = "http://127.0.0.1:8383/support"`, `url = "http://127.0.0.1:8383/support"
+timeout = "750ms"
+throughput_per_hour = 12.5`, 1) + `cache_ttl = "1m"
+
+[fetch]
+timeout = "2s"
+concurrency = 3
+max_file_size = 1024
+`
+
+	want := wantValid
+	want.FetchTimeout = 2 * time.Second
+	want.TimeoutSupport = 750 * time.Millisecond
+	want.FetchConcurrency = 3
+	want.CacheTTL = time.Minute
+	want.MaxFileSize = 1024
+	want.SupportThroughputPerHour = 12.5
+
+	for _, tt := range []struct{ name, content string }{
+		{"config.cfg", legacy},
+		{"config.yaml", yaml},
+		{"config.json", json},
+		{"config.toml", toml},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeNamed(t, tt.name, tt.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *cfg != want {
+				t.Fatalf("cfg mismatch:\n got: %+v\nwant: %+v", *cfg, want)
+			}
+		})
+	}
+}
+
+func TestLimits_SourceInterval(t *testing.T) {
+	if (&CfgApp{IntervalSms: time.Second}).SourceInterval(SourceSMS) != 0 {
+		t.Fatalf("without collect.interval scheduling must stay off")
+	}
+	c := &CfgApp{CollectInterval: 30 * time.Second, IntervalBilling: 5 * time.Minute}
+	if got := c.SourceInterval(SourceBilling); got != 5*time.Minute {
+		t.Errorf("billing interval = %s, want 5m", got)
+	}
+	if got := c.SourceInterval(SourceSMS); got != 30*time.Second {
+		t.Errorf("sms interval = %s, want collect.interval 30s", got)
+	}
+
+	only := (&CfgApp{DisableVoice: true}).Only(SourceSMS, SourceVoice)
+	for _, source := range Sources {
+		if want := source == SourceSMS; only.Enabled(source) != want {
+			t.Errorf("Only: %s enabled = %v, want %v", source, only.Enabled(source), want)
+		}
+	}
+}
+
+func TestLoad_Schedule_AllFormats(t *testing.T) {
+	legacy := validCfg + `CollectInterval = "30s"
+CollectJitter = "5s"
+IntervalBilling = "5m"
+`
+	yaml := strings.Replace(validYAML, "billing:\n  file: billing.data", "billing:\n  file: billing.data\n  interval: 5m", 1) +
+		"collect:\n  interval: 30s\n  jitter: 5s\n"
+	json := strings.Replace(validJSON, `"billing":  {"file": "billing.data"}`,
+		`"billing":  {"file": "billing.data", "interval": "5m"},
+  "collect":  {"interval": "30s", "jitter": "5s"}`, 1)
+	toml := strings.Replace(validTOML, "[billing]\nfile = \"billing.data\"", "[billing]\nfile = \"billing.data\"\ninterval = \"5m\"", 1) +
+		"\n[collect]\ninterval = \"30s\"\njitter = \"5s\"\n"
+
+	want := wantValid
+	want.CollectInterval = 30 * time.Second
+	want.CollectJitter = 5 * time.Second
+	want.IntervalBilling = 5 * time.Minute
+
+	for _, tt := range []struct{ name, content string }{
+		{"config.cfg", legacy},
+		{"config.yaml", yaml},
+		{"config.json", json},
+		{"config.toml", toml},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeNamed(t, tt.name, tt.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *cfg != want {
+				t.Fatalf("cfg mismatch:\n got: %+v\nwant: %+v", *cfg, want)
+			}
+		})
+	}
+}
+
+func TestLoad_Limits_Invalid(t *testing.T) {
+	content := validCfg + `FetchTimeout = "soon"
+CacheTTL = "-1s"
+FetchConcurrency = -2
+CollectJitter = "-1s"
+`
+	_, err := Load(writeCfg(t, content))
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	for _, want := range []string{
+		`FetchTimeout: not a duration: "soon"`,
+		"CacheTTL must be >= 0, got -1s",
+		"FetchConcurrency must be >= 0, got -2",
+		"CollectJitter must be >= 0, got -1s",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err.Error(), want)
+		}
+	}
+}
+
+// выключенный источник не требует своих ключей и не опрашивается
+func TestLoad_DisabledSources(t *testing.T) {
+	legacy := strings.Replace(validCfg, `FileBillingState = "billing.data"`, `EnableBilling = false`, 1) +
+		"EnableVoice = false\n"
+	yaml := strings.Replace(validYAML, "billing:\n  file: billing.data", "billing:\n  enabled: false", 1)
+	yaml = strings.Replace(yaml, "voice:\n", "voice:\n  enabled: false\n", 1)
+	json := strings.Replace(validJSON, `"billing":  {"file": "billing.data"}`, `"billing": {"enabled": false}`, 1)
+	json = strings.Replace(json, `"voice":    {"file": "voice.data", "columns": 8}`,
+		`"voice": {"file": "voice.data", "columns": 8, "enabled": false}`, 1)
+	toml := strings.Replace(validTOML, "[billing]\nfile = \"billing.data\"", "[billing]\nenabled = false", 1)
+	toml = strings.Replace(toml, "[voice]\n", "[voice]\nenabled = false\n", 1)
+
+	want := wantValid
+	want.FileBillingState = ""
+	want.DisableBilling = true
+	want.DisableVoice = true
+
+	for _, tt := range []struct{ name, content string }{
+		{"config.cfg", legacy},
+		{"config.yaml", yaml},
+		{"config.json", json},
+		{"config.toml", toml},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeNamed(t, tt.name, tt.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *cfg != want {
+				t.Fatalf("cfg mismatch:\n got: %+v\nwant: %+v", *cfg, want)
+			}
+			if cfg.Enabled(SourceBilling) || cfg.Enabled(SourceVoice) || !cfg.Enabled(SourceSMS) {
+				t.Fatalf("Enabled: billing=%v voice=%v sms=%v", cfg.Enabled(SourceBilling), cfg.Enabled(SourceVoice), cfg.Enabled(SourceSMS))
+			}
+		})
+	}
+}
+
+func TestResolve_EnableOverride(t *testing.T) {
+	path := writeCfg(t, validCfg+"EnableSupport = false\n")
+	env := fakeEnv(map[string]string{
+		EnvConfigPath:                    path,
+		"STATECOLLECTOR_SUPPORT_ENABLED": "true", // env включает обратно
+	})
+	cfg, err := Resolve(env, parseFlags(t, "-incident.enabled=false"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Enabled(SourceSupport) || cfg.Enabled(SourceIncident) {
+		t.Fatalf("support enabled=%v incident enabled=%v", cfg.Enabled(SourceSupport), cfg.Enabled(SourceIncident))
+	}
+
+	if _, err := Load(writeCfg(t, validCfg+"EnableSms = maybe\n")); err == nil ||
+		!strings.Contains(err.Error(), `EnableSms: not a boolean: "maybe"`) {
+		t.Fatalf("expected boolean error, got %v", err)
+	}
+}
+
+func TestLimits_Rate(t *testing.T) {
+	tests := []struct {
+		name      string
+		c         *CfgApp
+		rate      float64
+		burst     int
+		globalMax int
+	}{
+		{"nil", nil, 0, 0, 0},
+		{"off", &CfgApp{}, 0, 0, 0},
+		{"burst from rate", &CfgApp{RateLimitClient: 2.5}, 2.5, 3, 0},
+		{"slow rate", &CfgApp{RateLimitClient: 0.1}, 0.1, 1, 0},
+		{"explicit burst", &CfgApp{RateLimitClient: 5, RateBurstClient: 20, RateLimitGlobal: 50}, 5, 20, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, burst := tt.c.RateClient()
+			if rate != tt.rate || burst != tt.burst {
+				t.Fatalf("RateClient = %g, %d; want %g, %d", rate, burst, tt.rate, tt.burst)
+			}
+			if _, gb := tt.c.RateGlobal(); gb != tt.globalMax {
+				t.Fatalf("RateGlobal burst = %d, want %d", gb, tt.globalMax)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Адрес источника (FileSms, FileVoice, FileEmail, FileBillingState, PathMmsData, PathSupportData, PathIncidentData)
+задаётся URI, схема выбирает способ доставки, формат данных секции при этом не меняется:
+
+	file://sms.data, file:///data/sms-*.data — локальный файл (для sms/voice/email — список/glob через запятую)
+	http://host/sms, https://host/sms           — GET по HTTP (для mms/support/incident — с авторизацией Auth*)
+	exec:///usr/local/bin/get-sms --region eu   — stdout команды; без shell, аргументы через пробел
+	                                              (кавычек и экранирования нет, поэтому они запрещены:
+	                                              аргумент с пробелом — через скрипт-обёртку)
+
+Для файловых секций (sms/voice/email/billing) путь без схемы — по-прежнему локальный файл.
+Для mms/support/incident схема обязательна, чтобы опечатка в URL не превратилась в чтение файла.
+*/
+
+// Схемы адреса источника; https — тоже SchemeHTTP.
+const (
+	SchemeFile = "file"
+	SchemeHTTP = "http"
+	SchemeExec = "exec"
+)
+
+// Location разбирает адрес источника на схему и цель:
+// для file — путь (или список путей), для http — URL целиком, для exec — командная строка.
+// Адрес без известной схемы возвращается как путь к файлу, ok=false означает, что схема не была указана явно.
+func Location(loc string) (scheme, target string, ok bool) {
+	switch {
+	case strings.HasPrefix(loc, "http://"), strings.HasPrefix(loc, "https://"):
+		return SchemeHTTP, loc, true
+	case strings.HasPrefix(loc, "file://"):
+		return SchemeFile, strings.TrimPrefix(loc, "file://"), true
+	case strings.HasPrefix(loc, "exec://"):
+		return SchemeExec, strings.TrimSpace(strings.TrimPrefix(loc, "exec://")), true
+	default:
+		return SchemeFile, loc, false
+	}
+}
+
+// locKind — какие адреса допустимы в секции (см. checkLocation).
+type locKind int
+
+const (
+	locURL   locKind = iota // mms/support/incident: схема обязательна
+	locFile                 // billing: путь без схемы — локальный файл, но ровно один (без списков и glob)
+	locFiles                // sms/voice/email: путь без схемы — файл, допустимы списки и glob через запятую
+)
+
+// checkLocation проверяет адрес источника секции вида kind.
+func checkLocation(loc string, kind locKind) error {
+	scheme, target, explicit := Location(loc)
+	if !explicit && kind == locURL {
+		// для HTTP-секций адрес без схемы — скорее всего опечатка в URL
+		return fmt.Errorf("invalid URL %q: scheme must be http, https, file or exec", loc)
+	}
+	switch scheme {
+	case SchemeHTTP:
+		return checkHTTPURL(target)
+	case SchemeExec:
+		if target == "" {
+			return fmt.Errorf("invalid URL %q: empty command", loc)
+		}
+		// команда режется по пробелам (strings.Fields): кавычки не сгруппируют аргумент, а молча попадут в argv
+		if strings.ContainsAny(target, "\"'\\") {
+			return fmt.Errorf("invalid URL %q: quotes and escapes are not supported, arguments are split on spaces", loc)
+		}
+	case SchemeFile:
+		if target == "" {
+			return fmt.Errorf("invalid URL %q: empty path", loc)
+		}
+		switch kind {
+		case locFiles:
+			return checkPatterns(target)
+		case locFile:
+			if strings.ContainsAny(target, ",*?[") {
+				return fmt.Errorf("invalid path %q: exactly one file expected, lists and globs are not allowed", target)
+			}
+		}
+	}
+	return nil
+}
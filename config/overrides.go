@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+)
+
+/*
+Итоговый конфиг собирается слоями, каждый следующий перекрывает предыдущий:
+
+	Default() < файл (-config) < переменные окружения STATECOLLECTOR_* < флаги командной строки
+
+Переопределяются только явно заданные значения: пустая переменная окружения
+или флаг, которого нет в командной строке, не затирают значение из файла.
+Полный список ключей/переменных/флагов — таблица fields в config.go.
+*/
+
+// EnvConfigPath — переменная окружения с путём к файлу конфига (флаг -config приоритетнее).
+const EnvConfigPath = envPrefix + "CONFIG"
+
+// DefaultPath — файл конфига по умолчанию, относительно рабочего каталога.
+const DefaultPath = "config.cfg"
+
+// Default возвращает значения по умолчанию — такие же, как в поставляемом config.cfg.
+func Default() *CfgApp {
+	return &CfgApp{
+		FileSms:           "sms.data",
+		QuantSMSDataCol:   minSMSDataCol,
+		PathMmsData:       "http://127.0.0.1:8383/mms",
+		FileVoiceCall:     "voice.data",
+		QuantVoiceDataCol: minVoiceDataCol,
+		FileEmail:         "email.data",
+		QuantEmailDataCol: minEmailDataCol,
+		FileBillingState:  "billing.data",
+		PathSupportData:   "http://127.0.0.1:8383/support",
+		PathIncidentData:  "http://127.0.0.1:8383/accendent",
+		HTTPAddr:          "127.0.0.1:8282",
+
+		FetchTimeout:             DefaultFetchTimeout,
+		HTTPClientTimeout:        DefaultHTTPClientTimeout,
+		FetchConcurrency:         DefaultFetchConcurrency,
+		HandlerTimeout:           DefaultHandlerTimeout,
+		CacheTTL:                 DefaultCacheTTL,
+		StreamHeartbeat:          DefaultStreamHeartbeat,
+		ServerReadTimeout:        DefaultReadTimeout,
+		ServerWriteTimeout:       DefaultWriteTimeout,
+		ServerReadHeaderTimeout:  DefaultReadHeaderTimeout,
+		ServerIdleTimeout:        DefaultIdleTimeout,
+		MaxFileSize:              DefaultMaxFileSize,
+		SupportThroughputPerHour: DefaultSupportThroughput,
+		ConfigPollInterval:       DefaultConfigPollInterval,
+		AuthHMACSkew:             DefaultHMACSkew,
+	}
+}
+
+// Flags — флаги командной строки для всех полей CfgApp плюс -config.
+type Flags struct {
+	fs     *flag.FlagSet
+	path   string
+	values map[string]*string // имя флага → значение; учитываются только заданные явно
+}
+
+// RegisterFlags регистрирует во fs флаг -config и по флагу на каждое поле CfgApp (например -http.addr).
+// Значения читаются после fs.Parse() через Resolve.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs, values: make(map[string]*string, len(fields))}
+	fs.StringVar(&f.path, "config", DefaultPath, "path to config file (.cfg|.yaml|.yml|.json|.toml), env "+EnvConfigPath)
+	for _, fl := range fields {
+		f.values[fl.flag] = fs.String(fl.flag, "", fl.usage+" (overrides "+fl.key+", env "+fl.env()+")")
+	}
+	return f
+}
+
+// set возвращает только те флаги, что явно заданы в командной строке.
+func (f *Flags) set() map[string]string {
+	out := make(map[string]string)
+	f.fs.Visit(func(fl *flag.Flag) {
+		if v, ok := f.values[fl.Name]; ok {
+			out[fl.Name] = *v
+		}
+	})
+	return out
+}
+
+// Path — файл конфига: флаг -config, иначе STATECOLLECTOR_CONFIG, иначе config.cfg.
+func (f *Flags) Path(lookupEnv func(string) (string, bool)) string {
+	explicit := false
+	f.fs.Visit(func(fl *flag.Flag) {
+		if fl.Name == "config" {
+			explicit = true
+		}
+	})
+	if !explicit && lookupEnv != nil {
+		if p, ok := lookupEnv(EnvConfigPath); ok && p != "" {
+			return p
+		}
+	}
+	return f.path
+}
+
+// Resolve собирает итоговый конфиг: Default() < файл < STATECOLLECTOR_* < флаги, и валидирует его.
+// lookupEnv — обычно os.LookupEnv (в тестах подменяется). flags может быть nil.
+// Как и Load, возвращает все найденные проблемы одной ошибкой.
+func Resolve(lookupEnv func(string) (string, bool), flags *Flags) (*CfgApp, error) {
+	path := DefaultPath
+	if flags != nil {
+		path = flags.Path(lookupEnv)
+	} else if p, ok := lookupEnv(EnvConfigPath); ok && p != "" {
+		path = p
+	}
+
+	cfgApp := Default()
+	var errs []error
+
+	if err := readFile(path, cfgApp); err != nil {
+		errs = append(errs, fmt.Errorf("config %s: %w", path, err))
+	}
+
+	for _, fl := range fields {
+		if v, ok := lookupEnv(fl.env()); ok && v != "" {
+			if err := fl.set(cfgApp, v); err != nil {
+				errs = append(errs, fmt.Errorf("env %s: %w", fl.env(), err))
+			}
+		}
+	}
+
+	if flags != nil {
+		set := flags.set()
+		for _, fl := range fields {
+			if v, ok := set[fl.flag]; ok {
+				if err := fl.set(cfgApp, v); err != nil {
+					errs = append(errs, fmt.Errorf("flag -%s: %w", fl.flag, err))
+				}
+			}
+		}
+	}
+
+	if err := cfgApp.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return cfgApp, nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeEnv — подмена os.LookupEnv
+func fakeEnv(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+}
+
+// parseFlags регистрирует флаги конфига в отдельном FlagSet и разбирает args.
+func parseFlags(t *testing.T, args ...string) *Flags {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	f := RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	return f
+}
+
+func TestResolve_Precedence(t *testing.T) {
+	// файл задаёт только часть ключей — остальное берётся из Default()
+	path := writeCfg(t, `FileSms = "file-sms.data"
+HTTPAddr = "127.0.0.1:1111"
+PathMmsData = "http://file/mms"
+`)
+	env := fakeEnv(map[string]string{
+		"STATECOLLECTOR_HTTP_ADDR":     "127.0.0.1:2222",
+		"STATECOLLECTOR_MMS_URL":       "http://env/mms",
+		"STATECOLLECTOR_SUPPORT_URL":   "", // пустая переменная не перекрывает значение
+		"STATECOLLECTOR_EMAIL_COLUMNS": "5",
+	})
+	flags := parseFlags(t, "-config", path, "-http.addr", "127.0.0.1:3333")
+
+	cfg, err := Resolve(env, flags)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	def := Default()
+	checks := []struct {
+		name      string
+		got, want any
+	}{
+		{"default", cfg.FileVoiceCall, def.FileVoiceCall},
+		{"default_support_not_cleared_by_empty_env", cfg.PathSupportData, def.PathSupportData},
+		{"file_over_default", cfg.FileSms, "file-sms.data"},
+		{"env_over_file", cfg.PathMmsData, "http://env/mms"},
+		{"env_int", cfg.QuantEmailDataCol, 5},
+		{"flag_over_env", cfg.HTTPAddr, "127.0.0.1:3333"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestResolve_ConfigPathFromEnv(t *testing.T) {
+	path := writeNamed(t, "config.yaml", "http:\n  addr: \"127.0.0.1:4444\"\n")
+	env := fakeEnv(map[string]string{EnvConfigPath: path})
+
+	cfg, err := Resolve(env, parseFlags(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.HTTPAddr != "127.0.0.1:4444" {
+		t.Fatalf("HTTPAddr = %q, want value from %s", cfg.HTTPAddr, path)
+	}
+}
+
+func TestResolve_AggregatesOverrideErrors(t *testing.T) {
+	path := writeCfg(t, "FileSms = \"sms.data\"\n")
+	env := fakeEnv(map[string]string{"STATECOLLECTOR_SMS_COLUMNS": "many"})
+	flags := parseFlags(t, "-config", path, "-voice.columns", "2", "-incident.url", "ftp://x")
+
+	_, err := Resolve(env, flags)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	for _, want := range []string{
+		`env STATECOLLECTOR_SMS_COLUMNS: not an integer: "many"`,
+		"QuantVoiceDataCol must be >= 8, got 2",
+		"PathIncidentData: invalid URL",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err.Error(), want)
+		}
+	}
+}
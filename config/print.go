@@ -0,0 +1,35 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Write выводит конфиг в структурированном формате (yaml|json|toml) — в том же виде,
+// в каком его принимает Load, так что вывод можно сохранить и использовать как файл конфига.
+//
+// Значения секретов в CfgApp не хранятся (только ссылки env:/file:), поэтому в вывод они не попадают.
+func Write(w io.Writer, c *CfgApp, format string) error {
+	f := newFileCfg(c)
+	switch format {
+	case "", "yaml", "yml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(f); err != nil {
+			return fmt.Errorf("yaml: %w", err)
+		}
+		return enc.Close()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(f)
+	case "toml":
+		return toml.NewEncoder(w).Encode(f)
+	default:
+		return fmt.Errorf("unknown format %q, want yaml|json|toml", format)
+	}
+}
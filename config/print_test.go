@@ -0,0 +1,45 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// вывод Write должен читаться обратно через Load в тот же CfgApp
+func TestWrite_RoundTrip(t *testing.T) {
+	want := *Default()
+	want.TimeoutSms = 1500 * time.Millisecond
+	want.AuthMms = Auth{Type: AuthBearer, Token: "env:MMS_TOKEN"}
+	want.AuthSupport = Auth{Type: AuthBasic, Username: "collector", Password: "file:/run/secrets/support"}
+	want.DisableBilling = true
+	want.APIKeys = "dashboard:sms+mms:env:DASH_KEY, reports:*:cn:reports.internal"
+	want.TLSCertFile, want.TLSKeyFile, want.TLSClientCA = "tls.crt", "tls.key", "clients.pem"
+	want.TLSCiphers = "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"
+	want.StreamOrigins = "https://status.example.com, http://localhost:3000"
+	noSampling := 0.0 // явный 0 не должен превратиться в «не задано»
+	want.TraceEndpoint, want.TraceService, want.TraceSampleRatio = "http://otel-collector:4318/v1/traces", "sc", &noSampling
+
+	for _, format := range []string{"yaml", "json", "toml"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, &want, format); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			cfg, err := Load(writeNamed(t, "config."+format, buf.String()))
+			if err != nil {
+				t.Fatalf("load back: %v\n%s", err, buf.String())
+			}
+			if !reflect.DeepEqual(*cfg, want) {
+				t.Fatalf("round trip mismatch:\n got: %+v\nwant: %+v", *cfg, want)
+			}
+		})
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, Default(), "ini"); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
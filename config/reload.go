@@ -0,0 +1,136 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"main/sl"
+)
+
+// Holder хранит текущий конфиг. Читатели берут снимок через Load() на каждый запрос/сбор,
+// поэтому подмена конфига не затрагивает уже идущие запросы — они дорабатывают со старым снимком.
+type Holder struct {
+	p atomic.Pointer[CfgApp]
+}
+
+func NewHolder(c *CfgApp) *Holder {
+	h := &Holder{}
+	h.p.Store(c)
+	return h
+}
+
+// Load возвращает текущий снимок конфига. Снимок нельзя менять — только читать.
+func (h *Holder) Load() *CfgApp {
+	return h.p.Load()
+}
+
+// Store атомарно публикует новый конфиг.
+func (h *Holder) Store(c *CfgApp) {
+	h.p.Store(c)
+}
+
+// Watch перечитывает конфиг, когда меняется файл path (опрос mtime/размера раз в interval)
+// или приходит сигнал в hup (SIGHUP). Работает до отмены ctx.
+//
+// Новый конфиг собирает load (обычно Resolve — с теми же env и флагами, что при старте).
+// Если load вернул ошибку, в h остаётся прежний конфиг, а ошибка пишется в лог.
+func Watch(ctx context.Context, logger *slog.Logger, h *Holder, path string, interval time.Duration,
+	hup <-chan os.Signal, load func() (*CfgApp, error)) {
+
+	last := statFile(path)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logger.Info("config reload requested by signal", slog.String("path", path))
+			last = statFile(path)
+		case <-t.C:
+			cur := statFile(path)
+			if cur == last {
+				continue
+			}
+			last = cur
+			logger.Info("config file changed", slog.String("path", path))
+		}
+		reload(logger, h, load)
+	}
+}
+
+// reload подменяет конфиг в h, если новый успешно собран и прошёл Validate.
+func reload(logger *slog.Logger, h *Holder, load func() (*CfgApp, error)) {
+	next, err := load()
+	if err != nil {
+		logger.Error("config reload failed, keeping previous config", sl.Err(err))
+		return
+	}
+	for _, ch := range restartRequired(h.Load(), next) {
+		// значение уже используется (открытый сокет, TLS, провайдер трасс…) — новое вступит в силу только после перезапуска
+		logger.Warn("config change requires restart, keeping current value",
+			slog.String("key", ch.key), slog.Any("current", ch.current), slog.Any("new", ch.next))
+	}
+	h.Store(next)
+	logger.Info("config reloaded")
+}
+
+// restartOnly — настройки, которые читаются один раз при старте: адрес и TLS слушателя, таймауты http.Server,
+// трассировка (глобальный провайдер) и интервал опроса файла конфига. Reload их принимает, но не применяет.
+var restartOnly = []struct {
+	key string
+	get func(c *CfgApp) any
+}{
+	{"HTTPAddr", func(c *CfgApp) any { return c.HTTPAddr }},
+	{"TLSCertFile", func(c *CfgApp) any { return c.TLSCertFile }},
+	{"TLSKeyFile", func(c *CfgApp) any { return c.TLSKeyFile }},
+	{"TLSClientCA", func(c *CfgApp) any { return c.TLSClientCA }},
+	{"TLSMinVersion", func(c *CfgApp) any { return c.TLSMinVersion }},
+	{"TLSCiphers", func(c *CfgApp) any { return c.TLSCiphers }},
+	{"ServerReadTimeout", func(c *CfgApp) any { t, _, _, _ := c.ServerTimeouts(); return t }},
+	{"ServerWriteTimeout", func(c *CfgApp) any { _, t, _, _ := c.ServerTimeouts(); return t }},
+	{"ServerReadHeaderTimeout", func(c *CfgApp) any { _, _, t, _ := c.ServerTimeouts(); return t }},
+	{"ServerIdleTimeout", func(c *CfgApp) any { _, _, _, t := c.ServerTimeouts(); return t }},
+	{"TraceEndpoint", func(c *CfgApp) any { return c.TraceEndpoint }},
+	{"TraceService", func(c *CfgApp) any { return c.TraceServiceName() }},
+	{"TraceSampleRatio", func(c *CfgApp) any { return c.TraceSampling() }},
+	{"ConfigPollInterval", func(c *CfgApp) any { return c.PollInterval() }},
+}
+
+// restartChange — изменённая настройка из restartOnly.
+type restartChange struct {
+	key           string
+	current, next any
+}
+
+// restartRequired — настройки из restartOnly, которые в next отличаются от prev (prev == nil — первый конфиг).
+func restartRequired(prev, next *CfgApp) []restartChange {
+	if prev == nil {
+		return nil
+	}
+	var changes []restartChange
+	for _, f := range restartOnly {
+		if cur, nxt := f.get(prev), f.get(next); cur != nxt {
+			changes = append(changes, restartChange{f.key, cur, nxt})
+		}
+	}
+	return changes
+}
+
+// fileStamp — признак изменения файла; нулевое значение — файла нет.
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+func statFile(path string) fileStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{mod: fi.ModTime(), size: fi.Size()}
+}
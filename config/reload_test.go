@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+
+// waitFor ждёт, пока cond не станет true, иначе валит тест.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("condition not met in time")
+}
+
+// rewrite перезаписывает файл и сдвигает mtime, чтобы изменение было видно даже на ФС с грубым временем.
+func rewrite(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat config: %v", err)
+	}
+	mt := fi.ModTime().Add(time.Second)
+	if err := os.Chtimes(path, mt, mt); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+func TestWatch_FileChange_SwapAndRollback(t *testing.T) {
+	path := writeCfg(t, validCfg)
+	first, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	h := NewHolder(first)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Watch(ctx, testLogger, h, path, 10*time.Millisecond, nil, func() (*CfgApp, error) { return Load(path) })
+	time.Sleep(30 * time.Millisecond) // даём Watch запомнить исходное состояние файла
+
+	// 1) валидное изменение — подхватываем
+	rewrite(t, path, strings.Replace(validCfg, `"sms.data"`, `"sms2.data"`, 1))
+	waitFor(t, func() bool { return h.Load().FileSms == "sms2.data" })
+	good := h.Load()
+
+	// 2) невалидное изменение — остаётся предыдущий конфиг
+	rewrite(t, path, strings.Replace(validCfg, `QuantSMSDataCol = 4`, `QuantSMSDataCol = 0`, 1)+"// broken\n")
+	time.Sleep(100 * time.Millisecond)
+	if h.Load() != good {
+		t.Fatalf("invalid config must not replace the current one")
+	}
+}
+
+func TestWatch_Signal(t *testing.T) {
+	path := writeCfg(t, validCfg)
+	h := NewHolder(&CfgApp{})
+
+	hup := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// интервал большой — сработать может только сигнал
+	go Watch(ctx, testLogger, h, path, time.Hour, hup, func() (*CfgApp, error) { return Load(path) })
+
+	hup <- os.Interrupt
+	waitFor(t, func() bool { return h.Load().FileSms == "sms.data" })
+}
+
+// настройки, читаемые только при старте: reload их принимает, но предупреждает, что нужен перезапуск
+func TestReload_RestartOnlyWarns(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	prev := &CfgApp{HTTPAddr: ":8282", FileSms: "sms.data"}
+	h := NewHolder(prev)
+
+	tests := []struct {
+		name    string
+		next    CfgApp
+		wantKey string // "" — предупреждения быть не должно
+	}{
+		{"hot field", CfgApp{HTTPAddr: ":8282", FileSms: "sms2.data"}, ""},
+		{"server timeout", CfgApp{HTTPAddr: ":8282", ServerReadTimeout: time.Minute}, "key=ServerReadTimeout"},
+		{"tls client ca", CfgApp{HTTPAddr: ":8282", TLSClientCA: "clients.pem"}, "key=TLSClientCA"},
+		{"tracing endpoint", CfgApp{HTTPAddr: ":8282", TraceEndpoint: "http://otel:4318/v1/traces"}, "key=TraceEndpoint"},
+		{"poll interval", CfgApp{HTTPAddr: ":8282", ConfigPollInterval: time.Minute}, "key=ConfigPollInterval"},
+		{"addr", CfgApp{HTTPAddr: ":9090"}, "key=HTTPAddr"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			h.Store(prev)
+			next := tt.next
+			reload(logger, h, func() (*CfgApp, error) { return &next, nil })
+			if h.Load() != &next {
+				t.Fatalf("reload must still publish the new config")
+			}
+			warned := strings.Contains(logs.String(), "requires restart")
+			if tt.wantKey == "" {
+				if warned {
+					t.Fatalf("unexpected restart warning:\n%s", logs.String())
+				}
+				return
+			}
+			if !warned || !strings.Contains(logs.String(), tt.wantKey) {
+				t.Fatalf("no restart warning for %s:\n%s", tt.wantKey, logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+/*
+Секреты для HTTP-источников (mms, support, incident) в конфиге не хранятся — только ссылка на них:
+
+	env:MMS_TOKEN               — значение переменной окружения
+	file:/run/secrets/mms_token — содержимое файла (смонтированный секрет), хвостовой перевод строки отбрасывается
+
+Ссылка разыменовывается на каждый запрос, поэтому ротация файла секрета подхватывается без reload.
+Разыменованное значение имеет тип Secret и при выводе в лог/fmt/json всегда маскируется.
+*/
+
+// Типы авторизации в Auth.Type
+const (
+	AuthNone   = ""
+	AuthBearer = "bearer"
+	AuthBasic  = "basic"
+)
+
+const redacted = "******"
+
+// Secret — разыменованное значение секрета. String/LogValue/MarshalText маскируют значение,
+// получить его можно только явно через Reveal().
+type Secret string
+
+func (s Secret) Reveal() string                { return string(s) }
+func (Secret) String() string                  { return redacted }
+func (Secret) GoString() string                { return redacted }
+func (Secret) LogValue() slog.Value            { return slog.StringValue(redacted) }
+func (Secret) MarshalText() ([]byte, error)    { return []byte(redacted), nil }
+func (s Secret) Format(f fmt.State, verb rune) { _, _ = f.Write([]byte(redacted)) }
+
+// SecretRef — ссылка на секрет вида "env:NAME" или "file:/path".
+type SecretRef string
+
+// Resolve читает значение секрета по ссылке.
+func (r SecretRef) Resolve() (Secret, error) {
+	kind, name, err := r.parse()
+	if err != nil {
+		return "", err
+	}
+	switch kind {
+	case "env":
+		v, ok := os.LookupEnv(name)
+		if !ok || v == "" {
+			return "", fmt.Errorf("secret %s: env %s is not set", r, name)
+		}
+		return Secret(v), nil
+	default: // "file"
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return "", fmt.Errorf("secret %s: %w", r, err)
+		}
+		v := strings.TrimRight(string(b), "\r\n")
+		if v == "" {
+			return "", fmt.Errorf("secret %s: file is empty", r)
+		}
+		return Secret(v), nil
+	}
+}
+
+func (r SecretRef) parse() (kind, name string, err error) {
+	kind, name, ok := strings.Cut(string(r), ":")
+	if !ok || (kind != "env" && kind != "file") || name == "" {
+		return "", "", errors.New("secret must be a reference env:NAME or file:/path, plain values are not allowed")
+	}
+	return kind, name, nil
+}
+
+// Auth — авторизация к HTTP-источнику.
+type Auth struct {
+	Type     string    // "" (без авторизации) | bearer | basic
+	Token    SecretRef // для bearer
+	Username string    // для basic; логин секретом не считается
+	Password SecretRef // для basic
+}
+
+// Header возвращает значение заголовка Authorization ("" — авторизация не настроена).
+func (a Auth) Header() (Secret, error) {
+	switch a.Type {
+	case AuthBearer:
+		tok, err := a.Token.Resolve()
+		if err != nil {
+			return "", err
+		}
+		return Secret("Bearer " + tok.Reveal()), nil
+	case AuthBasic:
+		pass, err := a.Password.Resolve()
+		if err != nil {
+			return "", err
+		}
+		return Secret("Basic " + base64.StdEncoding.EncodeToString([]byte(a.Username+":"+pass.Reveal()))), nil
+	default:
+		return "", nil
+	}
+}
+
+// validate проверяет согласованность настроек; сами секреты не читаются.
+func (a Auth) validate(key string) []error {
+	var errs []error
+	refOK := func(name string, r SecretRef) {
+		if r == "" {
+			errs = append(errs, fmt.Errorf("%s%s is required for auth type %q", key, name, a.Type))
+			return
+		}
+		if _, _, err := r.parse(); err != nil {
+			errs = append(errs, fmt.Errorf("%s%s: %w", key, name, err))
+		}
+	}
+	switch a.Type {
+	case AuthNone:
+	case AuthBearer:
+		refOK("Token", a.Token)
+	case AuthBasic:
+		if a.Username == "" {
+			errs = append(errs, fmt.Errorf("%sUsername is required for auth type %q", key, a.Type))
+		}
+		refOK("Password", a.Password)
+	default:
+		errs = append(errs, fmt.Errorf("%sType must be one of bearer|basic, got %q", key, a.Type))
+	}
+	return errs
+}
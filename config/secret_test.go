@@ -0,0 +1,133 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretRef_Resolve(t *testing.T) {
+	t.Setenv("TEST_SC_TOKEN", "tok-from-env")
+	file := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(file, []byte("tok-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ref     SecretRef
+		want    string
+		wantErr bool
+	}{
+		{ref: "env:TEST_SC_TOKEN", want: "tok-from-env"},
+		{ref: SecretRef("file:" + file), want: "tok-from-file"},
+		{ref: "env:TEST_SC_ABSENT", wantErr: true},
+		{ref: "plain-token", wantErr: true},
+		{ref: "file:", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.ref.Resolve()
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: err = %v, wantErr %v", tt.ref, err, tt.wantErr)
+		}
+		if got.Reveal() != tt.want {
+			t.Fatalf("%s: got %q, want %q", tt.ref, got.Reveal(), tt.want)
+		}
+	}
+}
+
+// значение секрета не должно утекать ни через fmt, ни через slog, ни через json
+func TestSecret_Redacted(t *testing.T) {
+	s := Secret("topsecret")
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("x", slog.Any("secret", s))
+	js, _ := json.Marshal(struct{ S Secret }{s})
+
+	for _, out := range []string{
+		fmt.Sprint(s), fmt.Sprintf("%v %s %q %#v", s, s, s, s), buf.String(), string(js),
+	} {
+		if strings.Contains(out, "topsecret") {
+			t.Fatalf("secret leaked: %s", out)
+		}
+	}
+}
+
+func TestAuth_Header(t *testing.T) {
+	t.Setenv("TEST_SC_TOKEN", "abc")
+	t.Setenv("TEST_SC_PASS", "pass")
+
+	tests := []struct {
+		name string
+		auth Auth
+		want string
+	}{
+		{"none", Auth{}, ""},
+		{"bearer", Auth{Type: AuthBearer, Token: "env:TEST_SC_TOKEN"}, "Bearer abc"},
+		{"basic", Auth{Type: AuthBasic, Username: "user", Password: "env:TEST_SC_PASS"}, "Basic dXNlcjpwYXNz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.auth.Header()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Reveal() != tt.want {
+				t.Fatalf("Header() = %q, want %q", got.Reveal(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_Auth(t *testing.T) {
+	legacy := validCfg + `AuthMmsType = "bearer"
+AuthMmsToken = "env:MMS_TOKEN"
+`
+	yaml := strings.Replace(validYAML, `  url: "http://127.0.0.1:8383/mms"`, `  url: "http://127.0.0.1:8383/mms"
+  auth:
+    type: bearer
+    token: "env:MMS_TOKEN"`, 1)
+
+	want := wantValid
+	want.AuthMms = Auth{Type: AuthBearer, Token: "env:MMS_TOKEN"}
+
+	for _, tt := range []struct{ name, content string }{{"config.cfg", legacy}, {"config.yaml", yaml}} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeNamed(t, tt.name, tt.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *cfg != want {
+				t.Fatalf("cfg mismatch:\n got: %+v\nwant: %+v", *cfg, want)
+			}
+		})
+	}
+}
+
+func TestLoad_Auth_Invalid(t *testing.T) {
+	content := strings.Replace(validCfg, `"http://127.0.0.1:8383/support"`, `"http://user:pw@127.0.0.1:8383/support"`, 1) + `AuthMmsType = "bearer"
+AuthIncidentType = "digest"
+AuthSupportPassword = "plaintext"
+`
+	_, err := Load(writeCfg(t, content))
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	for _, want := range []string{
+		"AuthMmsToken is required",
+		`AuthIncidentType must be one of bearer|basic, got "digest"`,
+		"AuthSupportPassword: secret must be a reference",
+		"credentials in URL are not allowed",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err.Error(), want)
+		}
+	}
+	if strings.Contains(err.Error(), "pw@") || strings.Contains(err.Error(), "plaintext") {
+		t.Errorf("error leaks secret: %q", err.Error())
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+/*
+WebSocket-поток /api/v1/stream/ws открывается браузером с любой страницы, поэтому Origin проверяется:
+с чужого хоста (Origin не совпадает с Host запроса) поток доступен только из списка
+
+	http:
+	  stream_origins: [https://status.example.com, http://localhost:3000]
+
+Запрос без Origin (не из браузера) не ограничивается.
+*/
+
+// StreamAllowedOrigins — разрешённые Origin-ы других хостов в виде scheme://host[:port] в нижнем регистре.
+func (c *CfgApp) StreamAllowedOrigins() []string {
+	if c == nil || c.StreamOrigins == "" {
+		return nil
+	}
+	var out []string
+	for _, o := range strings.Split(c.StreamOrigins, ",") {
+		if o = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(o), "/")); o != "" {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+func (c *CfgApp) validateStream() []error {
+	var errs []error
+	for _, o := range c.StreamAllowedOrigins() {
+		u, err := url.Parse(o)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.User != nil {
+			errs = append(errs, fmt.Errorf("StreamOrigins: invalid origin %q: want scheme://host[:port]", o))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad_StreamOrigins(t *testing.T) {
+	cfg, err := Load(writeCfg(t, validCfg+`StreamOrigins = "https://Status.example.com/, http://localhost:3000"`+"\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := cfg.StreamAllowedOrigins(), []string{"https://status.example.com", "http://localhost:3000"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("origins = %q, want %q", got, want)
+	}
+	var none *CfgApp
+	if none.StreamAllowedOrigins() != nil {
+		t.Fatalf("nil cfg: want no origins")
+	}
+
+	for _, bad := range []string{"status.example.com", "ftp://files.example.com", "https://example.com/page"} {
+		_, err := Load(writeCfg(t, validCfg+`StreamOrigins = "`+bad+`"`+"\n"))
+		if err == nil || !strings.Contains(err.Error(), "StreamOrigins: invalid origin") {
+			t.Errorf("%q: err = %v", bad, err)
+		}
+	}
+}
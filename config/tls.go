@@ -0,0 +1,113 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+/*
+TLS для HTTP API (по умолчанию выключен — сервис слушает обычный HTTP, как раньше):
+
+	http:
+	  tls:
+	    cert: /etc/statecollector/tls.crt    # сертификат и ключ перечитываются при ротации файлов
+	    key: /etc/statecollector/tls.key
+	    min_version: "1.2"                   # 1.2 (по умолчанию) | 1.3
+	    ciphers: [TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256]
+	    client_ca: /etc/statecollector/clients.pem  # проверять клиентские сертификаты (ключи API с cert_cn)
+
+ciphers — только наборы TLS 1.2 из tls.CipherSuites() (небезопасные запрещены), пусто — выбор Go;
+у TLS 1.3 наборы не настраиваются. С TLS включается HTTP/2, которому нужен хотя бы один
+TLS_ECDHE_*_WITH_AES_128_GCM_SHA256.
+*/
+
+// Версии TLS в TLSMinVersion
+const (
+	TLS12 = "1.2"
+	TLS13 = "1.3"
+)
+
+// http2Ciphers — хотя бы один из них обязателен для HTTP/2 (RFC 7540, 9.2.2).
+var http2Ciphers = []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}
+
+// TLSEnabled — слушать HTTPS вместо HTTP.
+func (c *CfgApp) TLSEnabled() bool {
+	return c != nil && c.TLSCertFile != ""
+}
+
+// TLSVersion — минимальная версия TLS; пустое значение — TLS 1.2.
+func (c *CfgApp) TLSVersion() uint16 {
+	if c != nil && c.TLSMinVersion == TLS13 {
+		return tls.VersionTLS13
+	}
+	return tls.VersionTLS12
+}
+
+// TLSCipherSuites — разрешённые наборы шифров TLS 1.2; nil — выбор Go.
+// Конфиг после Validate уже проверен, поэтому неизвестные имена здесь просто пропускаются.
+func (c *CfgApp) TLSCipherSuites() []uint16 {
+	if c == nil {
+		return nil
+	}
+	ids, _ := cipherSuites(c.TLSCiphers)
+	return ids
+}
+
+// cipherSuites — id наборов по именам через запятую; возвращает и имена, которых нет среди безопасных.
+func cipherSuites(list string) (ids []uint16, unknown []string) {
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		i := slices.IndexFunc(tls.CipherSuites(), func(s *tls.CipherSuite) bool { return s.Name == name })
+		if i < 0 {
+			unknown = append(unknown, name)
+			continue
+		}
+		ids = append(ids, tls.CipherSuites()[i].ID)
+	}
+	return ids, unknown
+}
+
+// validateTLS — согласованность настроек TLS; файлы не читаются (их проверит старт сервера).
+func (c *CfgApp) validateTLS() []error {
+	var errs []error
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("TLSCertFile and TLSKeyFile must be set together"))
+	}
+	if !c.TLSEnabled() && (c.TLSClientCA != "" || c.TLSMinVersion != "" || c.TLSCiphers != "") {
+		errs = append(errs, fmt.Errorf("TLSClientCA, TLSMinVersion and TLSCiphers require TLSCertFile"))
+	}
+	switch c.TLSMinVersion {
+	case "", TLS12:
+	case TLS13:
+		if c.TLSCiphers != "" {
+			errs = append(errs, fmt.Errorf("TLSCiphers apply only to TLS 1.2, TLS 1.3 suites are not configurable"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("TLSMinVersion must be %s or %s, got %q", TLS12, TLS13, c.TLSMinVersion))
+	}
+	if c.TLSCiphers != "" {
+		ids, unknown := cipherSuites(c.TLSCiphers)
+		if len(unknown) > 0 {
+			errs = append(errs, fmt.Errorf("TLSCiphers: unknown or insecure suites %q", unknown))
+		}
+		if !slices.ContainsFunc(ids, func(id uint16) bool { return slices.Contains(http2Ciphers, id) }) {
+			errs = append(errs, fmt.Errorf("TLSCiphers: HTTP/2 requires %s or %s",
+				tls.CipherSuiteName(http2Ciphers[0]), tls.CipherSuiteName(http2Ciphers[1])))
+		}
+	}
+	// ключ с cert_cn без проверки клиентских сертификатов никогда не сработает
+	if c.TLSClientCA == "" {
+		keys, _ := parseAPIKeys(c.APIKeys)
+		for _, k := range keys {
+			if k.Kind == KeyCert {
+				errs = append(errs, fmt.Errorf("APIKeys: key %q uses cert_cn, TLSClientCA is required", k.Name))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"crypto/tls"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad_TLS(t *testing.T) {
+	yaml := validYAML + `  tls:
+    cert: tls.crt
+    key: tls.key
+    ciphers: [TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384]
+`
+	cfg, err := Load(writeNamed(t, "config.yaml", yaml))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.TLSEnabled() || cfg.TLSVersion() != tls.VersionTLS12 {
+		t.Fatalf("TLS enabled=%v version=%x", cfg.TLSEnabled(), cfg.TLSVersion())
+	}
+	want := []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}
+	if got := cfg.TLSCipherSuites(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("cipher suites = %v, want %v", got, want)
+	}
+	if (*CfgApp)(nil).TLSEnabled() || (&CfgApp{TLSMinVersion: TLS13}).TLSVersion() != tls.VersionTLS13 {
+		t.Fatalf("defaults mismatch")
+	}
+}
+
+func TestLoad_TLS_Invalid(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"key without cert", `TLSKeyFile = "tls.key"`, "TLSCertFile and TLSKeyFile must be set together"},
+		{"client ca without tls", `TLSClientCA = "ca.pem"`, "require TLSCertFile"},
+		{"bad version", "TLSCertFile = \"a\"\nTLSKeyFile = \"b\"\nTLSMinVersion = \"1.1\"", `TLSMinVersion must be 1.2 or 1.3, got "1.1"`},
+		{"ciphers with 1.3", "TLSCertFile = \"a\"\nTLSKeyFile = \"b\"\nTLSMinVersion = \"1.3\"\nTLSCiphers = \"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256\"",
+			"TLSCiphers apply only to TLS 1.2"},
+		{"insecure cipher", "TLSCertFile = \"a\"\nTLSKeyFile = \"b\"\nTLSCiphers = \"TLS_RSA_WITH_RC4_128_SHA, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256\"",
+			`unknown or insecure suites ["TLS_RSA_WITH_RC4_128_SHA"]`},
+		{"no http2 cipher", "TLSCertFile = \"a\"\nTLSKeyFile = \"b\"\nTLSCiphers = \"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384\"",
+			"HTTP/2 requires"},
+		{"cert key without client ca", "TLSCertFile = \"a\"\nTLSKeyFile = \"b\"\nAPIKeys = \"r:*:cn:reports\"",
+			`key "r" uses cert_cn, TLSClientCA is required`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeCfg(t, validCfg+tt.content+"\n"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error %v does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
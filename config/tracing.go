@@ -0,0 +1,50 @@
+package config
+
+import "fmt"
+
+/*
+Трассировка сбора (internal/tracing), по умолчанию выключена:
+
+	tracing:
+	  endpoint: http://otel-collector:4318/v1/traces   # OTLP/HTTP (protobuf); пусто — no-op провайдер
+	  service: statecollector                          # service.name
+	  sample_ratio: 0.1                                # доля записываемых трасс; не задана — все, 0 — ни одной
+
+Настройки, как и адрес HTTP, фиксируются при старте (reload их не меняет).
+*/
+
+// DefaultTraceService — service.name по умолчанию.
+const DefaultTraceService = "statecollector"
+
+// TracingEnabled — отправлять ли span-ы.
+func (c *CfgApp) TracingEnabled() bool {
+	return c != nil && c.TraceEndpoint != ""
+}
+
+func (c *CfgApp) TraceServiceName() string {
+	if c == nil || c.TraceService == "" {
+		return DefaultTraceService
+	}
+	return c.TraceService
+}
+
+// TraceSampling — доля записываемых трасс; не задана — 1 (все), 0 — ни одной.
+func (c *CfgApp) TraceSampling() float64 {
+	if c == nil || c.TraceSampleRatio == nil {
+		return 1
+	}
+	return *c.TraceSampleRatio
+}
+
+func (c *CfgApp) validateTracing() []error {
+	var errs []error
+	if c.TraceEndpoint != "" {
+		if err := checkHTTPURL(c.TraceEndpoint); err != nil {
+			errs = append(errs, fmt.Errorf("TraceEndpoint: %w", err))
+		}
+	}
+	if r := c.TraceSampleRatio; r != nil && (*r < 0 || *r > 1) {
+		errs = append(errs, fmt.Errorf("TraceSampleRatio must be within [0, 1], got %g", *r))
+	}
+	return errs
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoad_Tracing(t *testing.T) {
+	yaml := validYAML + `tracing:
+  endpoint: http://otel-collector:4318/v1/traces
+  sample_ratio: 0.25
+`
+	cfg, err := Load(writeNamed(t, "config.yaml", yaml))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.TracingEnabled() || cfg.TraceEndpoint != "http://otel-collector:4318/v1/traces" {
+		t.Fatalf("tracing enabled=%v endpoint=%q", cfg.TracingEnabled(), cfg.TraceEndpoint)
+	}
+	if cfg.TraceServiceName() != DefaultTraceService || cfg.TraceSampling() != 0.25 {
+		t.Fatalf("service=%q sampling=%g", cfg.TraceServiceName(), cfg.TraceSampling())
+	}
+	var off *CfgApp
+	if off.TracingEnabled() || off.TraceSampling() != 1 || (&CfgApp{}).TraceSampling() != 1 ||
+		(&CfgApp{TraceService: "sc"}).TraceServiceName() != "sc" {
+		t.Fatalf("defaults mismatch")
+	}
+}
+
+// sample_ratio: 0 — «не записывать ничего», а не «по умолчанию»
+func TestLoad_Tracing_ZeroRatio(t *testing.T) {
+	for name, path := range map[string]string{
+		"yaml":   writeNamed(t, "config.yaml", validYAML+"tracing:\n  sample_ratio: 0\n"),
+		"legacy": writeCfg(t, validCfg+"TraceSampleRatio = 0\n"),
+	} {
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if got := cfg.TraceSampling(); got != 0 {
+			t.Fatalf("%s: sampling = %g, want 0", name, got)
+		}
+	}
+}
+
+func TestLoad_Tracing_Invalid(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"relative endpoint", `TraceEndpoint = "otel-collector:4318"`, "TraceEndpoint"},
+		{"ratio above one", `TraceSampleRatio = 1.5`, "TraceSampleRatio must be within [0, 1], got 1.5"},
+		{"negative ratio", `TraceSampleRatio = -0.1`, "TraceSampleRatio must be within [0, 1], got -0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeCfg(t, validCfg+tt.content+"\n"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error %v does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
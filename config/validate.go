@@ -0,0 +1,189 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Минимальное кол-во колонок, которое ожидают парсеры smsdata/voicedata/emaildata:
+// они обращаются к полям по индексу, поэтому меньшее значение приведёт к панике.
+const (
+	minSMSDataCol   = 4
+	minVoiceDataCol = 8
+	minEmailDataCol = 3
+)
+
+// Validate проверяет обязательные поля и диапазоны значений.
+// Возвращает все найденные проблемы одной ошибкой (errors.Join) либо nil.
+func (c *CfgApp) Validate() error {
+	var errs []error
+
+	columns := func(key string, val, min int) {
+		if val < min {
+			errs = append(errs, fmt.Errorf("%s must be >= %d, got %d", key, min, val))
+		}
+	}
+	// адрес источника (см. location.go): kind — что допустимо в секции (URL, один файл, список файлов)
+	location := func(key, val string, kind locKind) {
+		if val == "" {
+			errs = append(errs, fmt.Errorf("%s is required", key))
+			return
+		}
+		if err := checkLocation(val, kind); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	// у выключенного источника файл/URL не нужны — например, в регионе нет billing-файла
+	if c.Enabled(SourceSMS) {
+		location("FileSms", c.FileSms, locFiles)
+		columns("QuantSMSDataCol", c.QuantSMSDataCol, minSMSDataCol)
+	}
+	if c.Enabled(SourceMMS) {
+		location("PathMmsData", c.PathMmsData, locURL)
+	}
+	if c.Enabled(SourceVoice) {
+		location("FileVoice", c.FileVoiceCall, locFiles)
+		columns("QuantVoiceDataCol", c.QuantVoiceDataCol, minVoiceDataCol)
+	}
+	if c.Enabled(SourceEmail) {
+		location("FileEmail", c.FileEmail, locFiles)
+		columns("QuantEmailDataCol", c.QuantEmailDataCol, minEmailDataCol)
+	}
+	if c.Enabled(SourceBilling) {
+		location("FileBillingState", c.FileBillingState, locFile)
+	}
+	if c.Enabled(SourceSupport) {
+		location("PathSupportData", c.PathSupportData, locURL)
+	}
+	if c.Enabled(SourceIncident) {
+		location("PathIncidentData", c.PathIncidentData, locURL)
+	}
+
+	if c.HTTPAddr == "" {
+		errs = append(errs, fmt.Errorf("HTTPAddr is required"))
+	} else if err := checkHostPort(c.HTTPAddr); err != nil {
+		errs = append(errs, fmt.Errorf("HTTPAddr: %w", err))
+	}
+
+	// таймауты и лимиты: 0 — значение по умолчанию, отрицательные — ошибка
+	for _, d := range []struct {
+		key string
+		val time.Duration
+	}{
+		{"FetchTimeout", c.FetchTimeout},
+		{"TimeoutSms", c.TimeoutSms},
+		{"TimeoutMms", c.TimeoutMms},
+		{"TimeoutVoice", c.TimeoutVoice},
+		{"TimeoutEmail", c.TimeoutEmail},
+		{"TimeoutBilling", c.TimeoutBilling},
+		{"TimeoutSupport", c.TimeoutSupport},
+		{"TimeoutIncident", c.TimeoutIncident},
+		{"HTTPClientTimeout", c.HTTPClientTimeout},
+		{"HandlerTimeout", c.HandlerTimeout},
+		{"CacheTTL", c.CacheTTL},
+		{"StreamHeartbeat", c.StreamHeartbeat},
+		{"ServerReadTimeout", c.ServerReadTimeout},
+		{"ServerWriteTimeout", c.ServerWriteTimeout},
+		{"ServerReadHeaderTimeout", c.ServerReadHeaderTimeout},
+		{"ServerIdleTimeout", c.ServerIdleTimeout},
+		{"ConfigPollInterval", c.ConfigPollInterval},
+		{"CollectInterval", c.CollectInterval},
+		{"CollectJitter", c.CollectJitter},
+		{"IntervalSms", c.IntervalSms},
+		{"IntervalMms", c.IntervalMms},
+		{"IntervalVoice", c.IntervalVoice},
+		{"IntervalEmail", c.IntervalEmail},
+		{"IntervalBilling", c.IntervalBilling},
+		{"IntervalSupport", c.IntervalSupport},
+		{"IntervalIncident", c.IntervalIncident},
+		{"AuthHMACSkew", c.AuthHMACSkew},
+	} {
+		if d.val < 0 {
+			errs = append(errs, fmt.Errorf("%s must be >= 0, got %s", d.key, d.val))
+		}
+	}
+	if c.FetchConcurrency < 0 {
+		errs = append(errs, fmt.Errorf("FetchConcurrency must be >= 0, got %d", c.FetchConcurrency))
+	}
+	for _, n := range []struct {
+		key string
+		val float64
+	}{
+		{"RateLimitClient", c.RateLimitClient},
+		{"RateBurstClient", float64(c.RateBurstClient)},
+		{"RateLimitGlobal", c.RateLimitGlobal},
+		{"RateBurstGlobal", float64(c.RateBurstGlobal)},
+		{"MaxInFlight", float64(c.MaxInFlight)},
+	} {
+		if n.val < 0 {
+			errs = append(errs, fmt.Errorf("%s must be >= 0, got %g", n.key, n.val))
+		}
+	}
+	if c.MaxFileSize < 0 {
+		errs = append(errs, fmt.Errorf("MaxFileSize must be >= 0, got %d", c.MaxFileSize))
+	}
+	errs = append(errs, c.AuthMms.validate("AuthMms")...)
+	errs = append(errs, c.AuthSupport.validate("AuthSupport")...)
+	errs = append(errs, c.AuthIncident.validate("AuthIncident")...)
+	errs = append(errs, validateAPIKeys(c.APIKeys)...)
+	errs = append(errs, c.validateTLS()...)
+	errs = append(errs, c.validateTracing()...)
+	errs = append(errs, c.validateStream()...)
+
+	if c.SupportThroughputPerHour < 0 {
+		errs = append(errs, fmt.Errorf("SupportThroughputPerHour must be >= 0, got %g", c.SupportThroughputPerHour))
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkHTTPURL — абсолютный http(s) URL с хостом.
+func checkHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid URL %q: scheme must be http or https", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid URL %q: missing host", raw)
+	}
+	if u.User != nil {
+		// логин/пароль в URL попали бы в конфиг и логи открытым текстом
+		return fmt.Errorf("invalid URL %q: credentials in URL are not allowed, use auth settings", u.Redacted())
+	}
+	return nil
+}
+
+// checkPatterns — список путей/glob-шаблонов через запятую; проверяем только синтаксис шаблонов,
+// наличие файлов — дело фетчера (файлы от апстрима могут появиться позже).
+func checkPatterns(list string) error {
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimSpace(p)
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("bad pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// checkHostPort — адрес вида host:port, порт 0..65535 (пустой host = все интерфейсы).
+func checkHostPort(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid host:port %q: %w", addr, err)
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid port in %q", addr)
+	}
+	return nil
+}
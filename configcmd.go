@@ -0,0 +1,15 @@
+package main
+
+import (
+	"os"
+
+	"main/config"
+)
+
+// configCmd — `statecollector config validate|print` перехватывается до разбора флагов сервиса,
+// сама логика и тесты — в config.RunCommand.
+func configCmd() {
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(config.RunCommand(os.Args[2:], os.LookupEnv, os.Stdout, os.Stderr))
+	}
+}
@@ -4,12 +4,11 @@ import (
 	"context"
 	"log/slog"
 	"main/config"
-	"main/internal/fileutil"
 	m "main/internal/model"
+	"main/internal/source"
 	"main/internal/textutil"
-	"main/sl"
+	"net/http"
 	"strconv"
-	"strings"
 )
 
 /*
@@ -29,55 +28,41 @@ import (
 	9. Все целочисленные данные должны быть приведены к типу int
 */
 
-func Fetch(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) ([]m.EmailData, error) {
-
-	// файл c voice
-	path := cfg.FileEmail
-
-	rf, err := fileutil.FileOpener(path)
+func Fetch(ctx context.Context, logger *slog.Logger, client *http.Client, cfg *config.CfgApp) ([]m.EmailData, error) {
 
+	// файл(ы) c email: один путь или несколько путей/glob-шаблонов через запятую, строки всех файлов склеиваются;
+	// либо http(s):// / exec:// с тем же форматом (см. config.Location).
+	// По отмене source.Lines останавливается сразу после чтения, чтобы вызывающий код не публиковал результат.
+	out, err := source.Lines(ctx, logger, client, config.SourceEmail, cfg.FileEmail, cfg.MaxFile(), func(line string) (m.EmailData, bool) {
+		return parseLine(line, cfg.QuantEmailDataCol)
+	})
 	if err != nil {
-		logger.Error("Error by opening file "+path, sl.Err(err))
 		return nil, err
 	}
+	return out, nil
 
-	/*Почему останавливаемся тут
-	Ранний выход без «публикации». Даже если парсинг и валидация быстрые, по отмене лучше вернуть ошибку и не делать больше ничего.
-	Тогда вызывающий код (горутина) не будет логировать “fetched” и не будет публиковать результат.
-	*/
-	if err := ctx.Err(); err != nil {
-		return nil, err
-	}
-
-	//преобразовать байты в массив строк, разделитель новая строка, затем разделитель ;
-	lines := strings.Split(string(rf), "\n")
-
-	out := make([]m.EmailData, 0, len(lines)) //данные будут срезе, len = , cap =	//так чтобы не было пустых элементов в срезе, cap сразу чтоб не переназначалась каждый раз память
-
-	for _, line := range lines {
-		splitted, ok := textutil.SplitN(line, ';', cfg.QuantEmailDataCol) //критерий 5,8 //перешли на более дешевый метод SplitN.
-		if !ok {
-			continue
-		}
-
-		DeliveryTime, err := strconv.Atoi(splitted[2])
-		//проверка на соответствие критерия 9 - поле не цифра
-		if err != nil {
-			continue
-		}
+}
 
-		//заполняем структуру провайдера
-		e := m.EmailData{
-			Country:      splitted[0],
-			Provider:     splitted[1],
-			DeliveryTime: DeliveryTime,
-		}
+// parseLine разбирает одну строку email-файла; false — строка повреждена или не прошла проверку.
+func parseLine(line string, quantCol int) (m.EmailData, bool) {
+	//разделитель ;
+	splitted, ok := textutil.SplitN(line, ';', quantCol) //критерий 5,8 //перешли на более дешевый метод SplitN.
+	if !ok {
+		return m.EmailData{}, false
+	}
 
-		if err := e.Validate(); err == nil { //проверка на соответствие критериям 4, 6, 7
-			out = append(out, e)
-		}
+	DeliveryTime, err := strconv.Atoi(splitted[2])
+	//проверка на соответствие критерия 9 - поле не цифра
+	if err != nil {
+		return m.EmailData{}, false
+	}
 
+	//заполняем структуру провайдера
+	e := m.EmailData{
+		Country:      splitted[0],
+		Provider:     splitted[1],
+		DeliveryTime: DeliveryTime,
 	}
-	return out, nil
 
+	return e, e.Validate() == nil //проверка на соответствие критериям 4, 6, 7
 }
@@ -66,13 +66,13 @@ RU;Comcast;408
 RU;AOL;254
 RU;GMX;246`
 
-	fileutil.FileOpener = func(_ string) ([]byte, error) {
+	fileutil.FileOpener = func(_ string, _ int64) ([]byte, error) {
 		return []byte(sample), nil
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	got, err := Fetch(ctx, testLogger(), makeCfg())
+	got, err := Fetch(ctx, testLogger(), nil, makeCfg())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -150,14 +150,14 @@ GB;Hotmail;300`,
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			fileutil.FileOpener = func(_ string) ([]byte, error) {
+			fileutil.FileOpener = func(_ string, _ int64) ([]byte, error) {
 				return []byte(tt.sample), nil
 			}
 
 			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 			defer cancel()
 
-			got, err := Fetch(ctx, testLogger(), makeCfg())
+			got, err := Fetch(ctx, testLogger(), nil, makeCfg())
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"log/slog"
 	"main/config"
+	"main/internal/health"
 	m "main/internal/model"
+	"main/internal/tracing"
 	"math"
+	"net/http"
 	"slices"
 	"strings"
 	"sync"
@@ -24,6 +27,7 @@ func GoFetch(
 	parentCtx context.Context,
 	logger *slog.Logger,
 	timeout time.Duration,
+	client *http.Client,
 	cfg *config.CfgApp,
 	rs *m.ResultSetT,
 	mu *sync.Mutex,
@@ -39,8 +43,9 @@ func GoFetch(
 
 		start := time.Now()
 
-		nonSortedData, err := fetchEmails(ctx, logger, cfg)
+		nonSortedData, err := fetchEmails(ctx, logger, client, cfg)
 		if err != nil {
+			health.Record(ctx, config.SourceEmail, start, 0, err)
 			// отличаем отмену от реальной ошибки
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				logger.Info("email cancelled", slog.Duration("dur", time.Since(start)))
@@ -53,12 +58,15 @@ func GoFetch(
 		// перед публикацией ещё раз убеждаемся, что не отменено
 		select {
 		case <-ctx.Done():
+			health.Record(ctx, config.SourceEmail, start, 0, ctx.Err())
 			logger.Info("email cancelled before publish", slog.Duration("dur", time.Since(start)))
 			return nil
 		default:
 		}
 		//все что ниже продолжит выполнение как по default
+		_, buildSpan := tracing.Start(ctx, "BuildSortedEmails")
 		sortedData := BuildSortedEmails(nonSortedData)
+		buildSpan.End()
 
 		// сохранить результат с защитой от гонок
 		mu.Lock()
@@ -71,6 +79,7 @@ func GoFetch(
 			total += len(part)
 		}
 
+		health.Record(ctx, config.SourceEmail, start, len(nonSortedData), nil)
 		logger.Info("email fetched",
 			slog.Int("count", total),
 			slog.Duration("dur", time.Since(start)),
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"main/config"
 	m "main/internal/model"
+	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
@@ -270,7 +271,7 @@ func TestGoFetch_SuccessPublishesRanked(t *testing.T) {
 		{Country: "US", Provider: "AOL", DeliveryTime: 200},
 	}
 
-	fetchEmails = func(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) ([]m.EmailData, error) {
+	fetchEmails = func(ctx context.Context, logger *slog.Logger, _ *http.Client, cfg *config.CfgApp) ([]m.EmailData, error) {
 		return sample, nil
 	}
 
@@ -281,7 +282,7 @@ func TestGoFetch_SuccessPublishesRanked(t *testing.T) {
 
 	g, ctx := errgroup.WithContext(context.Background())
 	// небольшой таймаут для внутреннего ctx (но он не должен сработать)
-	GoFetch(g, ctx, logger, 250*time.Millisecond, cfg, &rs, &mu)
+	GoFetch(g, ctx, logger, 250*time.Millisecond, nil, cfg, &rs, &mu)
 
 	if err := g.Wait(); err != nil {
 		t.Fatalf("GoFetch returned group error: %v", err)
@@ -303,7 +304,7 @@ func TestGoFetch_FetchError_NoPublish(t *testing.T) {
 	orig := fetchEmails
 	defer func() { fetchEmails = orig }()
 
-	fetchEmails = func(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) ([]m.EmailData, error) {
+	fetchEmails = func(ctx context.Context, logger *slog.Logger, _ *http.Client, cfg *config.CfgApp) ([]m.EmailData, error) {
 		return nil, io.EOF // любая ошибка
 	}
 
@@ -313,7 +314,7 @@ func TestGoFetch_FetchError_NoPublish(t *testing.T) {
 	var mu sync.Mutex
 
 	g, ctx := errgroup.WithContext(context.Background())
-	GoFetch(g, ctx, logger, 200*time.Millisecond, cfg, &rs, &mu)
+	GoFetch(g, ctx, logger, 200*time.Millisecond, nil, cfg, &rs, &mu)
 
 	if err := g.Wait(); err != nil {
 		t.Fatalf("GoFetch returned group error: %v", err)
@@ -332,7 +333,7 @@ func TestGoFetch_CancelBeforePublish(t *testing.T) {
 	defer func() { fetchEmails = orig }()
 
 	// Эмулируем «долгий» Fetch, чтобы таймаут внутри GoFetch успел истечь
-	fetchEmails = func(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) ([]m.EmailData, error) {
+	fetchEmails = func(ctx context.Context, logger *slog.Logger, _ *http.Client, cfg *config.CfgApp) ([]m.EmailData, error) {
 		time.Sleep(40 * time.Millisecond)
 		return []m.EmailData{
 			{Country: "RU", Provider: "Gmail", DeliveryTime: 10},
@@ -349,7 +350,7 @@ func TestGoFetch_CancelBeforePublish(t *testing.T) {
 
 	g, ctx := errgroup.WithContext(context.Background())
 	// ставим очень маленький timeout, чтобы ctx в GoFetch успел отмениться
-	GoFetch(g, ctx, logger, 10*time.Millisecond, cfg, &rs, &mu)
+	GoFetch(g, ctx, logger, 10*time.Millisecond, nil, cfg, &rs, &mu)
 
 	if err := g.Wait(); err != nil {
 		t.Fatalf("GoFetch returned group error: %v", err)
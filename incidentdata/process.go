@@ -5,7 +5,9 @@ import (
 	"errors"
 	"log/slog"
 	"main/config"
+	"main/internal/health"
 	m "main/internal/model"
+	"main/internal/tracing"
 	"net/http"
 	"sync"
 	"time"
@@ -49,6 +51,7 @@ func GoFetch(
 
 		nonSortedData, err := s.Fetch(ctx)
 		if err != nil {
+			health.Record(ctx, config.SourceIncident, start, 0, err)
 			// отличаем отмену от реальной ошибки
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				logger.Info("Incidents cancelled", slog.Duration("dur", time.Since(start)))
@@ -61,19 +64,23 @@ func GoFetch(
 		// перед публикацией ещё раз убеждаемся, что не отменено
 		select {
 		case <-ctx.Done():
+			health.Record(ctx, config.SourceIncident, start, 0, ctx.Err())
 			logger.Info("Incidents cancelled before publish", slog.Duration("dur", time.Since(start)))
 			return nil
 		default:
 		}
 
 		//все что ниже продолжит выполнение как по default
+		_, buildSpan := tracing.Start(ctx, "BuildSortedIncident")
 		sortedData := BuildSortedIncident(nonSortedData)
+		buildSpan.End()
 
 		// сохранить результат с защитой от гонок
 		mu.Lock()
 		rs.Incidents = sortedData
 		mu.Unlock()
 
+		health.Record(ctx, config.SourceIncident, start, len(nonSortedData), nil)
 		logger.Info("Incidents fetched",
 			slog.Duration("dur", time.Since(start)),
 		)
@@ -0,0 +1,157 @@
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"main/sl"
+)
+
+/*
+Файловый источник (sms, voice, email) может состоять из нескольких файлов: апстрим кладёт по файлу
+на провайдера или на час. В конфиге пути перечисляются через запятую, каждый может быть glob-шаблоном:
+
+	FileSms = "sms.data, incoming/sms-*.data"
+
+Строки всех файлов разбираются одним парсером и склеиваются в общий результат,
+по каждому файлу в лог пишется статистика разбора (FileStats).
+*/
+
+// ExpandPaths раскрывает список путей/шаблонов через запятую в список файлов (без повторов, в порядке перечисления,
+// совпадения шаблона — по алфавиту). У каждого элемента снимается префикс file:// ("file://a.data, file://b-*.data").
+// Шаблон без совпадений пропускается с предупреждением в лог (файлы за час ещё не выложены — не повод терять остальные);
+// ошибка — только если весь список не дал ни одного файла.
+// Путь без спецсимволов glob возвращается как есть: его существование проверит FileOpener (и его мок в тестах).
+func ExpandPaths(logger *slog.Logger, spec string) ([]string, error) {
+	var out, unmatched []string
+	seen := make(map[string]bool)
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimPrefix(strings.TrimSpace(part), "file://")
+		if part == "" {
+			continue
+		}
+		if !strings.ContainsAny(part, "*?[") {
+			add(part)
+			continue
+		}
+		matches, err := filepath.Glob(part) // совпадения уже отсортированы
+		if err != nil {
+			return nil, fmt.Errorf("bad pattern %q: %w", part, err)
+		}
+		if len(matches) == 0 {
+			logger.Warn("no files match pattern, skipped", slog.String("pattern", part))
+			unmatched = append(unmatched, part)
+			continue
+		}
+		for _, p := range matches {
+			add(p)
+		}
+	}
+	if len(out) == 0 {
+		if len(unmatched) > 0 {
+			return nil, fmt.Errorf("no files match %q", strings.Join(unmatched, ", "))
+		}
+		// пустой путь — как и раньше, ошибку вернёт FileOpener
+		out = append(out, spec)
+	}
+	return out, nil
+}
+
+// FileStats — итог разбора одного файла источника.
+type FileStats struct {
+	Path    string
+	Lines   int // непустые строки
+	Valid   int // строки, попавшие в результат
+	Skipped int // повреждённые/невалидные строки
+	Err     error
+}
+
+// ParseLines разбирает непустые строки data функцией parse, дописывает валидные в out и считает статистику в st.
+// Нужна и для файлов, и для построчных данных, пришедших по HTTP или из команды.
+func ParseLines[T any](data []byte, out []T, st *FileStats, parse func(line string) (T, bool)) []T {
+	lines := strings.Split(string(data), "\n")
+	if out == nil {
+		out = make([]T, 0, len(lines)) //cap сразу, чтоб не переназначалась каждый раз память
+	}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		st.Lines++
+		if v, ok := parse(line); ok {
+			out = append(out, v)
+			st.Valid++
+		}
+	}
+	st.Skipped = st.Lines - st.Valid
+	return out
+}
+
+// Log пишет статистику разбора в лог.
+func (st FileStats) Log(logger *slog.Logger, source string) {
+	logger.Info(source+" parsed",
+		slog.String("path", st.Path),
+		slog.Int("lines", st.Lines),
+		slog.Int("valid", st.Valid),
+		slog.Int("skipped", st.Skipped),
+	)
+}
+
+// ReadLines читает все файлы по spec (см. ExpandPaths) и разбирает каждую непустую строку функцией parse;
+// parse возвращает false для строки, которую нужно пропустить.
+// Файл, который не удалось прочитать, пропускается (ошибка — в лог и в FileStats); ошибка возвращается,
+// только если не прочитался ни один файл — тогда источник считается несобранным, как и раньше с одним файлом.
+// limit — лимит размера каждого файла (см. ReadFile).
+func ReadLines[T any](ctx context.Context, logger *slog.Logger, source, spec string, limit int64, parse func(line string) (T, bool)) ([]T, []FileStats, error) {
+	paths, err := ExpandPaths(logger, spec)
+	if err != nil {
+		logger.Error(source+" files: "+err.Error(), slog.String("spec", spec))
+		return nil, nil, err
+	}
+
+	var out []T
+	stats := make([]FileStats, 0, len(paths))
+	var errs []error
+	for _, path := range paths {
+		st := FileStats{Path: path}
+
+		rf, err := ReadFile(ctx, path, limit)
+		if err != nil {
+			logger.Error("Error by open/read file "+path, slog.String("source", source), sl.Err(err))
+			st.Err = err
+			stats = append(stats, st)
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+
+		// по отмене не разбираем дальше и ничего не публикуем
+		if err := ctx.Err(); err != nil {
+			return nil, stats, err
+		}
+
+		out = ParseLines(rf, out, &st, parse)
+		stats = append(stats, st)
+		st.Log(logger, source)
+	}
+
+	if len(errs) == len(paths) {
+		if len(errs) == 1 {
+			return nil, stats, errors.Unwrap(errs[0]) // один файл — ошибка как раньше, без префикса пути
+		}
+		return nil, stats, errors.Join(errs...)
+	}
+	if out == nil {
+		out = []T{}
+	}
+	return out, stats, nil
+}
@@ -0,0 +1,121 @@
+package fileutil
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// writeFiles создаёт файлы во временном каталоге и возвращает его путь.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestExpandPaths(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"sms-b.data": "x", "sms-a.data": "x", "voice.data": "x"})
+	j := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    []string
+		wantErr string
+	}{
+		{"single path as is", "sms.data", []string{"sms.data"}, ""},
+		{"empty spec left to FileOpener", "", []string{""}, ""},
+		{"glob sorted", j("sms-*.data"), []string{j("sms-a.data"), j("sms-b.data")}, ""},
+		{"list with dedup", j("sms-b.data") + ", " + j("sms-*.data"), []string{j("sms-b.data"), j("sms-a.data")}, ""},
+		{"glob without matches", j("mms-*.data"), nil, "no files match"},
+		{"unmatched glob skipped", j("mms-*.data") + ", " + j("voice.data"), []string{j("voice.data")}, ""},
+		{"all globs unmatched", j("mms-*.data") + "," + j("email-*.data"), nil, "no files match"},
+		{"file:// on each entry", "file://" + j("voice.data") + ", file://" + j("sms-a.data"), []string{j("voice.data"), j("sms-a.data")}, ""},
+		{"bad pattern", j("sms-[.data"), nil, "bad pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandPaths(testLogger, tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// строки всех файлов склеиваются, статистика — по каждому файлу
+func TestReadLines_MergeAndStats(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"sms-a.data": "a1\nbad\na2\n",
+		"sms-b.data": "b1\n\nbad\n",
+	})
+	parse := func(line string) (string, bool) { return line, line != "bad" }
+
+	got, stats, err := ReadLines(context.Background(), testLogger, "sms", filepath.Join(dir, "sms-*.data"), 0, parse)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"a1", "a2", "b1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	want := []FileStats{
+		{Path: filepath.Join(dir, "sms-a.data"), Lines: 3, Valid: 2, Skipped: 1},
+		{Path: filepath.Join(dir, "sms-b.data"), Lines: 2, Valid: 1, Skipped: 1},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+// нечитаемый файл пропускается, если есть другие; ошибка — только когда не прочитан ни один
+func TestReadLines_FileErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"ok.data": "x\n", "empty.data": ""})
+	parse := func(line string) (string, bool) { return line, true }
+	ctx := context.Background()
+
+	got, stats, err := ReadLines(ctx, testLogger, "sms", filepath.Join(dir, "empty.data")+","+filepath.Join(dir, "ok.data"), 0, parse)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || len(stats) != 2 || stats[0].Err == nil {
+		t.Fatalf("got %q, stats %+v", got, stats)
+	}
+
+	if _, _, err := ReadLines(ctx, testLogger, "sms", filepath.Join(dir, "empty.data"), 0, parse); err == nil ||
+		err.Error() != "opening file is empty" {
+		t.Fatalf("single file: err = %v, want plain FileOpener error", err)
+	}
+	if _, _, err := ReadLines(ctx, testLogger, "sms", filepath.Join(dir, "empty.data")+","+filepath.Join(dir, "nope.data"), 0, parse); err == nil {
+		t.Fatalf("expected error when no file is readable")
+	}
+}
+
+func TestReadLines_Cancelled(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"ok.data": "x\n"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := ReadLines(ctx, testLogger, "sms", filepath.Join(dir, "ok.data"), 0, func(l string) (string, bool) { return l, true }); err != context.Canceled {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
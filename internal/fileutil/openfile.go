@@ -1,9 +1,14 @@
 package fileutil
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+
+	"main/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 /*
@@ -14,10 +19,25 @@ import (
 const DefaultMaxFile = 10 << 12 // 40 kB
 var FileOpener = Openfile
 
+// ReadFile читает файл через FileOpener в span-е "read file" (путь и размер — атрибуты span-а).
+// limit — лимит размера из снимка конфига текущего сбора (cfg.MaxFile()); <= 0 — DefaultMaxFile.
+func ReadFile(ctx context.Context, path string, limit int64) ([]byte, error) {
+	_, span := tracing.Start(ctx, "read file", attribute.String("file.path", path))
+	defer span.End()
+	data, err := FileOpener(path, limit)
+	span.SetAttributes(attribute.Int("file.size", len(data)))
+	tracing.RecordError(span, err)
+	return data, err
+}
+
 // Openfile opens a file and check it size.
 // If the file does not exist or is empty, an appropriate message is printed to the console.
 // To get the size of the file, the Stat() method is used, which returns information about the file and an error.
-func Openfile(fileName string) (result []byte, err error) {
+// Файл больше limit байт — ошибка; limit <= 0 — DefaultMaxFile.
+func Openfile(fileName string, limit int64) (result []byte, err error) {
+	if limit <= 0 {
+		limit = DefaultMaxFile
+	}
 
 	//сначала проверяем что файл существует
 	file, err := os.Open(fileName)
@@ -40,7 +60,7 @@ func Openfile(fileName string) (result []byte, err error) {
 	//файл не пустой
 	if sizeFile == 0 {
 		return nil, fmt.Errorf("opening file is empty")
-	} else if fileInfo.Size() > DefaultMaxFile {
+	} else if fileInfo.Size() > limit {
 		return nil, fmt.Errorf("file too large: %d", fileInfo.Size())
 	} else {
 
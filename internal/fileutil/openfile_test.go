@@ -12,6 +12,7 @@ func TestReadfile(t *testing.T) {
 		name        string
 		file        string
 		writeToFile bool
+		limit       int64 // 0 — DefaultMaxFile
 		wantErr     bool
 	}{
 		{
@@ -29,6 +30,13 @@ func TestReadfile(t *testing.T) {
 			writeToFile: true,
 			wantErr:     false,
 		},
+		{
+			name:        "file over limit",
+			file:        "large.txt",
+			writeToFile: true,
+			limit:       8,
+			wantErr:     true,
+		},
 	}
 
 	//сами тесты
@@ -46,7 +54,7 @@ func TestReadfile(t *testing.T) {
 		}
 
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := Openfile(tt.file)
+			_, err := Openfile(tt.file, tt.limit)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("readfile() error = %v, wantErr %v", err, tt.wantErr)
 				return
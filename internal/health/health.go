@@ -0,0 +1,80 @@
+// Package health хранит итог последнего опроса каждого источника (пишут GoFetch, читает /status).
+// Реестр глобальный: фетчеры — функции пакетов без общего состояния.
+package health
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"main/internal/metrics"
+	"main/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SourceStatus — последний опрос источника.
+type SourceStatus struct {
+	LastSuccess  time.Time     // когда источник последний раз дал данные
+	LastError    string        // ошибка последней неудачной попытки ("" — ещё не было)
+	LastErrorAt  time.Time     //
+	LastDuration time.Duration // длительность последней попытки (успешной или нет)
+	Count        int           // кол-во записей в последнем успешном ответе
+}
+
+var (
+	mu      sync.RWMutex
+	sources = map[string]SourceStatus{}
+)
+
+// Record фиксирует результат попытки опроса source, начатой в start.
+// err == nil — успех с count записями. Отмена запроса клиентом (context.Canceled) не считается
+// ни успехом, ни ошибкой источника и не записывается; таймаут (DeadlineExceeded) — ошибка.
+// Каждая попытка, включая отменённые, учитывается и в метриках (internal/metrics),
+// а если к ctx привязан Report (WithReport) — и в нём, чтобы сбор знал причину пустой секции.
+// Итог (кол-во записей или ошибка) отмечается и в span-е опроса из ctx (tracing).
+func Record(ctx context.Context, source string, start time.Time, count int, err error) {
+	now := time.Now()
+	metrics.ObserveFetch(source, now.Sub(start), err)
+	if span := trace.SpanFromContext(ctx); err != nil {
+		tracing.RecordError(span, err)
+	} else {
+		span.SetAttributes(attribute.Int("records", count))
+	}
+	if r := reportFrom(ctx); r != nil {
+		r.set(source, err)
+	}
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	st := sources[source]
+	st.LastDuration = now.Sub(start)
+	if err != nil {
+		st.LastError = err.Error()
+		st.LastErrorAt = now
+	} else {
+		st.LastSuccess = now
+		st.Count = count
+	}
+	sources[source] = st
+}
+
+// Snapshot — копия состояний всех источников, которые уже опрашивались.
+func Snapshot() map[string]SourceStatus {
+	mu.RLock()
+	defer mu.RUnlock()
+	return maps.Clone(sources)
+}
+
+// Reset очищает реестр (для тестов).
+func Reset() {
+	mu.Lock()
+	clear(sources)
+	mu.Unlock()
+}
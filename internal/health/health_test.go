@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	Reset()
+	t.Cleanup(Reset)
+
+	start := time.Now().Add(-50 * time.Millisecond)
+	Record(context.Background(), "sms", start, 3, nil)
+	Record(context.Background(), "mms", start, 0, errors.New("mms: status 500"))
+	Record(context.Background(), "voice", start, 0, fmt.Errorf("read: %w", context.DeadlineExceeded))
+	Record(context.Background(), "email", start, 0, context.Canceled) // отмена клиентом — не событие источника
+
+	snap := Snapshot()
+	if _, ok := snap["email"]; ok {
+		t.Fatalf("canceled fetch must not be recorded: %+v", snap["email"])
+	}
+
+	tests := []struct {
+		source    string
+		wantOK    bool
+		wantErr   string
+		wantCount int
+	}{
+		{"sms", true, "", 3},
+		{"mms", false, "mms: status 500", 0},
+		{"voice", false, "read: context deadline exceeded", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			st, ok := snap[tt.source]
+			if !ok {
+				t.Fatalf("no status for %s", tt.source)
+			}
+			if !st.LastSuccess.IsZero() != tt.wantOK || st.LastError != tt.wantErr || st.Count != tt.wantCount {
+				t.Fatalf("status = %+v", st)
+			}
+			if st.LastDuration < 50*time.Millisecond {
+				t.Fatalf("LastDuration = %s, want >= 50ms", st.LastDuration)
+			}
+		})
+	}
+}
+
+// ошибка после успеха не стирает время и кол-во последнего успешного опроса
+func TestRecord_ErrorKeepsLastSuccess(t *testing.T) {
+	Reset()
+	t.Cleanup(Reset)
+
+	Record(context.Background(), "sms", time.Now(), 5, nil)
+	ok := Snapshot()["sms"].LastSuccess
+	Record(context.Background(), "sms", time.Now(), 0, errors.New("boom"))
+
+	st := Snapshot()["sms"]
+	if !st.LastSuccess.Equal(ok) || st.Count != 5 || st.LastError != "boom" || st.LastErrorAt.IsZero() {
+		t.Fatalf("status = %+v", st)
+	}
+}
@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"sync"
+)
+
+// Report — ошибки опроса источников в рамках одного сбора (GetResultData/GetSectionData), в отличие
+// от глобального реестра, который хранит последний опрос из любого запроса. Передаётся через контекст,
+// чтобы не менять сигнатуры GoFetch: Record пишет в Report из контекста задачи.
+type Report struct {
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+type reportKey struct{}
+
+// WithReport привязывает r к ctx: все Record с потомками ctx попадут в r.
+func WithReport(ctx context.Context, r *Report) context.Context {
+	return context.WithValue(ctx, reportKey{}, r)
+}
+
+func reportFrom(ctx context.Context) *Report {
+	if ctx == nil {
+		return nil
+	}
+	r, _ := ctx.Value(reportKey{}).(*Report)
+	return r
+}
+
+func (r *Report) set(source string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		delete(r.errs, source)
+		return
+	}
+	if r.errs == nil {
+		r.errs = map[string]error{}
+	}
+	r.errs[source] = err
+}
+
+// Err — ошибка последнего опроса source в этом сборе (nil — успех или источник не опрашивался).
+// Безопасен для nil.
+func (r *Report) Err(source string) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.errs[source]
+}
@@ -0,0 +1,153 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"main/config"
+	res "main/internal/mainfetcher"
+	"main/internal/metrics"
+	m "main/internal/model"
+	"main/sl"
+)
+
+/*
+REST API v1 — по эндпоинту на секцию, чтобы дашборды не тянули весь ResultSetT ради одной секции:
+
+	GET /api/v1/sms | mms | voice | email | billing | support | incidents
+
+Собирается (или берётся из кэша) только запрошенный источник. Ответ — в духе ResultT:
+
+	200 {"status": true,  "data": <секция>, "error": ""}
+	503 {"status": false, "error": "sms empty", "code": "empty"} — источник не ответил/данные невалидны
+	404 {"status": false, "error": "source disabled"}   — источник выключен в конфиге
+
+Старый GET "/" (полный APIResponse) остаётся как был.
+*/
+
+// apiPrefix — префикс версии API.
+const apiPrefix = "/api/v1/"
+
+// apiSections — имя секции в URL → источник в конфиге (в URL incidents, как в ResultSetT).
+var apiSections = []struct{ path, source string }{
+	{"sms", config.SourceSMS},
+	{"mms", config.SourceMMS},
+	{"voice", config.SourceVoice},
+	{"email", config.SourceEmail},
+	{"billing", config.SourceBilling},
+	{"support", config.SourceSupport},
+	{"incidents", config.SourceIncident},
+}
+
+// sectionGetter — данные секции и время, когда они собраны (для X-Data-Age).
+type sectionGetter func(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp, source string) (any, time.Time, error)
+
+// кэш секций: отдельно от полного кэша "/", с теми же правилами (CacheTTL, stale-while-revalidate, reload)
+var sectionCache = newSWRCache[any]("section")
+
+var fetchSection sectionGetter = func(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp, source string) (any, time.Time, error) {
+	if p, ok := latest(cfg); ok {
+		return snapshotSection(p, source)
+	}
+
+	// полный кэш "/" свежий и секция в нём собрана — источник повторно не дёргаем
+	if e, ok := fullCache.peek("/", cfg); ok && time.Since(e.at) < cfg.CacheLifetime() &&
+		(e.val.r.Status || e.val.r.Sections[source] == m.StatusOK) {
+		metrics.Cache("section", metrics.CacheHit)
+		return res.SectionData(e.val.rs, source), e.at, nil
+	}
+
+	return sectionCache.get(ctx, source, cfg, func(ctx context.Context, _ any, _ bool) (any, error) {
+		rs, err := res.GetSectionData(ctx, sl.FromContext(ctx, logger), cfg, source) // логгер сервера, а не запроса (см. refresh)
+		if err != nil {
+			return nil, err // ошибки не кэшируем: прежнее значение секции (если было) отдаётся дальше
+		}
+		return res.SectionData(rs, source), nil
+	})
+}
+
+// snapshotSection — секция из снимка фонового сбора; несобранная — с причиной из r.Errors.
+func snapshotSection(p *published, source string) (any, time.Time, error) {
+	switch p.r.Sections[source] {
+	case m.StatusOK, m.SectionStale:
+		return res.SectionData(p.rs, source), p.at, nil
+	case m.SectionDisabled:
+		return nil, p.at, res.ErrSourceDisabled
+	}
+	for _, e := range p.r.Errors {
+		if e.Section == source {
+			return nil, p.at, e
+		}
+	}
+	return nil, p.at, m.SectionError{Section: source, Code: m.ErrCodeEmpty, Message: source + " empty"}
+}
+
+type sectionResponse struct {
+	Status bool   `json:"status"`
+	Data   any    `json:"data,omitempty"`
+	Error  string `json:"error"`
+	Code   string `json:"code,omitempty"` // код причины, как в ResultT.Errors (timeout, unreachable, parse, empty ...)
+}
+
+// makeHandleSection — хендлер одной секции; бюджет и снимок конфига — как у "/".
+func makeHandleSection(logger *slog.Logger, cfg *config.Holder, source string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := sl.FromContext(r.Context(), logger) // логгер запроса с request_id (requestlog.go)
+		current := cfg.Load()
+		format, err := negotiate(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), current.HandlerBudget())
+		defer cancel()
+
+		data, at, err := fetchSection(ctx, logger, current, source)
+
+		// если клиент уже отвалился/таймаут — не пишем ответ
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		status, resp := http.StatusOK, sectionResponse{Status: true, Data: data}
+		if err != nil {
+			status = http.StatusServiceUnavailable
+			if errors.Is(err, res.ErrSourceDisabled) {
+				status = http.StatusNotFound
+			}
+			resp = sectionResponse{Error: err.Error()}
+			var se m.SectionError
+			if errors.As(err, &se) {
+				resp.Code = se.Code
+			}
+		}
+
+		setDataAge(w, at)
+		err = writeFormat(w, r, format, status, source, resp,
+			func() table {
+				if resp.Error != "" {
+					return table{Header: []string{"code", "error"}, Rows: [][]string{{resp.Code, resp.Error}}}
+				}
+				return sectionTable(data)
+			},
+			func() htmlPage {
+				s := htmlSection{Name: source, State: m.StatusOK, Code: resp.Code, Error: resp.Error, Table: sectionTable(data)}
+				if resp.Error != "" {
+					s.State = m.SectionMissing
+					if status == http.StatusNotFound {
+						s.State = m.SectionDisabled
+					}
+				}
+				return htmlPage{Title: "State collector: " + source, At: at, Sections: []htmlSection{s}}
+			})
+		if err != nil && logger != nil {
+			logger.Error("encode response", slog.String("source", source), slog.Any("err", err))
+		}
+	}
+}
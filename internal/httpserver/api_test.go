@@ -0,0 +1,117 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"main/config"
+	res "main/internal/mainfetcher"
+	m "main/internal/model"
+)
+
+func TestAPI_SectionRoutes(t *testing.T) {
+	orig := fetchSection
+	t.Cleanup(func() { fetchSection = orig })
+	fetchSection = func(_ context.Context, _ *slog.Logger, _ *config.CfgApp, source string) (any, time.Time, error) {
+		switch source {
+		case config.SourceVoice:
+			return nil, time.Time{}, m.SectionError{Section: source, Code: m.ErrCodeEmpty, Message: "voice_call empty"}
+		case config.SourceBilling:
+			return nil, time.Time{}, fmt.Errorf("billing: %w", res.ErrSourceDisabled)
+		}
+		return "data of " + source, time.Now(), nil
+	}
+	router := newRouter(nil, config.NewHolder(&config.CfgApp{}))
+
+	tests := []struct {
+		path        string
+		wantCode    int
+		wantStatus  bool
+		wantData    string
+		wantErr     string
+		wantErrCode string
+	}{
+		{"/api/v1/sms", http.StatusOK, true, "data of sms", "", ""},
+		{"/api/v1/mms", http.StatusOK, true, "data of mms", "", ""},
+		{"/api/v1/email", http.StatusOK, true, "data of email", "", ""},
+		{"/api/v1/support", http.StatusOK, true, "data of support", "", ""},
+		{"/api/v1/incidents", http.StatusOK, true, "data of incident", "", ""},
+		{"/api/v1/voice", http.StatusServiceUnavailable, false, "", "voice_call empty", m.ErrCodeEmpty},
+		{"/api/v1/billing", http.StatusNotFound, false, "", "billing: source disabled", ""},
+		{"/api/v1/fax", http.StatusNotFound, false, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rr.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.wantCode)
+			}
+			if tt.path == "/api/v1/fax" {
+				return // 404 от роутера, тело не JSON
+			}
+			if got := rr.Header().Get("Content-Type"); got != "application/json" {
+				t.Fatalf("Content-Type = %q", got)
+			}
+			var resp struct {
+				Status bool   `json:"status"`
+				Data   string `json:"data"`
+				Error  string `json:"error"`
+				Code   string `json:"code"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("bad json %q: %v", rr.Body.String(), err)
+			}
+			if resp.Status != tt.wantStatus || resp.Data != tt.wantData || resp.Error != tt.wantErr || resp.Code != tt.wantErrCode {
+				t.Fatalf("resp = %+v", resp)
+			}
+		})
+	}
+}
+
+// legacy "/" на месте и по-прежнему отдаёт полный APIResponse
+func TestAPI_LegacyRootKept(t *testing.T) {
+	orig := fetch
+	t.Cleanup(func() { fetch = orig })
+	fetch = func(context.Context, *slog.Logger, *config.CfgApp) (m.ResultSetT, m.ResultT, time.Time) {
+		return m.ResultSetT{Support: []int{1}}, m.ResultT{}, time.Now()
+	}
+
+	rr := httptest.NewRecorder()
+	newRouter(nil, config.NewHolder(&config.CfgApp{})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	var payload struct {
+		ResultSet m.ResultSetT `json:"resultSet"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil || len(payload.ResultSet.Support) != 1 {
+		t.Fatalf("legacy response %q: %v", rr.Body.String(), err)
+	}
+}
+
+// свежий кэш "/" с собранной секцией обслуживает и её — источник заново не опрашивается,
+// даже если другие секции в нём не собраны
+func TestFetchSection_FromFullCache(t *testing.T) {
+	cfg := &config.CfgApp{}
+	sms := [][]m.SMSData{{{Country: "RU", Provider: "Topolo"}}}
+	rs := m.ResultSetT{SMS: sms}
+	at := time.Now().Add(-time.Second)
+
+	fullCache.mu.Lock()
+	fullCache.entries["/"] = swrEntry[fullResult]{val: fullResult{rs, res.BuildResultT(rs, cfg, nil)}, cfg: cfg, at: at}
+	fullCache.mu.Unlock()
+	t.Cleanup(fullCache.reset)
+
+	got, gotAt, err := fetchSection(context.Background(), nil, cfg, config.SourceSMS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, sms) || !gotAt.Equal(at) {
+		t.Fatalf("got %#v at %s, want %#v at %s", got, gotAt, sms, at)
+	}
+}
@@ -0,0 +1,316 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"main/config"
+)
+
+/*
+Доступ к API по ключам из http.auth.keys (config/apikeys.go). Пока ключей нет — всё открыто, как раньше.
+Способ проверки — у каждого ключа свой, запрос может предъявить любой:
+
+	key  — Authorization: Bearer <ключ> или X-API-Key: <ключ>
+	hmac — X-Auth-Key: <имя ключа>, X-Auth-Timestamp: <unix-время, сек>, X-Auth-Nonce: <случайная строка>,
+	       X-Auth-Signature: hex(HMAC-SHA256(секрет, "<метод>\n<путь?query>\n<timestamp>\n<nonce>\n<sha256 тела>"));
+	       sha256 тела — hex, у GET/HEAD пустая строка; timestamp не дальше http.auth.hmac_skew от часов сервиса,
+	       nonce второй раз за это время не принимается (повтор перехваченного запроса — 401), секрет по сети не передаётся
+	cert — проверенный клиентский сертификат (mTLS) с CN из cert_cn; нужен TLS с проверкой клиентских сертификатов
+
+Без учётных данных или с неверными — 401. Ключ ограничен секциями (scopes):
+
+	/api/v1/<секция>          — секция должна быть в scopes, иначе 403
+	/api/v1/stream[/ws]       — все секции фильтра ?sections= (без фильтра — все секции)
+	"/"                       — все включённые источники
+	/status                   — любой действующий ключ; только источники из scopes
+	/metrics                  — только ключ со scope "*": счётчики и ошибки там по всем источникам сразу
+	/healthz, /readyz         — без ключа: пробы балансировщика/Kubernetes
+*/
+
+// publicPaths — маршруты без проверки ключа.
+var publicPaths = map[string]bool{"/healthz": true, "/readyz": true}
+
+// Заголовки подписанного (HMAC) запроса
+const (
+	headerAPIKey    = "X-API-Key"
+	headerAuthKey   = "X-Auth-Key"
+	headerAuthTS    = "X-Auth-Timestamp"
+	headerAuthNonce = "X-Auth-Nonce"
+	headerAuthSig   = "X-Auth-Signature"
+	wwwAuthenticate = `Bearer realm="statecollector"`
+)
+
+// Ограничения подписанных запросов
+const (
+	maxNonceLen   = 128
+	maxSignedBody = 1 << 20 // тело, от которого считается sha256 подписи
+	maxNonces     = 100_000 // nonce-ов в памяти одновременно; больше — 503, пока старые не истекут
+)
+
+var errBadCredentials = errors.New("invalid credentials")
+
+// authenticator — один способ проверки. found=false — запрос не предъявил учётных данных этого способа,
+// тогда пробуем следующий; ошибка — предъявил, но неверные.
+type authenticator func(r *http.Request, keys []config.APIKey, cfg *config.CfgApp) (key config.APIKey, found bool, err error)
+
+// authenticators — способы проверки в порядке попыток; новый способ добавляется сюда.
+var authenticators = []authenticator{certAuth, hmacAuth, staticAuth}
+
+// principalKey — ключ контекста, под которым лежит проверенный ключ API.
+type principalKey struct{}
+
+// principal — ключ API, с которым пришёл запрос (false — auth выключен или маршрут публичный).
+func principal(ctx context.Context) (config.APIKey, bool) {
+	k, ok := ctx.Value(principalKey{}).(config.APIKey)
+	return k, ok
+}
+
+// nonceCache — nonce-ы подписанных запросов, принятые за последние 2×hmac_skew: запрос с тем же timestamp
+// проходит проверку времени не дольше этого, значит, и повтор надо помнить столько же.
+type nonceCache struct {
+	mu    sync.Mutex
+	seen  map[string]time.Time // ключ — имя ключа API и nonce, значение — когда забыть
+	swept time.Time
+}
+
+func newNonceCache() *nonceCache {
+	return &nonceCache{seen: make(map[string]time.Time)}
+}
+
+// add запоминает nonce ключа name до now+ttl; ошибка и код ответа — nonce уже был (401) или кэш переполнен (503).
+func (c *nonceCache) add(name, nonce string, now time.Time, ttl time.Duration) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.swept) >= sweepEvery || len(c.seen) >= maxNonces {
+		c.swept = now
+		for k, until := range c.seen {
+			if !now.Before(until) {
+				delete(c.seen, k)
+			}
+		}
+	}
+	k := name + "\n" + nonce
+	if until, ok := c.seen[k]; ok && now.Before(until) {
+		return http.StatusUnauthorized, fmt.Errorf("%s already used", headerAuthNonce)
+	}
+	if len(c.seen) >= maxNonces {
+		return http.StatusServiceUnavailable, errors.New("too many signed requests, retry later")
+	}
+	c.seen[k] = now.Add(ttl)
+	return 0, nil
+}
+
+// authenticate — middleware роутера: проверка ключа и его scopes для маршрута.
+func authenticate(logger *slog.Logger, cfg *config.Holder) func(http.Handler) http.Handler {
+	nonces := newNonceCache()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			current := cfg.Load()
+			keys := current.APIKeyList()
+			if len(keys) == 0 || publicPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := identify(r, keys, current)
+			if err != nil {
+				if logger != nil {
+					logger.Warn("api auth failed", slog.String("path", r.URL.Path),
+						slog.String("remote", r.RemoteAddr), slog.Any("err", err))
+				}
+				w.Header().Set("WWW-Authenticate", wwwAuthenticate)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			// повтор подписанного запроса: nonce запоминается только после проверки подписи,
+			// чтобы запросы без секрета не забивали кэш
+			if key.Kind == config.KeyHMAC {
+				if code, err := nonces.add(key.Name, r.Header.Get(headerAuthNonce), time.Now(), 2*current.HMACSkew()); err != nil {
+					if logger != nil {
+						logger.Warn("api auth failed", slog.String("path", r.URL.Path),
+							slog.String("remote", r.RemoteAddr), slog.String("key", key.Name), slog.Any("err", err))
+					}
+					if code == http.StatusUnauthorized {
+						w.Header().Set("WWW-Authenticate", wwwAuthenticate)
+					}
+					http.Error(w, err.Error(), code)
+					return
+				}
+			}
+			if denied := deniedSections(r, key, current); len(denied) > 0 {
+				http.Error(w, fmt.Sprintf("key %q has no access to %s", key.Name, strings.Join(denied, ", ")), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, key)))
+		})
+	}
+}
+
+// identify — первый способ, по которому запрос предъявил учётные данные, решает исход.
+func identify(r *http.Request, keys []config.APIKey, cfg *config.CfgApp) (config.APIKey, error) {
+	for _, auth := range authenticators {
+		key, found, err := auth(r, keys, cfg)
+		if found || err != nil {
+			return key, err
+		}
+	}
+	return config.APIKey{}, errors.New("credentials required")
+}
+
+// certAuth — CN проверенного клиентского сертификата. Непроверенные сертификаты (без VerifiedChains) не считаются.
+func certAuth(r *http.Request, keys []config.APIKey, _ *config.CfgApp) (config.APIKey, bool, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return config.APIKey{}, false, nil
+	}
+	cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	for _, k := range keys {
+		if k.Kind == config.KeyCert && k.CertCN == cn {
+			return k, true, nil
+		}
+	}
+	// сертификат доверенный, но ключа для него нет — пусть клиент предъявит ключ другим способом
+	return config.APIKey{}, false, nil
+}
+
+// hmacAuth — подписанный запрос: имя ключа, время, nonce и подпись в заголовках X-Auth-*.
+// Повтор nonce проверяет authenticate (nonceCache), здесь — только формат и подпись.
+func hmacAuth(r *http.Request, keys []config.APIKey, cfg *config.CfgApp) (config.APIKey, bool, error) {
+	sig := r.Header.Get(headerAuthSig)
+	if sig == "" {
+		return config.APIKey{}, false, nil
+	}
+	name, ts := r.Header.Get(headerAuthKey), r.Header.Get(headerAuthTS)
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return config.APIKey{}, true, fmt.Errorf("bad %s", headerAuthTS)
+	}
+	if skew := time.Since(time.Unix(unix, 0)).Abs(); skew > cfg.HMACSkew() {
+		return config.APIKey{}, true, fmt.Errorf("%s is %s off the server clock", headerAuthTS, skew.Round(time.Second))
+	}
+	nonce := r.Header.Get(headerAuthNonce)
+	if nonce == "" || len(nonce) > maxNonceLen {
+		return config.APIKey{}, true, fmt.Errorf("%s required, up to %d characters", headerAuthNonce, maxNonceLen)
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return config.APIKey{}, true, errBadCredentials
+	}
+	digest, err := bodyDigest(r)
+	if err != nil {
+		return config.APIKey{}, true, err
+	}
+	for _, k := range keys {
+		if k.Kind != config.KeyHMAC || k.Name != name {
+			continue
+		}
+		secret, err := k.Secret.Resolve()
+		if err != nil {
+			return config.APIKey{}, true, errBadCredentials // секрет недоступен — отказ, подробности не раскрываем
+		}
+		if hmac.Equal(got, signature(secret, r.Method, r.URL.RequestURI(), ts, nonce, digest)) {
+			return k, true, nil
+		}
+		break
+	}
+	return config.APIKey{}, true, errBadCredentials
+}
+
+// signature — HMAC-SHA256 от "METHOD\nпуть?query\ntimestamp\nnonce\nsha256 тела".
+func signature(secret config.Secret, method, uri, ts, nonce, digest string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret.Reveal()))
+	mac.Write([]byte(method + "\n" + uri + "\n" + ts + "\n" + nonce + "\n" + digest))
+	return mac.Sum(nil)
+}
+
+// bodyDigest — hex(sha256) тела для подписи; у GET/HEAD тела нет — пустая строка.
+// Тело читается целиком (не больше maxSignedBody) и подкладывается обратно для хендлера.
+func bodyDigest(r *http.Request) (string, error) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Body == nil {
+		return "", nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBody+1))
+	_ = r.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("read body: %w", err)
+	}
+	if len(body) > maxSignedBody {
+		return "", fmt.Errorf("signed body larger than %d bytes", maxSignedBody)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// staticAuth — ключ целиком в заголовке. Сравниваем хэши за постоянное время, чтобы не выдать ключ по таймингу.
+func staticAuth(r *http.Request, keys []config.APIKey, _ *config.CfgApp) (config.APIKey, bool, error) {
+	presented := r.Header.Get(headerAPIKey)
+	if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		presented = strings.TrimSpace(v)
+	}
+	if presented == "" {
+		return config.APIKey{}, false, nil
+	}
+	sum := sha256.Sum256([]byte(presented))
+	for _, k := range keys {
+		if k.Kind != config.KeyStatic {
+			continue
+		}
+		secret, err := k.Secret.Resolve()
+		if err != nil {
+			continue
+		}
+		want := sha256.Sum256([]byte(secret.Reveal()))
+		if subtle.ConstantTimeCompare(sum[:], want[:]) == 1 {
+			return k, true, nil
+		}
+	}
+	return config.APIKey{}, true, errBadCredentials
+}
+
+// deniedSections — секции маршрута, которых нет в scopes ключа.
+func deniedSections(r *http.Request, key config.APIKey, cfg *config.CfgApp) []string {
+	var need []string
+	switch path := r.URL.Path; {
+	case path == "/metrics":
+		if !slices.Contains(key.Scopes, config.ScopeAll) {
+			return []string{"metrics (scope " + config.ScopeAll + " required)"}
+		}
+	case path == "/":
+		for _, source := range config.Sources {
+			if cfg.Enabled(source) {
+				need = append(need, source)
+			}
+		}
+	case path == apiPrefix+"stream" || path == apiPrefix+"stream/ws":
+		// неизвестную секцию в фильтре отвергнет сам хендлер (400)
+		need, _ = streamFilter(r.URL.Query().Get("sections"))
+	case strings.HasPrefix(path, apiPrefix):
+		for _, s := range apiSections {
+			if path == apiPrefix+s.path {
+				need = append(need, s.source)
+			}
+		}
+	}
+	var denied []string
+	for _, source := range need {
+		if !key.Allows(source) && !slices.Contains(denied, source) {
+			denied = append(denied, source)
+		}
+	}
+	return denied
+}
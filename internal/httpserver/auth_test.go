@@ -0,0 +1,238 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"main/config"
+	m "main/internal/model"
+)
+
+// signRequest подписывает запрос так же, как это должен делать клиент; nonce — свой на каждый вызов.
+func signRequest(r *http.Request, name string, secret config.Secret, at time.Time) {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	signRequestNonce(r, name, secret, at, hex.EncodeToString(b), nil)
+}
+
+// signRequestNonce — подпись с заданным nonce и телом (body == nil — запрос без тела).
+func signRequestNonce(r *http.Request, name string, secret config.Secret, at time.Time, nonce string, body []byte) {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	digest := ""
+	if body != nil {
+		sum := sha256.Sum256(body)
+		digest = hex.EncodeToString(sum[:])
+	}
+	r.Header.Set(headerAuthKey, name)
+	r.Header.Set(headerAuthTS, ts)
+	r.Header.Set(headerAuthNonce, nonce)
+	r.Header.Set(headerAuthSig, hex.EncodeToString(signature(secret, r.Method, r.URL.RequestURI(), ts, nonce, digest)))
+}
+
+// withCert — запрос с проверенным клиентским сертификатом (как после mTLS-рукопожатия).
+func withCert(cn string) func(*http.Request) {
+	return func(r *http.Request) {
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}}}
+	}
+}
+
+func withHeader(k, v string) func(*http.Request) {
+	return func(r *http.Request) { r.Header.Set(k, v) }
+}
+
+func TestAuth(t *testing.T) {
+	t.Setenv("DASH_KEY", "dash-secret")
+	t.Setenv("BOT_KEY", "bot-secret")
+	origFetch, origSection := fetch, fetchSection
+	t.Cleanup(func() { fetch, fetchSection = origFetch, origSection })
+	fetch = func(context.Context, *slog.Logger, *config.CfgApp) (m.ResultSetT, m.ResultT, time.Time) {
+		return m.ResultSetT{}, m.ResultT{}, time.Now()
+	}
+	fetchSection = func(context.Context, *slog.Logger, *config.CfgApp, string) (any, time.Time, error) {
+		return []int{1, 2}, time.Now(), nil
+	}
+	cfg := &config.CfgApp{
+		APIKeys:         "dashboard:sms+mms:env:DASH_KEY, bot:billing:hmac:env:BOT_KEY, reports:*:cn:reports.internal",
+		DisableVoice:    true,
+		StreamHeartbeat: time.Hour,
+	}
+	router := newRouter(nil, config.NewHolder(cfg))
+
+	sign := func(name, secret string, at time.Time) func(*http.Request) {
+		return func(r *http.Request) { signRequest(r, name, config.Secret(secret), at) }
+	}
+	tests := []struct {
+		name, path string
+		prepare    func(*http.Request)
+		want       int
+	}{
+		{"public healthz", "/healthz", nil, http.StatusOK},
+		{"no credentials", "/api/v1/sms", nil, http.StatusUnauthorized},
+		{"bearer", "/api/v1/sms", withHeader("Authorization", "Bearer dash-secret"), http.StatusOK},
+		{"x-api-key", "/api/v1/mms", withHeader(headerAPIKey, "dash-secret"), http.StatusOK},
+		{"wrong key", "/api/v1/sms", withHeader(headerAPIKey, "guess"), http.StatusUnauthorized},
+		{"hmac key as bearer", "/api/v1/billing", withHeader(headerAPIKey, "bot-secret"), http.StatusUnauthorized},
+		{"out of scope", "/api/v1/billing", withHeader(headerAPIKey, "dash-secret"), http.StatusForbidden},
+		{"root needs all enabled", "/", withHeader(headerAPIKey, "dash-secret"), http.StatusForbidden},
+		{"status any key", "/status", withHeader(headerAPIKey, "dash-secret"), http.StatusOK},
+		{"metrics scoped key", "/metrics", withHeader(headerAPIKey, "dash-secret"), http.StatusForbidden},
+		{"metrics hmac scoped key", "/metrics", sign("bot", "bot-secret", time.Now()), http.StatusForbidden},
+		{"metrics scope all", "/metrics", withCert("reports.internal"), http.StatusOK},
+		{"hmac", "/api/v1/billing?format=csv", sign("bot", "bot-secret", time.Now()), http.StatusOK},
+		{"hmac wrong secret", "/api/v1/billing", sign("bot", "guess", time.Now()), http.StatusUnauthorized},
+		{"hmac unknown key", "/api/v1/billing", sign("dashboard", "dash-secret", time.Now()), http.StatusUnauthorized},
+		{"hmac stale", "/api/v1/billing", sign("bot", "bot-secret", time.Now().Add(-time.Hour)), http.StatusUnauthorized},
+		{"hmac out of scope", "/api/v1/sms", sign("bot", "bot-secret", time.Now()), http.StatusForbidden},
+		{"cert", "/", withCert("reports.internal"), http.StatusOK},
+		{"unknown cert", "/", withCert("other"), http.StatusUnauthorized},
+		{"stream out of scope", "/api/v1/stream?sections=sms,billing", withHeader(headerAPIKey, "dash-secret"), http.StatusForbidden},
+		{"stream without filter", "/api/v1/stream", withHeader(headerAPIKey, "dash-secret"), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.prepare != nil {
+				tt.prepare(req)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Fatalf("code = %d, want %d: %s", rr.Code, tt.want, rr.Body.String())
+			}
+			if tt.want == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") == "" {
+				t.Fatalf("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestAuth_Disabled(t *testing.T) {
+	orig := fetchSection
+	t.Cleanup(func() { fetchSection = orig })
+	fetchSection = func(context.Context, *slog.Logger, *config.CfgApp, string) (any, time.Time, error) {
+		return []int{1, 2}, time.Now(), nil
+	}
+	rr := httptest.NewRecorder()
+	newRouter(nil, config.NewHolder(&config.CfgApp{})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/support", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("without keys API must stay open, code = %d", rr.Code)
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	t.Setenv("DASH_KEY", "dash-secret")
+	var got config.APIKey
+	h := authenticate(nil, config.NewHolder(&config.CfgApp{APIKeys: "dashboard:*:env:DASH_KEY"}))(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) { got, _ = principal(r.Context()) }))
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.Header.Set("Authorization", "Bearer dash-secret")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if got.Name != "dashboard" {
+		t.Fatalf("principal = %+v", got)
+	}
+}
+
+// перехваченный подписанный запрос нельзя повторить: nonce запоминается на 2×hmac_skew
+func TestAuth_HMACReplay(t *testing.T) {
+	t.Setenv("BOT_KEY", "bot-secret")
+	orig := fetchSection
+	t.Cleanup(func() { fetchSection = orig })
+	fetchSection = func(context.Context, *slog.Logger, *config.CfgApp, string) (any, time.Time, error) {
+		return m.BillingData{}, time.Now(), nil
+	}
+	router := newRouter(nil, config.NewHolder(&config.CfgApp{APIKeys: "bot:billing:hmac:env:BOT_KEY"}))
+
+	do := func(nonce string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/billing", nil)
+		signRequestNonce(req, "bot", "bot-secret", time.Now(), nonce, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	if code := do("n-1"); code != http.StatusOK {
+		t.Fatalf("first request: code = %d", code)
+	}
+	if code := do("n-1"); code != http.StatusUnauthorized {
+		t.Fatalf("replay: code = %d, want 401", code)
+	}
+	if code := do("n-2"); code != http.StatusOK {
+		t.Fatalf("new nonce: code = %d", code)
+	}
+	if code := do(""); code != http.StatusUnauthorized {
+		t.Fatalf("without nonce: code = %d, want 401", code)
+	}
+}
+
+func TestNonceCache(t *testing.T) {
+	c := newNonceCache()
+	now := time.Now()
+	if _, err := c.add("bot", "n", now, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if code, err := c.add("bot", "n", now.Add(30*time.Second), time.Minute); err == nil || code != http.StatusUnauthorized {
+		t.Fatalf("replay: code = %d, err = %v", code, err)
+	}
+	if _, err := c.add("other", "n", now, time.Minute); err != nil {
+		t.Fatalf("same nonce of another key: %v", err)
+	}
+	if _, err := c.add("bot", "n", now.Add(2*time.Minute), time.Minute); err != nil {
+		t.Fatalf("expired nonce: %v", err)
+	}
+}
+
+// у запроса с телом подписывается и sha256 тела: подменить тело, сохранив подпись, нельзя
+func TestHMAC_BodyDigest(t *testing.T) {
+	t.Setenv("BOT_KEY", "bot-secret")
+	cfg := &config.CfgApp{APIKeys: "bot:billing:hmac:env:BOT_KEY"}
+	keys := cfg.APIKeyList()
+	body := []byte(`{"sections":["billing"]}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/billing", bytes.NewReader(body))
+	signRequestNonce(req, "bot", "bot-secret", time.Now(), "n-body", body)
+	if key, found, err := hmacAuth(req, keys, cfg); !found || err != nil || key.Name != "bot" {
+		t.Fatalf("signed body: key = %q, found = %v, err = %v", key.Name, found, err)
+	}
+	if got, _ := io.ReadAll(req.Body); !bytes.Equal(got, body) {
+		t.Fatalf("handler body = %q, want %q", got, body)
+	}
+
+	tampered := httptest.NewRequest(http.MethodPost, "/api/v1/billing", bytes.NewReader([]byte(`{"sections":["sms"]}`)))
+	tampered.Header = req.Header.Clone()
+	if _, _, err := hmacAuth(tampered, keys, cfg); err == nil {
+		t.Fatalf("tampered body accepted")
+	}
+}
+
+// /status показывает ключу только источники из его scopes
+func TestStatus_Scopes(t *testing.T) {
+	t.Setenv("DASH_KEY", "dash-secret")
+	router := newRouter(nil, config.NewHolder(&config.CfgApp{APIKeys: "dashboard:sms+mms:env:DASH_KEY"}))
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.Header.Set(headerAPIKey, "dash-secret")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var resp struct {
+		Sources map[string]sourceStatus `json:"sources"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("bad json %q: %v", rr.Body.String(), err)
+	}
+	if len(resp.Sources) != 2 || !resp.Sources[config.SourceSMS].Enabled || !resp.Sources[config.SourceMMS].Enabled {
+		t.Fatalf("sources = %v, want only sms and mms", resp.Sources)
+	}
+}
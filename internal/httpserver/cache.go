@@ -0,0 +1,173 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"main/config"
+	"main/internal/httpx"
+	"main/internal/metrics"
+	"main/internal/tracing"
+	"main/sl"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+/*
+Кэш ответов "/" и /api/v1/<секция> — stale-while-revalidate, время жизни — CacheTTL:
+
+  - свежая запись (моложе CacheTTL) отдаётся как есть;
+  - устаревшая отдаётся сразу, а в фоне запускается ровно одно обновление;
+  - записи нет (первый запрос, reload конфига) — запрос ждёт сбор; одновременные промахи ждут один и тот же сбор;
+  - неудачное обновление не затирает последнее хорошее значение (для "/" — по каждой секции, см. res.KeepStale).
+
+Возраст отданных данных — в заголовке X-Data-Age (секунды).
+*/
+
+// headerDataAge — сколько секунд назад собраны отданные данные.
+const headerDataAge = "X-Data-Age"
+
+// swrEntry — значение в кэше и с каким конфигом/когда оно собрано.
+type swrEntry[T any] struct {
+	val T
+	cfg *config.CfgApp // снимок конфига; после reload запись не используется
+	at  time.Time      // время сбора, от него считается возраст
+}
+
+// loader собирает новое значение; prev — последнее значение для того же конфига (ok=false — его нет).
+type loader[T any] func(ctx context.Context, prev T, ok bool) (T, error)
+
+type swrCache[T any] struct {
+	name    string // метка cache в метриках: full | section
+	mu      sync.RWMutex
+	entries map[string]swrEntry[T]
+	group   singleflight.Group
+}
+
+func newSWRCache[T any](name string) *swrCache[T] {
+	return &swrCache[T]{name: name, entries: map[string]swrEntry[T]{}}
+}
+
+// peek — запись key, собранная с cfg, без обращения к источникам.
+func (c *swrCache[T]) peek(key string, cfg *config.CfgApp) (swrEntry[T], bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key]
+	if !ok || e.cfg != cfg {
+		return swrEntry[T]{}, false
+	}
+	return e, true
+}
+
+// get возвращает значение key и время его сбора; ошибка — только если значения нет и сбор не удался
+// (или запрос отменён раньше, чем сбор закончился).
+// Обращение — span "cache <name>" с итогом (hit/stale/miss); сбор при промахе — внутри него.
+func (c *swrCache[T]) get(ctx context.Context, key string, cfg *config.CfgApp, load loader[T]) (T, time.Time, error) {
+	ctx, span := tracing.Start(ctx, "cache "+c.name, attribute.String("cache.key", key))
+	defer span.End()
+
+	if e, ok := c.peek(key, cfg); ok {
+		if time.Since(e.at) < cfg.CacheLifetime() {
+			metrics.Cache(c.name, metrics.CacheHit)
+			span.SetAttributes(attribute.String("cache.result", metrics.CacheHit))
+			return e.val, e.at, nil
+		}
+		metrics.Cache(c.name, metrics.CacheStale)
+		span.SetAttributes(attribute.String("cache.result", metrics.CacheStale))
+		c.refresh(ctx, key, cfg, load) // результат не ждём: отдаём то, что есть
+		return e.val, e.at, nil
+	}
+
+	metrics.Cache(c.name, metrics.CacheMiss)
+	span.SetAttributes(attribute.String("cache.result", metrics.CacheMiss))
+	var zero T
+	select {
+	case r := <-c.refresh(ctx, key, cfg, load):
+		if r.Err != nil {
+			tracing.RecordError(span, r.Err)
+			return zero, time.Time{}, r.Err
+		}
+		e := r.Val.(swrEntry[T])
+		return e.val, e.at, nil
+	case <-ctx.Done():
+		tracing.RecordError(span, ctx.Err())
+		return zero, time.Time{}, ctx.Err()
+	}
+}
+
+// refresh запускает сбор key, если он ещё не идёт; одновременные вызовы получают результат одного сбора.
+// Сбор общий для всех, кто его ждёт, поэтому идёт не в контексте запустившего запроса, а от корневого контекста
+// сервера (serverContext): без его отмены, со своим request_id (в логгере сбора и в X-Request-ID к апстримам)
+// и бюджетом HandlerTimeout. Span сбора — корень своей трассы "cache refresh <name>", со ссылкой (link)
+// на span обращения к кэшу, который его запустил.
+func (c *swrCache[T]) refresh(ctx context.Context, key string, cfg *config.CfgApp, load loader[T]) <-chan singleflight.Result {
+	flight := fmt.Sprintf("%s@%p", key, cfg) // сбор со старым конфигом не должен достаться запросу с новым
+	base, caller := baseContext(ctx), trace.LinkFromContext(ctx)
+	return c.group.DoChan(flight, func() (any, error) {
+		prev, ok := c.peek(key, cfg)
+
+		bctx, cancel := context.WithTimeout(base, cfg.HandlerBudget())
+		defer cancel()
+		id := newRequestID()
+		bctx = httpx.WithRequestID(bctx, id)
+		if l := sl.FromContext(base, nil); l != nil {
+			bctx = sl.WithLogger(bctx, l.With(slog.String("request_id", id), slog.String("cache", c.name)))
+		}
+		bctx, span := tracing.StartRoot(bctx, "cache refresh "+c.name, caller,
+			attribute.String("cache.key", key), attribute.String("request_id", id))
+		defer span.End()
+		val, err := load(bctx, prev.val, ok)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return nil, err // не кэшируем: прежнее значение (если было) остаётся
+		}
+
+		e := swrEntry[T]{val: val, cfg: cfg, at: time.Now()}
+		c.mu.Lock()
+		c.entries[key] = e
+		c.mu.Unlock()
+		return e, nil
+	})
+}
+
+type baseCtxKey struct{}
+
+// serverContext — корневой контекст сервера (BaseContext у http.Server): логгер сервиса и ссылка на себя,
+// чтобы фоновые сборы (refresh) могли начаться от него, а не от запроса.
+func serverContext(parent context.Context, logger *slog.Logger) context.Context {
+	base := parent
+	if logger != nil {
+		base = sl.WithLogger(parent, logger)
+	}
+	return context.WithValue(base, baseCtxKey{}, base)
+}
+
+// baseContext — корневой контекст сервера из контекста запроса; без него (роутер без http.Server, тесты) — Background.
+func baseContext(ctx context.Context) context.Context {
+	if base, ok := ctx.Value(baseCtxKey{}).(context.Context); ok {
+		return base
+	}
+	return context.Background()
+}
+
+// reset очищает кэш (для тестов).
+func (c *swrCache[T]) reset() {
+	c.mu.Lock()
+	clear(c.entries)
+	c.mu.Unlock()
+}
+
+// setDataAge пишет возраст данных в заголовок ответа.
+func setDataAge(w http.ResponseWriter, at time.Time) {
+	if at.IsZero() {
+		return
+	}
+	w.Header().Set(headerDataAge, strconv.Itoa(int(time.Since(at).Seconds())))
+}
@@ -0,0 +1,176 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"main/config"
+	"main/internal/httpx"
+	"main/sl"
+)
+
+// одновременные промахи ждут один сбор
+func TestSWRCache_CoalescesMisses(t *testing.T) {
+	c := newSWRCache[int]("test")
+	cfg := &config.CfgApp{}
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := func(context.Context, int, bool) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, _, err := c.get(context.Background(), "k", cfg, load); err != nil || v != 42 {
+				t.Errorf("get = %d, %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond) // все запросы дошли до ожидания
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("loader called %d times, want 1", got)
+	}
+}
+
+// устаревшее значение отдаётся сразу, обновление одно и в фоне; неудачное обновление значение не затирает
+func TestSWRCache_StaleWhileRevalidate(t *testing.T) {
+	c := newSWRCache[int]("test")
+	cfg := &config.CfgApp{CacheTTL: time.Minute}
+	old := time.Now().Add(-2 * time.Minute)
+	c.entries["k"] = swrEntry[int]{val: 1, cfg: cfg, at: old}
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	var fail atomic.Bool
+	load := func(_ context.Context, prev int, ok bool) (int, error) {
+		calls.Add(1)
+		<-release
+		if fail.Load() {
+			return 0, errors.New("boom")
+		}
+		if !ok || prev != 1 {
+			t.Errorf("prev = %d, %v; want 1, true", prev, ok)
+		}
+		return 2, nil
+	}
+
+	for range 5 {
+		v, at, err := c.get(context.Background(), "k", cfg, load)
+		if err != nil || v != 1 || !at.Equal(old) {
+			t.Fatalf("stale get = %d %s %v, want 1 immediately", v, at, err)
+		}
+	}
+	close(release)
+	waitFor(t, func() bool { e, _ := c.peek("k", cfg); return e.val == 2 })
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("loader called %d times, want 1", got)
+	}
+
+	// обновление упало — остаётся последнее хорошее значение
+	fail.Store(true)
+	c.mu.Lock()
+	c.entries["k"] = swrEntry[int]{val: 2, cfg: cfg, at: old}
+	c.mu.Unlock()
+	c.get(context.Background(), "k", cfg, load)
+	waitFor(t, func() bool { return calls.Load() == 2 })
+	time.Sleep(10 * time.Millisecond)
+	if e, _ := c.peek("k", cfg); e.val != 2 {
+		t.Fatalf("value after failed refresh = %d, want 2", e.val)
+	}
+}
+
+// после reload (другой *CfgApp) запись не используется; отмена запроса не отменяет сбор
+func TestSWRCache_ConfigChangeAndCancel(t *testing.T) {
+	c := newSWRCache[int]("test")
+	oldCfg, newCfg := &config.CfgApp{}, &config.CfgApp{}
+	c.entries["k"] = swrEntry[int]{val: 1, cfg: oldCfg, at: time.Now()}
+
+	release := make(chan struct{})
+	load := func(ctx context.Context, _ int, ok bool) (int, error) {
+		if ok {
+			t.Errorf("prev from old config must not be passed")
+		}
+		<-release
+		return 2, ctx.Err()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := c.get(ctx, "k", newCfg, load); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	close(release)
+	waitFor(t, func() bool { e, ok := c.peek("k", newCfg); return ok && e.val == 2 })
+}
+
+// сбор идёт от корневого контекста сервера: без логгера, request_id и отмены запроса, который его запустил
+func TestSWRCache_RefreshDetachedFromCaller(t *testing.T) {
+	var serverLogs, requestLogs bytes.Buffer
+	base := serverContext(context.Background(), slog.New(slog.NewJSONHandler(&serverLogs, nil)))
+	reqCtx, cancel := context.WithCancel(sl.WithLogger(httpx.WithRequestID(base, "req-1"),
+		slog.New(slog.NewJSONHandler(&requestLogs, nil))))
+	cancel() // запустивший запрос уже ушёл — сбор это не прерывает
+
+	c := newSWRCache[int]("test")
+	var loadID string
+	var loadErr error
+	load := func(ctx context.Context, _ int, _ bool) (int, error) {
+		loadID, loadErr = httpx.RequestID(ctx), ctx.Err()
+		sl.FromContext(ctx, nil).Info("collect")
+		return 1, nil
+	}
+	if r := <-c.refresh(reqCtx, "k", &config.CfgApp{}, load); r.Err != nil {
+		t.Fatalf("refresh: %v", r.Err)
+	}
+	if loadErr != nil {
+		t.Fatalf("refresh context cancelled with the request: %v", loadErr)
+	}
+	if !validRequestID(loadID) || loadID == "req-1" {
+		t.Fatalf("refresh request id = %q, want its own", loadID)
+	}
+	if requestLogs.Len() != 0 || !strings.Contains(serverLogs.String(), `"request_id":"`+loadID+`"`) {
+		t.Fatalf("refresh must log via the server logger with its own id:\nserver: %srequest: %s",
+			serverLogs.String(), requestLogs.String())
+	}
+}
+
+func TestHandler_DataAgeHeader(t *testing.T) {
+	orig := fetchSection
+	t.Cleanup(func() { fetchSection = orig })
+	fetchSection = func(context.Context, *slog.Logger, *config.CfgApp, string) (any, time.Time, error) {
+		return []int{1}, time.Now().Add(-5 * time.Second), nil
+	}
+
+	rr := httptest.NewRecorder()
+	newRouter(nil, config.NewHolder(&config.CfgApp{})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/support", nil))
+	if got := rr.Header().Get(headerDataAge); got != "5" {
+		t.Fatalf("%s = %q, want 5", headerDataAge, got)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in 2s")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
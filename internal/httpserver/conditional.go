@@ -0,0 +1,144 @@
+package httpserver
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Дашборды опрашивают "/" и /api/v1/<секция> часто, а данные меняются раз в CacheTTL/collect.interval.
+Поэтому ответы (JSON, CSV, HTML — см. render.go) отдаются с валидаторами для условного GET и сжимаются:
+
+	ETag: W/"<хэш тела>"            — If-None-Match с тем же тегом → 304 без тела
+	Last-Modified: <когда тело по этому маршруту изменилось> — If-Modified-Since → 304
+	Content-Encoding: gzip          — если клиент прислал Accept-Encoding: gzip и тело не меньше gzipMinSize
+
+ETag слабый: gzip и несжатое тело — одно и то же представление.
+brotli не поддерживаем (в stdlib его нет): клиенту только с br отдаём тело без сжатия, Vary: Accept-Encoding ставим всегда.
+*/
+
+// gzipMinSize — тела меньше этого не сжимаем: выигрыш меньше заголовков gzip.
+const gzipMinSize = 512
+
+var gzipPool = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+// version — когда тело ответа по ключу (маршрут + режим) последний раз изменилось.
+type version struct {
+	etag string
+	at   time.Time
+}
+
+// versions — Last-Modified по ключам; ключи задают хендлеры (их немного), а не URL запроса.
+var versions = struct {
+	mu sync.Mutex
+	m  map[string]version
+}{m: make(map[string]version)}
+
+// modified — Last-Modified для тела с тегом etag: время, когда этот тег впервые появился по ключу.
+// Новое тело всегда получает время хотя бы на секунду позже прежнего: в HTTP-дате только секунды,
+// и два изменения в одну секунду иначе дали бы одинаковый Last-Modified.
+func modified(key, etag string) time.Time {
+	versions.mu.Lock()
+	defer versions.mu.Unlock()
+	prev, ok := versions.m[key]
+	if ok && prev.etag == etag {
+		return prev.at
+	}
+	at := time.Now().UTC().Truncate(time.Second)
+	if ok && !at.After(prev.at) {
+		at = prev.at.Add(time.Second)
+	}
+	versions.m[key] = version{etag: etag, at: at}
+	return at
+}
+
+// writeJSON пишет v как JSON через writeBody.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any, key string) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeBody(w, r, status, "application/json", append(body, '\n'), key) // перевод строки — как у json.Encoder
+}
+
+// writeBody пишет готовое тело: для 200 — с ETag/Last-Modified и 304 на условный запрос (key — см. versions),
+// с gzip — если клиент его принимает.
+func writeBody(w http.ResponseWriter, r *http.Request, status int, contentType string, body []byte, key string) error {
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Add("Vary", "Accept-Encoding")
+	if status == http.StatusOK {
+		sum := sha256.Sum256(body)
+		etag := `W/"` + hex.EncodeToString(sum[:12]) + `"`
+		lm := modified(key, etag)
+		h.Set("ETag", etag)
+		h.Set("Last-Modified", lm.Format(http.TimeFormat))
+		if notModified(r, etag, lm) {
+			h.Del("Content-Type")
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
+
+	if len(body) < gzipMinSize || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		h.Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(status)
+		_, err := w.Write(body)
+		return err
+	}
+
+	h.Set("Content-Encoding", "gzip")
+	w.WriteHeader(status)
+	gz := gzipPool.Get().(*gzip.Writer)
+	defer gzipPool.Put(gz)
+	gz.Reset(w)
+	if _, err := gz.Write(body); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// notModified — условный запрос совпал с текущим телом. If-None-Match важнее If-Modified-Since (RFC 9110 13.2.2).
+func notModified(r *http.Request, etag string, lm time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !lm.After(t)
+	}
+	return false
+}
+
+// acceptsGzip — принимает ли клиент gzip: явный gzip с ненулевым q, иначе "*" с ненулевым q.
+func acceptsGzip(header string) bool {
+	star := false
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		ok := true
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			v, err := strconv.ParseFloat(q, 64)
+			ok = err == nil && v > 0
+		}
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case "gzip":
+			return ok
+		case "*":
+			star = ok
+		}
+	}
+	return star
+}
@@ -0,0 +1,142 @@
+package httpserver
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"main/config"
+	m "main/internal/model"
+)
+
+func TestConditionalGET(t *testing.T) {
+	data := []int{1, 2}
+	orig := fetchSection
+	t.Cleanup(func() { fetchSection = orig })
+	fetchSection = func(context.Context, *slog.Logger, *config.CfgApp, string) (any, time.Time, error) {
+		return data, time.Now(), nil
+	}
+	router := newRouter(nil, config.NewHolder(&config.CfgApp{}))
+	get := func(hdr map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/support", nil)
+		for k, v := range hdr {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := get(nil)
+	etag, lm := first.Header().Get("ETag"), first.Header().Get("Last-Modified")
+	if first.Code != http.StatusOK || !strings.HasPrefix(etag, `W/"`) || lm == "" {
+		t.Fatalf("code=%d ETag=%q Last-Modified=%q", first.Code, etag, lm)
+	}
+
+	tests := []struct {
+		name string
+		hdr  map[string]string
+		want int
+	}{
+		{"same etag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"etag in list", map[string]string{"If-None-Match": `"other", ` + etag}, http.StatusNotModified},
+		{"other etag", map[string]string{"If-None-Match": `W/"other"`}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": lm}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": "Mon, 01 Jan 2001 00:00:00 GMT"}, http.StatusOK},
+		// If-None-Match важнее If-Modified-Since
+		{"etag wins", map[string]string{"If-None-Match": `W/"other"`, "If-Modified-Since": lm}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := get(tt.hdr)
+			if rr.Code != tt.want {
+				t.Fatalf("code = %d, want %d", rr.Code, tt.want)
+			}
+			if tt.want == http.StatusNotModified && rr.Body.Len() != 0 {
+				t.Fatalf("304 with body %q", rr.Body.String())
+			}
+		})
+	}
+
+	// данные изменились — новый ETag и более поздний Last-Modified, старые валидаторы не совпадают
+	data = []int{3, 4}
+	changed := get(map[string]string{"If-None-Match": etag})
+	if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+		t.Fatalf("changed data: code=%d ETag=%q", changed.Code, changed.Header().Get("ETag"))
+	}
+	if get(map[string]string{"If-Modified-Since": lm}).Code != http.StatusOK {
+		t.Fatalf("changed data must not match old Last-Modified")
+	}
+}
+
+func TestWriteJSON_Gzip(t *testing.T) {
+	big := map[string]string{"payload": strings.Repeat("x", 2*gzipMinSize)}
+	tests := []struct {
+		name     string
+		accept   string
+		v        any
+		wantGzip bool
+	}{
+		{"gzip", "gzip, deflate, br", big, true},
+		{"star", "*", big, true},
+		{"refused", "gzip;q=0, *", big, false},
+		{"br only", "br", big, false},
+		{"identity", "", big, false},
+		{"small body", "gzip", m.SectionError{Code: "x"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", tt.accept)
+			rr := httptest.NewRecorder()
+			if err := writeJSON(rr, req, http.StatusServiceUnavailable, tt.v, "test"); err != nil {
+				t.Fatal(err)
+			}
+			if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("ETag") != "" {
+				t.Fatalf("non-200: code=%d ETag=%q", rr.Code, rr.Header().Get("ETag"))
+			}
+			if ce := rr.Header().Get("Content-Encoding"); ce != "" && ce != "gzip" {
+				t.Fatalf("Content-Encoding = %q, want gzip or identity", ce)
+			}
+			gz := rr.Header().Get("Content-Encoding") == "gzip"
+			if gz != tt.wantGzip {
+				t.Fatalf("gzip = %v, want %v", gz, tt.wantGzip)
+			}
+			if v := rr.Header().Values("Vary"); !slices.Contains(v, "Accept-Encoding") {
+				t.Fatalf("Vary = %q, want Accept-Encoding", v)
+			}
+			var body io.Reader = rr.Body
+			if gz {
+				zr, err := gzip.NewReader(rr.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = zr
+			}
+			var got any
+			if err := json.NewDecoder(body).Decode(&got); err != nil {
+				t.Fatalf("bad body: %v", err)
+			}
+		})
+	}
+}
+
+func TestModified_StrictlyIncreasing(t *testing.T) {
+	a := modified("inc", "a")
+	b := modified("inc", "b")
+	c := modified("inc", "c")
+	if !b.After(a) || !c.After(b) {
+		t.Fatalf("Last-Modified must grow on every change: %s %s %s", a, b, c)
+	}
+	if modified("inc", "c") != c {
+		t.Fatalf("same etag must keep Last-Modified")
+	}
+}
@@ -0,0 +1,89 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"main/config"
+	"main/internal/health"
+)
+
+/*
+Служебные эндпоинты для Kubernetes/балансировщика — не запускают сбор данных:
+
+	GET /healthz — процесс жив (всегда 200)
+	GET /readyz  — листенер поднят и конфиг загружен (200), иначе 503; на graceful shutdown снова 503
+	GET /status  — по каждому источнику итог последнего опроса из GoFetch (см. internal/health)
+*/
+
+// ready — сервер слушает порт и не находится в shutdown.
+var ready atomic.Bool
+
+func handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("ok"))
+}
+
+func makeHandleReadyz(cfg *config.Holder) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if !ready.Load() || cfg.Load() == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("not ready"))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}
+}
+
+// sourceStatus — состояние источника в /status; времена — nil, если события ещё не было.
+type sourceStatus struct {
+	Enabled      bool       `json:"enabled"`
+	LastSuccess  *time.Time `json:"last_success"`
+	LastError    string     `json:"last_error"`
+	LastErrorAt  *time.Time `json:"last_error_at"`
+	LastDuration string     `json:"last_duration"`
+	Count        int        `json:"count"`
+}
+
+func makeHandleStatus(cfg *config.Holder) http.HandlerFunc {
+	timeRef := func(t time.Time) *time.Time {
+		if t.IsZero() {
+			return nil
+		}
+		return &t
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		current := cfg.Load()
+		snap := health.Snapshot()
+		key, authed := principal(r.Context())
+
+		out := make(map[string]sourceStatus, len(config.Sources))
+		for _, src := range config.Sources {
+			if authed && !key.Allows(src) {
+				continue // ошибки и счётчики чужих секций ключу не показываем
+			}
+			st := snap[src]
+			s := sourceStatus{
+				Enabled:     current.Enabled(src),
+				LastSuccess: timeRef(st.LastSuccess),
+				LastError:   st.LastError,
+				LastErrorAt: timeRef(st.LastErrorAt),
+				Count:       st.Count,
+			}
+			if st.LastDuration > 0 {
+				s.LastDuration = st.LastDuration.String()
+			}
+			out[src] = s
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(struct {
+			Sources map[string]sourceStatus `json:"sources"`
+		}{out}); err != nil {
+			http.Error(w, "encode error: "+err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
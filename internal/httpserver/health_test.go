@@ -0,0 +1,109 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"main/config"
+	"main/internal/health"
+)
+
+func TestProbes(t *testing.T) {
+	t.Cleanup(func() { ready.Store(false) })
+
+	tests := []struct {
+		name     string
+		path     string
+		ready    bool
+		cfg      *config.CfgApp
+		wantCode int
+	}{
+		{"healthz", "/healthz", false, nil, http.StatusOK},
+		{"readyz before listen", "/readyz", false, &config.CfgApp{}, http.StatusServiceUnavailable},
+		{"readyz no config", "/readyz", true, nil, http.StatusServiceUnavailable},
+		{"readyz ok", "/readyz", true, &config.CfgApp{}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready.Store(tt.ready)
+			rr := httptest.NewRecorder()
+			newRouter(nil, config.NewHolder(tt.cfg)).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rr.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%q)", rr.Code, tt.wantCode, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	health.Reset()
+	t.Cleanup(health.Reset)
+	health.Record(context.Background(), config.SourceSMS, time.Now().Add(-time.Second), 7, nil)
+	health.Record(context.Background(), config.SourceMMS, time.Now(), 0, errors.New("mms: status 500"))
+
+	rr := httptest.NewRecorder()
+	newRouter(nil, config.NewHolder(&config.CfgApp{DisableBilling: true})).
+		ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d", rr.Code)
+	}
+	var resp struct {
+		Sources map[string]sourceStatus `json:"sources"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("bad json %q: %v", rr.Body.String(), err)
+	}
+	if len(resp.Sources) != len(config.Sources) {
+		t.Fatalf("sources = %v, want all of %v", resp.Sources, config.Sources)
+	}
+
+	sms := resp.Sources[config.SourceSMS]
+	if !sms.Enabled || sms.LastSuccess == nil || sms.Count != 7 || sms.LastError != "" || sms.LastDuration == "" {
+		t.Fatalf("sms = %+v", sms)
+	}
+	mms := resp.Sources[config.SourceMMS]
+	if mms.LastSuccess != nil || mms.LastError != "mms: status 500" || mms.LastErrorAt == nil {
+		t.Fatalf("mms = %+v", mms)
+	}
+	// ещё не опрашивался
+	if voice := resp.Sources[config.SourceVoice]; voice.LastSuccess != nil || voice.LastErrorAt != nil || voice.LastDuration != "" {
+		t.Fatalf("voice = %+v", voice)
+	}
+	if resp.Sources[config.SourceBilling].Enabled {
+		t.Fatalf("billing must be reported disabled")
+	}
+}
+
+// /metrics отдаёт HTTP-метрики по шаблону маршрута и счётчики кэша
+func TestMetrics_Endpoint(t *testing.T) {
+	orig := fetchSection
+	t.Cleanup(func() { fetchSection = orig })
+	fetchSection = func(context.Context, *slog.Logger, *config.CfgApp, string) (any, time.Time, error) {
+		return nil, time.Time{}, errors.New("sms empty")
+	}
+	router := newRouter(nil, config.NewHolder(&config.CfgApp{}))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/sms", nil))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("status = %d, Content-Type = %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{
+		`statecollector_http_requests_total{code="503",method="GET",route="/api/v1/sms"} 1`,
+		`statecollector_http_request_duration_seconds_count{route="/api/v1/sms"}`,
+		"# TYPE statecollector_http_request_duration_seconds histogram",
+	} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, rr.Body.String())
+		}
+	}
+}
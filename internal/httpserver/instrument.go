@@ -0,0 +1,64 @@
+package httpserver
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+
+	"main/internal/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// statusRecorder запоминает код ответа для метрик (по умолчанию 200 — как у net/http без WriteHeader)
+// и сколько байт тела записано (для лога запросов).
+type statusRecorder struct {
+	http.ResponseWriter
+	code  int
+	bytes int64
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap — чтобы http.ResponseController (Flush, SetWriteDeadline в stream.go) видел исходный writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
+
+// Hijack — для WebSocket: websocket.Server забирает соединение у net/http.
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err == nil {
+		r.code = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// instrument — middleware роутера: кол-во и длительность запросов по шаблону маршрута (/api/v1/sms, /status ...).
+// Запросы мимо маршрутов (404 роутера) сюда не попадают — mux вызывает middleware только для найденного маршрута.
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		metrics.ObserveHTTP(routeOf(r), r.Method, rec.code, time.Since(start))
+	})
+}
+
+// routeOf — шаблон маршрута запроса (/api/v1/sms ...); вне маршрута — сырой путь.
+func routeOf(r *http.Request) string {
+	if cur := mux.CurrentRoute(r); cur != nil {
+		if tpl, err := cur.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return r.URL.Path
+}
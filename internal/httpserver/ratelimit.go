@@ -0,0 +1,172 @@
+package httpserver
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"main/config"
+	"main/internal/metrics"
+)
+
+/*
+Промах кэша "/" — это семь горутин и три запроса к апстримам (mms, support, incident), поэтому всплеск
+клиентов бьёт прямо по ним. Ограничения (http.limits, по умолчанию выключены):
+
+	client_rate/client_burst — token bucket на IP клиента (RemoteAddr; X-Forwarded-For не доверяем)
+	global_rate/global_burst — token bucket на весь сервис
+	max_in_flight            — сколько запросов обрабатывается одновременно; потоки /api/v1/stream
+	                           живут долго и сбор не запускают, поэтому не считаются
+
+Сверх лимита — 429 с Retry-After (через сколько секунд появится токен). Пробы /healthz и /readyz не ограничиваются.
+Лимиты берутся из текущего конфига, после reload с новыми значениями ведра начинаются заново.
+*/
+
+// sweepEvery — как часто выбрасывать ведра клиентов, которые давно не приходили.
+const sweepEvery = time.Minute
+
+// tokenBucket — ведро токенов: пополняется со скоростью rate, вмещает не больше burst.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// fill пополняет ведро к моменту now, токен не забирает; возвращает, через сколько появится токен (0 — уже есть).
+func (b *tokenBucket) fill(now time.Time, rate float64, burst int) time.Duration {
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else {
+		b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// take забирает токен; если его нет — возвращает, через сколько он появится.
+func (b *tokenBucket) take(now time.Time, rate float64, burst int) (bool, time.Duration) {
+	if wait := b.fill(now, rate, burst); wait > 0 {
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// limits — значения из конфига, под которые заведены ведра.
+type limits struct {
+	clientRate, globalRate   float64
+	clientBurst, globalBurst int
+}
+
+// rateLimiter — состояние ограничений одного роутера.
+type rateLimiter struct {
+	mu      sync.Mutex
+	current limits
+	global  tokenBucket
+	clients map[string]*tokenBucket
+	swept   time.Time
+
+	inFlight atomic.Int64
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{clients: make(map[string]*tokenBucket)}
+}
+
+// allow — пропустить ли запрос клиента ip; иначе причина (metrics.Throttle*) и Retry-After.
+// Токены забираются, только когда пропускают оба ведра: отказ по общему лимиту не тратит лимит клиента.
+func (l *rateLimiter) allow(ip string, lim limits, now time.Time) (ok bool, reason string, wait time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lim != l.current {
+		l.current, l.global, l.clients = lim, tokenBucket{}, make(map[string]*tokenBucket)
+	}
+	var client *tokenBucket
+	if lim.clientRate > 0 {
+		l.sweep(now)
+		client = l.clients[ip]
+		if client == nil {
+			client = &tokenBucket{}
+			l.clients[ip] = client
+		}
+		if wait := client.fill(now, lim.clientRate, lim.clientBurst); wait > 0 {
+			return false, metrics.ThrottleClient, wait
+		}
+	}
+	if lim.globalRate > 0 {
+		if wait := l.global.fill(now, lim.globalRate, lim.globalBurst); wait > 0 {
+			return false, metrics.ThrottleGlobal, wait
+		}
+		l.global.tokens--
+	}
+	if client != nil {
+		client.tokens--
+	}
+	return true, "", 0
+}
+
+// sweep выбрасывает ведра, которые успели бы наполниться целиком: новое ведро будет таким же.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < sweepEvery {
+		return
+	}
+	l.swept = now
+	full := time.Duration(float64(l.current.clientBurst) / l.current.clientRate * float64(time.Second))
+	for ip, b := range l.clients {
+		if now.Sub(b.last) >= full {
+			delete(l.clients, ip)
+		}
+	}
+}
+
+// rateLimit — middleware роутера: лимиты запросов и одновременной обработки.
+func rateLimit(cfg *config.Holder) func(http.Handler) http.Handler {
+	l := newRateLimiter()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if publicPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			current := cfg.Load()
+			var lim limits
+			lim.clientRate, lim.clientBurst = current.RateClient()
+			lim.globalRate, lim.globalBurst = current.RateGlobal()
+			if ok, reason, wait := l.allow(clientIP(r), lim, time.Now()); !ok {
+				tooManyRequests(w, reason, wait)
+				return
+			}
+
+			if maxInFlight := current.InFlightLimit(); maxInFlight > 0 && !strings.HasPrefix(r.URL.Path, apiPrefix+"stream") {
+				defer l.inFlight.Add(-1)
+				if l.inFlight.Add(1) > int64(maxInFlight) {
+					tooManyRequests(w, metrics.ThrottleInFlight, time.Second)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func tooManyRequests(w http.ResponseWriter, reason string, wait time.Duration) {
+	metrics.Throttled(reason)
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(wait.Seconds())))))
+	http.Error(w, "too many requests ("+reason+" limit)", http.StatusTooManyRequests)
+}
+
+// clientIP — адрес клиента без порта.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
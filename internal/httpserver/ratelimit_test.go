@@ -0,0 +1,138 @@
+package httpserver
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"main/config"
+	"main/internal/metrics"
+)
+
+func TestTokenBucket(t *testing.T) {
+	var b tokenBucket
+	now := time.Unix(1000, 0)
+	for i := range 2 {
+		if ok, _ := b.take(now, 1, 2); !ok {
+			t.Fatalf("take %d within burst refused", i)
+		}
+	}
+	ok, wait := b.take(now, 1, 2)
+	if ok || wait != time.Second {
+		t.Fatalf("empty bucket: ok=%v wait=%s, want refused for 1s", ok, wait)
+	}
+	if ok, _ := b.take(now.Add(1500*time.Millisecond), 1, 2); !ok {
+		t.Fatalf("token must be refilled after 1.5s")
+	}
+	// полтора токена за 1.5s: один забран, остаток 0.5 — следующий через полсекунды
+	if ok, wait := b.take(now.Add(1500*time.Millisecond), 1, 2); ok || wait != 500*time.Millisecond {
+		t.Fatalf("ok=%v wait=%s, want refused for 500ms", ok, wait)
+	}
+	// за час ведро наполняется только до burst
+	now = now.Add(time.Hour)
+	for range 2 {
+		b.take(now, 1, 2)
+	}
+	if ok, _ := b.take(now, 1, 2); ok {
+		t.Fatalf("bucket must not hold more than burst")
+	}
+}
+
+// отказ по общему лимиту не должен съедать токен клиента
+func TestRateLimiter_GlobalRejectKeepsClientToken(t *testing.T) {
+	l := newRateLimiter()
+	lim := limits{clientRate: 0.001, clientBurst: 2, globalRate: 0.01, globalBurst: 1}
+	now := time.Unix(1000, 0)
+
+	if ok, _, _ := l.allow("10.0.0.1", lim, now); !ok {
+		t.Fatalf("first request refused")
+	}
+	if ok, reason, _ := l.allow("10.0.0.1", lim, now); ok || reason != metrics.ThrottleGlobal {
+		t.Fatalf("second request: ok=%v reason=%q, want global limit", ok, reason)
+	}
+	// через 100s общее ведро пополнилось, у клиента остался второй токен из burst
+	if ok, reason, _ := l.allow("10.0.0.1", lim, now.Add(100*time.Second)); !ok {
+		t.Fatalf("client token lost on global reject: reason=%q", reason)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	orig := fetchSection
+	t.Cleanup(func() { fetchSection = orig })
+	fetchSection = func(context.Context, *slog.Logger, *config.CfgApp, string) (any, time.Time, error) {
+		return []int{1, 2}, time.Now(), nil
+	}
+	cfg := &config.CfgApp{RateLimitClient: 0.01, RateBurstClient: 2, RateLimitGlobal: 0.01, RateBurstGlobal: 3}
+	router := newRouter(nil, config.NewHolder(cfg))
+	get := func(path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":12345"
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	tests := []struct {
+		name, path, ip string
+		want           int
+	}{
+		{"first", "/api/v1/support", "10.0.0.1", http.StatusOK},
+		{"burst", "/api/v1/support", "10.0.0.1", http.StatusOK},
+		{"client limit", "/api/v1/support", "10.0.0.1", http.StatusTooManyRequests},
+		{"other client", "/api/v1/support", "10.0.0.2", http.StatusOK},
+		{"probes are not limited", "/healthz", "10.0.0.1", http.StatusOK},
+		{"global limit", "/api/v1/support", "10.0.0.3", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		rr := get(tt.path, tt.ip)
+		if rr.Code != tt.want {
+			t.Fatalf("%s: code = %d, want %d", tt.name, rr.Code, tt.want)
+		}
+		// токен появится через 100s (скорость 0.01/s)
+		if tt.want == http.StatusTooManyRequests && rr.Header().Get("Retry-After") != "100" {
+			t.Fatalf("%s: Retry-After = %q, want 100", tt.name, rr.Header().Get("Retry-After"))
+		}
+	}
+}
+
+func TestRateLimit_InFlight(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	orig := fetchSection
+	t.Cleanup(func() { fetchSection = orig })
+	fetchSection = func(context.Context, *slog.Logger, *config.CfgApp, string) (any, time.Time, error) {
+		entered <- struct{}{}
+		<-release
+		return []int{1, 2}, time.Now(), nil
+	}
+	router := newRouter(nil, config.NewHolder(&config.CfgApp{MaxInFlight: 1}))
+	get := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/support", nil))
+		return rr
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if rr := get(); rr.Code != http.StatusOK {
+			t.Errorf("first request: code = %d", rr.Code)
+		}
+	}()
+	<-entered
+
+	if rr := get(); rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "1" {
+		t.Fatalf("second request: code = %d Retry-After = %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	close(release)
+	wg.Wait()
+
+	go func() { <-entered }()
+	if rr := get(); rr.Code != http.StatusOK {
+		t.Fatalf("after release: code = %d", rr.Code)
+	}
+}
@@ -0,0 +1,266 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"main/config"
+	res "main/internal/mainfetcher"
+	m "main/internal/model"
+)
+
+/*
+Формат ответа "/" и /api/v1/<секция> выбирается параметром ?format= или заголовком Accept:
+
+	json — как раньше (application/json, любой тип или без Accept)
+	csv  — text/csv: у секции — её записи, у "/" — сводка по секциям (section, state, code, error)
+	html — text/html: страница статуса с таблицей на каждую собранную секцию (браузер присылает Accept: text/html)
+
+?format= важнее Accept; неизвестный ?format= — 400, неподходящий Accept — JSON.
+Метрики в формате Prometheus — на /metrics.
+*/
+
+// Форматы ответа
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+	formatHTML = "html"
+)
+
+var contentTypes = map[string]string{
+	formatJSON: "application/json",
+	formatCSV:  "text/csv; charset=utf-8",
+	formatHTML: "text/html; charset=utf-8",
+}
+
+// negotiate выбирает формат ответа: ?format=, иначе тип с наибольшим q из Accept (при равенстве — первый).
+func negotiate(r *http.Request) (string, error) {
+	if f := r.URL.Query().Get("format"); f != "" {
+		if _, ok := contentTypes[f]; !ok {
+			return "", fmt.Errorf("format must be one of json, csv, html")
+		}
+		return f, nil
+	}
+
+	best, bestQ := formatJSON, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		var format string
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "application/json", "application/*", "*/*":
+			format = formatJSON
+		case "text/csv":
+			format = formatCSV
+		case "text/html", "application/xhtml+xml":
+			format = formatHTML
+		default:
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best, nil
+}
+
+// writeFormat пишет ответ в формате format; csv и page вызываются только для своего формата.
+// Условный GET и gzip — как у JSON (writeBody), версии Last-Modified у каждого формата свои.
+func writeFormat(w http.ResponseWriter, r *http.Request, format string, status int, key string, v any,
+	csvTable func() table, page func() htmlPage) error {
+
+	w.Header().Add("Vary", "Accept")
+	var (
+		body []byte
+		err  error
+	)
+	switch format {
+	case formatCSV:
+		body, err = encodeCSV(csvTable())
+	case formatHTML:
+		body, err = encodeHTML(page())
+	default:
+		return writeJSON(w, r, status, v, key)
+	}
+	if err != nil {
+		return err
+	}
+	return writeBody(w, r, status, contentTypes[format], body, key+"|"+format)
+}
+
+// table — секция в виде таблицы для CSV и HTML.
+type table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// sectionTable раскладывает данные секции (см. mainfetcher.SectionData) в строки таблицы.
+// У sms/mms/email несколько выборок (отсортированных по-разному) — номер выборки в колонке batch.
+func sectionTable(data any) table {
+	switch d := data.(type) {
+	case [][]m.SMSData:
+		return batchTable(d)
+	case [][]m.MMSData:
+		return batchTable(d)
+	case []m.VoiceCallData:
+		return rowsTable(d)
+	case map[string][][]m.EmailData:
+		t := table{Header: append([]string{"batch"}, fieldNames[m.EmailData]()...)}
+		countries := make([]string, 0, len(d))
+		for c := range d {
+			countries = append(countries, c)
+		}
+		slices.Sort(countries)
+		for _, c := range countries {
+			t.Rows = append(t.Rows, batchTable(d[c]).Rows...)
+		}
+		return t
+	case m.BillingData:
+		return rowsTable([]m.BillingData{d})
+	case []int:
+		// support: []int{loadLevel, waitMinutes}, см. support.BuildSortedSupport
+		t := table{Header: []string{"load", "wait_minutes"}}
+		if len(d) == 2 {
+			t.Rows = [][]string{{strconv.Itoa(d[0]), strconv.Itoa(d[1])}}
+		}
+		return t
+	case []m.IncidentData:
+		return rowsTable(d)
+	}
+	return table{}
+}
+
+func rowsTable[T any](items []T) table {
+	t := table{Header: fieldNames[T]()}
+	for _, it := range items {
+		t.Rows = append(t.Rows, fieldValues(it))
+	}
+	return t
+}
+
+func batchTable[T any](batches [][]T) table {
+	t := table{Header: append([]string{"batch"}, fieldNames[T]()...)}
+	for i, batch := range batches {
+		for _, it := range batch {
+			t.Rows = append(t.Rows, append([]string{strconv.Itoa(i + 1)}, fieldValues(it)...))
+		}
+	}
+	return t
+}
+
+// fieldNames — колонки как ключи в JSON-ответе: json-тег, иначе имя поля.
+func fieldNames[T any]() []string {
+	typ := reflect.TypeFor[T]()
+	names := make([]string, 0, typ.NumField())
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func fieldValues(v any) []string {
+	rv := reflect.ValueOf(v)
+	out := make([]string, rv.NumField())
+	for i := range out {
+		out[i] = fmt.Sprint(rv.Field(i).Interface())
+	}
+	return out
+}
+
+func encodeCSV(t table) ([]byte, error) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	_ = cw.Write(t.Header)
+	_ = cw.WriteAll(t.Rows) // WriteAll делает Flush
+	return buf.Bytes(), cw.Error()
+}
+
+// summaryTable — сводка "/" для CSV: состояние и причина по каждой секции.
+func summaryTable(r m.ResultT) table {
+	errs := make(map[string]m.SectionError, len(r.Errors))
+	for _, e := range r.Errors {
+		errs[e.Section] = e
+	}
+	t := table{Header: []string{"section", "state", "code", "error"}}
+	for _, source := range config.Sources {
+		e := errs[source]
+		t.Rows = append(t.Rows, []string{source, r.Sections[source], e.Code, e.Message})
+	}
+	return t
+}
+
+// htmlSection — секция на HTML-странице.
+type htmlSection struct {
+	Name  string
+	State string
+	Code  string
+	Error string
+	Table table
+}
+
+type htmlPage struct {
+	Title    string
+	State    string
+	At       time.Time
+	Sections []htmlSection
+}
+
+var pageTmpl = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Title}}</title>
+<style>
+body{font-family:sans-serif;margin:2em}table{border-collapse:collapse;margin-bottom:1.5em}
+td,th{border:1px solid #ccc;padding:2px 8px;text-align:left}
+.ok{color:green}.stale,.degraded{color:darkorange}.missing,.failed{color:red}.disabled{color:gray}
+</style></head><body>
+<h1>{{.Title}}{{if .State}} — <span class="{{.State}}">{{.State}}</span>{{end}}</h1>
+{{if not .At.IsZero}}<p>collected at {{.At.Format "2006-01-02 15:04:05 MST"}}</p>{{end}}
+{{range .Sections}}
+<h2>{{.Name}} <span class="{{.State}}">{{.State}}</span></h2>
+{{if .Error}}<p class="missing">{{.Code}}: {{.Error}}</p>{{end}}
+{{if .Table.Rows}}<table><tr>{{range .Table.Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Table.Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>{{end}}
+{{end}}
+</body></html>
+`))
+
+func encodeHTML(p htmlPage) ([]byte, error) {
+	var buf bytes.Buffer
+	err := pageTmpl.Execute(&buf, p)
+	return buf.Bytes(), err
+}
+
+// resultPage — страница "/": сводка и таблица каждой собранной (ok/stale) секции.
+func resultPage(rs m.ResultSetT, r m.ResultT, at time.Time) htmlPage {
+	p := htmlPage{Title: "State collector", State: r.State, At: at}
+	errs := make(map[string]m.SectionError, len(r.Errors))
+	for _, e := range r.Errors {
+		errs[e.Section] = e
+	}
+	for _, source := range config.Sources {
+		s := htmlSection{Name: source, State: r.Sections[source], Code: errs[source].Code, Error: errs[source].Message}
+		if s.State == m.StatusOK || s.State == m.SectionStale {
+			s.Table = sectionTable(res.SectionData(rs, source))
+		}
+		p.Sections = append(p.Sections, s)
+	}
+	return p
+}
@@ -0,0 +1,119 @@
+package httpserver
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"main/config"
+	m "main/internal/model"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name, query, accept string
+		want                string
+		wantErr             bool
+	}{
+		{"no accept", "", "", formatJSON, false},
+		{"curl", "", "*/*", formatJSON, false},
+		{"browser", "", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", formatHTML, false},
+		{"csv", "", "text/csv", formatCSV, false},
+		{"q wins", "", "text/html;q=0.5, text/csv", formatCSV, false},
+		{"unsupported accept", "", "application/xml", formatJSON, false},
+		{"query wins", "?format=csv", "text/html", formatCSV, false},
+		{"bad query", "?format=xml", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			req.Header.Set("Accept", tt.accept)
+			got, err := negotiate(req)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("negotiate = %q, %v; want %q (err %v)", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSectionTable(t *testing.T) {
+	sms := [][]m.SMSData{
+		{{Country: "RU", Bandwidth: "40", ResponseTime: "100", Provider: "Rond"}},
+		{{Country: "US", Bandwidth: "10", ResponseTime: "200", Provider: "Kildy"}},
+	}
+	got := sectionTable(sms)
+	want := table{
+		Header: []string{"batch", "Country", "Bandwidth", "ResponseTime", "Provider"},
+		Rows:   [][]string{{"1", "RU", "40", "100", "Rond"}, {"2", "US", "10", "200", "Kildy"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sms table = %+v", got)
+	}
+
+	if got := sectionTable([]m.IncidentData{{Topic: "down", Status: "active"}}); !reflect.DeepEqual(got.Header, []string{"topic", "status"}) {
+		t.Fatalf("incident header must use json tags: %v", got.Header)
+	}
+	if got := sectionTable([]int{2, 15}); !reflect.DeepEqual(got.Rows, [][]string{{"2", "15"}}) {
+		t.Fatalf("support rows = %v", got.Rows)
+	}
+}
+
+func TestHandlers_Formats(t *testing.T) {
+	origFetch, origSection := fetch, fetchSection
+	t.Cleanup(func() { fetch, fetchSection = origFetch, origSection })
+	fetch = func(context.Context, *slog.Logger, *config.CfgApp) (m.ResultSetT, m.ResultT, time.Time) {
+		return m.ResultSetT{Support: []int{3, 20}}, m.ResultT{
+			State:    m.StatusDegraded,
+			Sections: map[string]string{config.SourceSupport: m.StatusOK, config.SourceSMS: m.SectionMissing},
+			Errors:   []m.SectionError{{Section: config.SourceSMS, Code: m.ErrCodeTimeout, Message: "sms <timeout>"}},
+		}, time.Now()
+	}
+	fetchSection = func(_ context.Context, _ *slog.Logger, _ *config.CfgApp, source string) (any, time.Time, error) {
+		if source == config.SourceSMS {
+			return nil, time.Time{}, m.SectionError{Section: source, Code: m.ErrCodeEmpty, Message: "sms empty"}
+		}
+		return []int{3, 20}, time.Now(), nil
+	}
+	router := newRouter(nil, config.NewHolder(&config.CfgApp{}))
+
+	tests := []struct {
+		name, path, accept string
+		wantCode           int
+		wantType           string
+		wantBody           []string
+	}{
+		{"root csv", "/?format=csv", "", http.StatusOK, "text/csv; charset=utf-8",
+			[]string{"section,state,code,error\n", "sms,missing,timeout,sms <timeout>\n", "support,ok,,\n"}},
+		{"root html", "/", "text/html", http.StatusOK, "text/html; charset=utf-8",
+			[]string{"<td>20</td>", "sms &lt;timeout&gt;", `class="degraded"`}},
+		{"root json", "/", "application/json", http.StatusOK, "application/json", []string{`"state":"degraded"`}},
+		{"section csv", "/api/v1/support", "text/csv", http.StatusOK, "text/csv; charset=utf-8",
+			[]string{"load,wait_minutes\n3,20\n"}},
+		{"section csv error", "/api/v1/sms?format=csv", "", http.StatusServiceUnavailable, "text/csv; charset=utf-8",
+			[]string{"code,error\nempty,sms empty\n"}},
+		{"section html", "/api/v1/sms?format=html", "", http.StatusServiceUnavailable, "text/html; charset=utf-8",
+			[]string{"empty: sms empty"}},
+		{"bad format", "/api/v1/sms?format=xml", "", http.StatusBadRequest, "text/plain; charset=utf-8", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.wantCode || rr.Header().Get("Content-Type") != tt.wantType {
+				t.Fatalf("code=%d type=%q, want %d %q", rr.Code, rr.Header().Get("Content-Type"), tt.wantCode, tt.wantType)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(rr.Body.String(), want) {
+					t.Errorf("body does not contain %q:\n%s", want, rr.Body.String())
+				}
+			}
+		})
+	}
+}
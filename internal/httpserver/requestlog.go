@@ -0,0 +1,95 @@
+package httpserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"main/internal/httpx"
+	"main/sl"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*
+У каждого запроса есть ID: клиентский X-Request-ID (если он разумный), иначе случайный. ID:
+
+	— возвращается клиенту в X-Request-ID;
+	— попадает в логгер запроса (атрибут request_id), который хендлеры берут из контекста (sl.FromContext)
+	  и передают в сбор данных — строки mainfetcher/httpx этого запроса связаны с ним;
+	— уходит заголовком X-Request-ID во все запросы к источникам, сделанные ради этого запроса (httpx.WithRequestID).
+	  Сбор, общий для нескольких запросов (кэш, singleflight), несёт ID запроса, который его запустил.
+
+По завершении — строка лога: method, path, status, dur, bytes. Пробы и /metrics — на уровне Debug, чтобы не шуметь.
+Если запрос идёт в трассе (trace.go), в логгере запроса есть и trace_id.
+*/
+
+// maxRequestIDLen — длиннее клиентский ID не принимаем (он попадает в логи и заголовки апстримов).
+const maxRequestIDLen = 128
+
+// quietPaths — запросы, которые логируются на уровне Debug.
+var quietPaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// requestLog — внешний middleware роутера: ID запроса, логгер запроса в контексте и строка лога на запрос.
+func requestLog(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			id := r.Header.Get(httpx.HeaderRequestID)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(httpx.HeaderRequestID, id)
+
+			ctx := httpx.WithRequestID(r.Context(), id)
+			var l *slog.Logger
+			if logger != nil {
+				l = logger.With(slog.String("request_id", id))
+				if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+					l = l.With(slog.String("trace_id", sc.TraceID().String())) // связать строки лога с трассой
+				}
+				ctx = sl.WithLogger(ctx, l)
+			}
+			rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+			if l == nil {
+				return
+			}
+
+			level := slog.LevelInfo
+			if quietPaths[r.URL.Path] {
+				level = slog.LevelDebug
+			}
+			l.LogAttrs(r.Context(), level, "http request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.code),
+				slog.Duration("dur", time.Since(start)),
+				slog.Int64("bytes", rec.bytes),
+				slog.String("remote", r.RemoteAddr),
+			)
+		})
+	}
+}
+
+// validRequestID — печатный ASCII без пробелов и не длиннее maxRequestIDLen: такой ID безопасно
+// писать в логи и пересылать апстримам.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:]) // crypto/rand.Read не возвращает ошибок
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,106 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"main/config"
+	"main/internal/httpx"
+)
+
+// logLines — строки JSON-лога.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var v map[string]any
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			t.Fatalf("bad log line %q: %v", line, err)
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestRequestLog(t *testing.T) {
+	var collectID string
+	orig := fetchSection
+	t.Cleanup(func() { fetchSection = orig })
+	fetchSection = func(ctx context.Context, logger *slog.Logger, _ *config.CfgApp, source string) (any, time.Time, error) {
+		collectID = httpx.RequestID(ctx)
+		logger.Info("collect", slog.String("source", source))
+		return []int{1, 2}, time.Now(), nil
+	}
+
+	tests := []struct {
+		name, header string
+		keep         bool // ID клиента сохраняется
+	}{
+		{"client id", "req-42", true},
+		{"no id", "", false},
+		{"id with spaces", "bad id", false},
+		{"too long", strings.Repeat("x", maxRequestIDLen+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			router := newRouter(slog.New(slog.NewJSONHandler(&buf, nil)), config.NewHolder(&config.CfgApp{}))
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/support", nil)
+			if tt.header != "" {
+				req.Header.Set(httpx.HeaderRequestID, tt.header)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			id := rr.Header().Get(httpx.HeaderRequestID)
+			if tt.keep && id != tt.header || !tt.keep && (len(id) != 32 || id == tt.header) {
+				t.Fatalf("X-Request-ID = %q (sent %q)", id, tt.header)
+			}
+			if collectID != id {
+				t.Fatalf("collection context id = %q, want %q", collectID, id)
+			}
+
+			lines := logLines(t, &buf)
+			if len(lines) != 2 || lines[0]["msg"] != "collect" || lines[0]["request_id"] != id {
+				t.Fatalf("collect log must carry request_id: %v", lines)
+			}
+			access := lines[1]
+			if access["msg"] != "http request" || access["request_id"] != id || access["status"] != float64(http.StatusOK) ||
+				access["path"] != "/api/v1/support" || access["bytes"] != float64(rr.Body.Len()) {
+				t.Fatalf("access log = %v", access)
+			}
+		})
+	}
+}
+
+// сбор для кэша общий для всех ждущих запросов: апстрим получает его собственный ID, а не ID запустившего запроса
+func TestRequestLog_UpstreamHeader(t *testing.T) {
+	got := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Get(httpx.HeaderRequestID)
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer upstream.Close()
+
+	cfg := &config.CfgApp{PathSupportData: upstream.URL}
+	router := newRouter(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)), config.NewHolder(cfg))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/support", nil)
+	req.Header.Set(httpx.HeaderRequestID, "trace-me")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case id := <-got:
+		if !validRequestID(id) || id == "trace-me" {
+			t.Fatalf("upstream %s = %q, want the refresh's own ID", httpx.HeaderRequestID, id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("upstream was not called")
+	}
+}
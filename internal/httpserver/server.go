@@ -2,7 +2,6 @@ package httpserver
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -10,10 +9,11 @@ import (
 	"main/sl"
 	"net"
 	"net/http"
-	"sync"
+	"strconv"
 	"time"
 
 	res "main/internal/mainfetcher"
+	"main/internal/metrics"
 	"main/internal/model"
 
 	"github.com/gorilla/mux"
@@ -21,98 +21,106 @@ import (
 
 //var fetch = res.GetResultData
 
-type resultGetter func(context.Context, *slog.Logger, *config.CfgApp) (model.ResultSetT, model.ResultT)
+// resultGetter — полный результат сбора и время, когда он собран (для X-Data-Age).
+type resultGetter func(context.Context, *slog.Logger, *config.CfgApp) (model.ResultSetT, model.ResultT, time.Time)
 
 // --- КЭШ ---
+// stale-while-revalidate, время жизни — config.CfgApp.CacheTTL (по умолчанию 10s), см. cache.go
 
-const cacheTTL = 10 * time.Second
-
-var (
-	cacheMu     sync.RWMutex
-	cacheRS     model.ResultSetT
-	cacheR      model.ResultT
-	cacheExp    time.Time
-	cleanerOnce sync.Once
-)
-
-// чистильщик кэша по тикеру; привязан к parentCtx, чтобы не течь
-func startCacheCleaner(ctx context.Context) {
-	cleanerOnce.Do(func() { //вложенная функция выполнится ровно один раз за весь жизненный цикл процесса, даже если startCacheCleaner вызовут многократно
-		t := time.NewTicker(cacheTTL) //шлёт «тики» в свой канал t.C каждые cacheTTL
-		go func() {
-			defer t.Stop()
-			for {
-				select {
-				case <-t.C:
-					cacheMu.Lock()
-					cacheRS = model.ResultSetT{}
-					cacheR = model.ResultT{}
-					cacheExp = time.Time{}
-					cacheMu.Unlock()
-				case <-ctx.Done():
-					return
-				}
-			}
-		}()
-	})
+type fullResult struct {
+	rs model.ResultSetT
+	r  model.ResultT
 }
 
+var fullCache = newSWRCache[fullResult]("full")
+
 // реализация через обёртку, чтобы спрятать varargs  custom ...fetcher и чужой тип
 // var fetch resultGetter = func(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) (model.ResultSetT, model.ResultT) {
 // 	return res.GetResultData(ctx, logger, cfg) // varargs нам тут не нужны
 // }
 
-var fetch resultGetter = func(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) (model.ResultSetT, model.ResultT) {
-	now := time.Now()
-	cacheMu.RLock() //разрешает параллельные чтения кэша и блокирует писателей (те, кто делает Lock()).
-
-	if !cacheExp.IsZero() && now.Before(cacheExp) {
-		// проверяем, «валиден ли кэш»:
-		//cacheExp.IsZero() — истина, когда срок годности не установлен (нулевое time.Time), значит кэша ещё нет. Мы хотим обратное, поэтому !….
-		//now.Before(cacheExp) — текущее время (now взято ранее как time.Now()) всё ещё раньше времени истечения.
-
-		rs, r := cacheRS, cacheR //копируем значения из глобальных кэшей в локальные переменные
-		cacheMu.RUnlock()        // важно: освободили перед тяжёлой GetResultData и перед Lock()
-		return rs, r
+var fetch resultGetter = func(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) (model.ResultSetT, model.ResultT, time.Time) {
+	if p, ok := latest(cfg); ok {
+		return p.rs, p.r, p.at // фоновый сбор: только последний снимок (snapshot.go)
 	}
-	cacheMu.RUnlock() // важно: освободили перед тяжёлой GetResultData и перед Lock()
-
-	// нет валидного кэша — собираем заново
-	rs, r := res.GetResultData(ctx, logger, cfg)
+	v, at, err := fullCache.get(ctx, "/", cfg, func(ctx context.Context, prev fullResult, ok bool) (fullResult, error) {
+		logger := sl.FromContext(ctx, logger) // сбор общий: логгер сервера из контекста refresh, а не запроса
+		rs, r := res.GetResultData(ctx, logger, cfg)
+		if ok {
+			// источник, не ответивший сейчас, отдаёт последнее хорошее значение (секция stale)
+			rs, r = res.KeepStale(rs, r, prev.rs, prev.r, cfg)
+		}
+		stream.publish(newStreamUpdate(rs, r, time.Now()))
+		return fullResult{rs, r}, nil
+	})
+	if err != nil {
+		// запрос отменён раньше, чем закончился сбор; сам сбор доработает и заполнит кэш
+		return model.ResultSetT{}, model.ResultT{}, time.Time{}
+	}
+	return v.rs, v.r, at
+}
 
-	cacheMu.Lock()
-	cacheRS, cacheR = rs, r
-	cacheExp = time.Now().Add(cacheTTL)
-	cacheMu.Unlock()
+// newRouter — все маршруты сервиса: legacy "/", /api/v1/<секция>, поток /api/v1/stream, служебные пробы (health.go) и /metrics.
+func newRouter(logger *slog.Logger, cfg *config.Holder) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(traceRequest)       // server span запроса (trace.go); первым — чтобы в трассу попадали и отказы 429/401
+	router.Use(requestLog(logger)) // X-Request-ID и лог запросов (requestlog.go); до лимитов — чтобы 429/401 тоже логировались
+	router.Use(instrument)
+	router.Use(rateLimit(cfg))            // 429 сверх http.limits (ratelimit.go); раньше auth — перебор ключей тоже ограничен
+	router.Use(authenticate(logger, cfg)) // ключи API и scopes (auth.go); без ключей в конфиге — пропускает всё
+	router.HandleFunc("/healthz", handleHealthz).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", makeHandleReadyz(cfg)).Methods(http.MethodGet)
+	router.HandleFunc("/status", makeHandleStatus(cfg)).Methods(http.MethodGet)
+	router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+
+	// legacy: весь APIResponse целиком
+	router.HandleFunc("/", makeHandleConnection(logger, cfg)).Methods(http.MethodGet)
 
-	return rs, r
+	for _, s := range apiSections {
+		router.HandleFunc(apiPrefix+s.path, makeHandleSection(logger, cfg, s.source)).Methods(http.MethodGet)
+	}
+	// live-обновления (stream.go)
+	router.HandleFunc(apiPrefix+"stream", makeHandleStream(logger, cfg)).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+"stream/ws", makeHandleStreamWS(logger, cfg)).Methods(http.MethodGet)
+	return router
 }
 
-// HttpServer вызывает serveOnListener для возможности тестов с подменой serveOnListener
-func HttpServer(parentCtx context.Context, logger *slog.Logger, cfg *config.CfgApp) error {
-	ln, err := net.Listen("tcp", cfg.HTTPAddr)
+// HttpServer вызывает serveOnListener для возможности тестов с подменой serveOnListener.
+// cfg — holder текущего конфига: каждый запрос берёт свежий снимок, поэтому hot reload
+// подхватывается без перезапуска (кроме адреса, TLS и таймаутов сервера — они берутся при старте, см. config.restartOnly).
+func HttpServer(parentCtx context.Context, logger *slog.Logger, cfg *config.Holder) error {
+	addr := cfg.Load().HTTPAddr
+	ln, err := net.Listen("tcp", addr)
 	if err != nil {
-		return fmt.Errorf("listen %s: %w", cfg.HTTPAddr, err)
+		return fmt.Errorf("listen %s: %w", addr, err)
 	}
 	return serveOnListener(parentCtx, logger, cfg, ln)
 }
 
-func serveOnListener(parentCtx context.Context, logger *slog.Logger, cfg *config.CfgApp, ln net.Listener) error {
-	startCacheCleaner(parentCtx)
-
-	router := mux.NewRouter()
-	// один обработчик для "/"
-	router.HandleFunc("/", makeHandleConnection(logger, cfg)).Methods(http.MethodGet)
+func serveOnListener(parentCtx context.Context, logger *slog.Logger, cfg *config.Holder, ln net.Listener) error {
+	startCfg := cfg.Load() // таймауты сервера фиксируются при старте
+	readTO, writeTO, readHeaderTO, idleTO := startCfg.ServerTimeouts()
 
 	srv := &http.Server{
-		Handler:           router,
-		Addr:              cfg.HTTPAddr,
-		ReadTimeout:       15 * time.Second,
-		WriteTimeout:      15 * time.Second,
-		ReadHeaderTimeout: 5 * time.Second,  // защита от slowloris
-		IdleTimeout:       60 * time.Second, // корректные keep-alive
+		Handler:           newRouter(logger, cfg),
+		Addr:              ln.Addr().String(),
+		ReadTimeout:       readTO,
+		WriteTimeout:      writeTO,
+		ReadHeaderTimeout: readHeaderTO, // защита от slowloris
+		IdleTimeout:       idleTO,       // корректные keep-alive
 		// Все входящие запросы унаследуют parentCtx:
-		BaseContext: func(net.Listener) context.Context { return parentCtx }, //теперь каждый r.Context() — потомок parentCtx. Когда parentCtx отменится, текущие хендлеры увидят <-r.Context().Done() и корректно завершатся.
+		BaseContext: func(net.Listener) context.Context { return serverContext(parentCtx, logger) }, //теперь каждый r.Context() — потомок parentCtx. Когда parentCtx отменится, текущие хендлеры увидят <-r.Context().Done() и корректно завершатся.
+	}
+
+	// HTTPS + HTTP/2, если в конфиге есть сертификат (tls.go)
+	serve := func() error { return srv.Serve(ln) }
+	if startCfg.TLSEnabled() {
+		tlsCfg, err := newTLSConfig(logger, startCfg)
+		if err != nil {
+			return fmt.Errorf("TLS: %w", err)
+		}
+		srv.TLSConfig = tlsCfg
+		serve = func() error { return srv.ServeTLS(ln, "", "") } // сертификат отдаёт tlsCfg.GetCertificate
 	}
 
 	//вместо ListenAndServe тока контролируемо вручную - вынесено в HttpServer
@@ -126,18 +134,21 @@ func serveOnListener(parentCtx context.Context, logger *slog.Logger, cfg *config
 	go func() {
 		// запускаем сервер в отдельной горутине
 		// Важно: не делать log.Fatal внутри горутины
-		logger.Info("HTTP server start running at: " + cfg.HTTPAddr)
-		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) { // если сервер упал с реальной ошибкой (порт занят, паника и т.п.)
+		logger.Info("HTTP server start running at: "+ln.Addr().String(), slog.Bool("tls", srv.TLSConfig != nil))
+		if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) { // если сервер упал с реальной ошибкой (порт занят, паника и т.п.)
 			errc <- err
 		} else {
 			errc <- nil
 
 		}
 	}()
+	ready.Store(true) // листенер уже открыт, соединения принимаются (очередь ядра) ещё до Serve
+	defer ready.Store(false)
 
 	// Ждём либо отмену контекста, либо ошибку сервера
 	select {
 	case <-parentCtx.Done():
+		ready.Store(false) // балансировщик снимает нас с трафика, пока дорабатывают активные запросы
 		// Нельзя использовать parentCtx для Shutdown: ато Shutdown сразу же увидит, что parentCtx уже отменён, и мгновенно завершит все соединения (форсировано без graceful), т.е. никакого «подождать активные запросы 5 секунд» не будет
 		// если Go >= 1.21 — лучше так:
 		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(parentCtx), 5*time.Second) // теперь это новый контекст, который не отменится сразу по SIGTERM,а отменится через 5 секунд, если Shutdown не успеет завершить все запросы
@@ -181,18 +192,40 @@ func serveOnListener(parentCtx context.Context, logger *slog.Logger, cfg *config
 // 	})
 // }
 
-// хендлер: берёт контекст запроса, вызывает GetResultData и отдаёт JSON
-func makeHandleConnection(logger *slog.Logger, cfg *config.CfgApp) http.HandlerFunc {
+// хендлер: берёт контекст запроса, вызывает GetResultData и отдаёт JSON (или CSV/HTML, см. render.go)
+func makeHandleConnection(logger *slog.Logger, cfg *config.Holder) http.HandlerFunc {
 	type APIResponse struct {
 		ResultSet model.ResultSetT `json:"resultSet"`
 		Result    model.ResultT    `json:"result"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
+		logger := sl.FromContext(r.Context(), logger) // логгер запроса с request_id (requestlog.go)
+		current := cfg.Load()                         // снимок конфига на весь запрос
+
+		// ?partial=true|false переопределяет PartialResults из конфига для этого запроса
+		partial := current != nil && current.PartialResults
+		if q := r.URL.Query().Get("partial"); q != "" {
+			v, err := strconv.ParseBool(q)
+			if err != nil {
+				http.Error(w, "partial must be true or false", http.StatusBadRequest)
+				return
+			}
+			partial = v
+		}
+		format, err := negotiate(r) // json | csv | html, см. render.go
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		// общий бюджет на сбор данных в рамках запроса (опционально)
-		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second) //Небольшой per-request таймаут (WithTimeout(r.Context(), 10s)) — чтобы не зависнуть, даже если кто-то внутри подвис.
+		ctx, cancel := context.WithTimeout(r.Context(), current.HandlerBudget()) //Небольшой per-request таймаут (WithTimeout(r.Context(), 10s)) — чтобы не зависнуть, даже если кто-то внутри подвис.
 		defer cancel()
 
-		rs, rr := fetch(ctx, logger, cfg)
+		rs, rr, at := fetch(ctx, logger, current)
+		if rr.State != "" { // результат собран BuildResultT (не заглушка) — режим можно переключить
+			rr = res.WithMode(rr, rs, partial)
+		}
 
 		// если клиент уже отвалился/таймаут — не пишем ответ
 		select {
@@ -201,10 +234,12 @@ func makeHandleConnection(logger *slog.Logger, cfg *config.CfgApp) http.HandlerF
 		default:
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		enc := json.NewEncoder(w)
-		// enc.SetIndent("", "  ") // если нужен красивый вывод
-		if err := enc.Encode(APIResponse{ResultSet: rs, Result: rr}); err != nil {
+		setDataAge(w, at)
+		// ETag/Last-Modified и gzip — conditional.go; у strict и partial разные тела, поэтому и версии разные
+		err = writeFormat(w, r, format, http.StatusOK, "/?partial="+strconv.FormatBool(partial), APIResponse{ResultSet: rs, Result: rr},
+			func() table { return summaryTable(rr) },
+			func() htmlPage { return resultPage(rs, rr, at) })
+		if err != nil {
 			http.Error(w, "encode error: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"main/config"
+	res "main/internal/mainfetcher"
 
 	m "main/internal/model"
 )
@@ -122,7 +123,7 @@ func TestHandler_HappyPath(t *testing.T) {
 	// подменяем fetch
 	orig := fetch
 	t.Cleanup(func() { fetch = orig })
-	fetch = func(ctx context.Context, _ *slog.Logger, _ *config.CfgApp) (m.ResultSetT, m.ResultT) {
+	fetch = func(ctx context.Context, _ *slog.Logger, _ *config.CfgApp) (m.ResultSetT, m.ResultT, time.Time) {
 		return m.ResultSetT{ //данные квази-пустые, len покажет = 1
 			SMS:       [][]m.SMSData{[]m.SMSData{m.SMSData{}}},
 			MMS:       [][]m.MMSData{[]m.MMSData{m.MMSData{}}},
@@ -131,15 +132,15 @@ func TestHandler_HappyPath(t *testing.T) {
 			Billing:   nonZeroBilling(),
 			Support:   []int{1},
 			Incidents: []m.IncidentData{m.IncidentData{}},
-		}, m.ResultT{}
+		}, m.ResultT{}, time.Now()
 	}
 
 	rr := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 
-	makeHandleConnection(nil, &config.CfgApp{})(rr, req) //(rr, req) — это моментальный вызов возвращённой функции с аргументами: rr — httptest.NewRecorder(), это *httptest.ResponseRecorder, который реализует http.ResponseWriter
+	makeHandleConnection(nil, config.NewHolder(&config.CfgApp{}))(rr, req) //(rr, req) — это моментальный вызов возвращённой функции с аргументами: rr — httptest.NewRecorder(), это *httptest.ResponseRecorder, который реализует http.ResponseWriter
 	/*нагляднее так
-	  h := makeHandleConnection(nil, &config.CfgApp{}) // h имеет тип http.HandlerFunc
+	  h := makeHandleConnection(nil, config.NewHolder(&config.CfgApp{})) // h имеет тип http.HandlerFunc
 	  h(rr, req)                                       // вызываем обработчик напрямую
 	  // или так:
 	  h.ServeHTTP(rr, req) // у http.HandlerFunc есть метод ServeHTTP, он вызывает саму функцию
@@ -159,16 +160,61 @@ func TestHandler_HappyPath(t *testing.T) {
 	}
 }
 
+// ?partial переключает режим поверх результата из fetch: strict — data пустой, partial — собранные секции
+func TestHandler_PartialQuery(t *testing.T) {
+	orig := fetch
+	t.Cleanup(func() { fetch = orig })
+	rs := m.ResultSetT{Support: []int{1}} // собран только support
+	fetch = func(context.Context, *slog.Logger, *config.CfgApp) (m.ResultSetT, m.ResultT, time.Time) {
+		return rs, res.BuildResultT(rs, nil, nil), time.Now()
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		cfg         *config.CfgApp
+		wantCode    int
+		wantSupport bool
+	}{
+		{"config strict", "", &config.CfgApp{}, http.StatusOK, false},
+		{"config partial", "", &config.CfgApp{PartialResults: true}, http.StatusOK, true},
+		{"query partial", "?partial=true", &config.CfgApp{}, http.StatusOK, true},
+		{"query strict", "?partial=0", &config.CfgApp{PartialResults: true}, http.StatusOK, false},
+		{"bad query", "?partial=maybe", &config.CfgApp{}, http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			makeHandleConnection(nil, config.NewHolder(tt.cfg))(rr, httptest.NewRequest(http.MethodGet, "/"+tt.query, nil))
+			if rr.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var payload struct {
+				Result m.ResultT `json:"result"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+				t.Fatalf("bad json: %v", err)
+			}
+			if got := len(payload.Result.Data.Support) > 0; got != tt.wantSupport || payload.Result.State != m.StatusDegraded {
+				t.Fatalf("result = %+v", payload.Result)
+			}
+		})
+	}
+}
+
 func TestHandler_ContextCanceled_NoWrite(t *testing.T) {
 	orig := fetch
 	t.Cleanup(func() { fetch = orig })
 
 	var entered atomic.Bool
 	// имитируем долгий сбор: ждём отмены ctx
-	fetch = func(ctx context.Context, _ *slog.Logger, _ *config.CfgApp) (m.ResultSetT, m.ResultT) {
+	fetch = func(ctx context.Context, _ *slog.Logger, _ *config.CfgApp) (m.ResultSetT, m.ResultT, time.Time) {
 		entered.Store(true)
 		<-ctx.Done()
-		return m.ResultSetT{}, m.ResultT{}
+		return m.ResultSetT{}, m.ResultT{}, time.Time{}
 	}
 
 	rr := httptest.NewRecorder()
@@ -177,7 +223,7 @@ func TestHandler_ContextCanceled_NoWrite(t *testing.T) {
 	cancel()
 	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(parentCtx)
 
-	makeHandleConnection(nil, &config.CfgApp{})(rr, req)
+	makeHandleConnection(nil, config.NewHolder(&config.CfgApp{}))(rr, req)
 
 	// хендлер должен вернуться, ничего не записав
 	if rr.Body.Len() != 0 {
@@ -193,9 +239,9 @@ func TestHttpServer_GracefulShutdown(t *testing.T) {
 	// Мокаем fetch, чтобы хендлер «подумал»
 	orig := fetch
 	t.Cleanup(func() { fetch = orig })
-	fetch = func(ctx context.Context, _ *slog.Logger, _ *config.CfgApp) (m.ResultSetT, m.ResultT) {
+	fetch = func(ctx context.Context, _ *slog.Logger, _ *config.CfgApp) (m.ResultSetT, m.ResultT, time.Time) {
 		time.Sleep(100 * time.Millisecond)
-		return m.ResultSetT{}, m.ResultT{}
+		return m.ResultSetT{}, m.ResultT{}, time.Now()
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -209,7 +255,7 @@ func TestHttpServer_GracefulShutdown(t *testing.T) {
 
 	done := make(chan error, 1)
 	logger := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
-	go func() { done <- serveOnListener(ctx, logger, &config.CfgApp{}, ln) }()
+	go func() { done <- serveOnListener(ctx, logger, config.NewHolder(&config.CfgApp{}), ln) }()
 
 	// Запускаем запрос
 	client := &http.Client{}
@@ -0,0 +1,36 @@
+package httpserver
+
+import (
+	"sync/atomic"
+	"time"
+
+	"main/config"
+	m "main/internal/model"
+)
+
+// snapshot — последний результат фонового сбора (internal/scheduler); nil — ещё ни одного раунда.
+// При включённом collect.interval хендлеры "/" и /api/v1/* читают только его и источники не дёргают.
+var snapshot atomic.Pointer[published]
+
+type published struct {
+	rs  m.ResultSetT
+	r   m.ResultT
+	at  time.Time
+	cfg *config.CfgApp
+}
+
+// Publish — новый снимок от планировщика (см. scheduler.Publisher).
+func Publish(rs m.ResultSetT, r m.ResultT, at time.Time, cfg *config.CfgApp) {
+	snapshot.Store(&published{rs: rs, r: r, at: at, cfg: cfg})
+	stream.publish(newStreamUpdate(rs, r, at))
+}
+
+// latest — снимок, собранный с конфигом cfg. До первого раунда и сразу после reload снимка нет —
+// тогда хендлер собирает данные сам, как без планировщика.
+func latest(cfg *config.CfgApp) (*published, bool) {
+	if !cfg.Scheduled() {
+		return nil, false
+	}
+	p := snapshot.Load()
+	return p, p != nil && p.cfg == cfg
+}
@@ -0,0 +1,81 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"main/config"
+	m "main/internal/model"
+)
+
+// при включённом фоновом сборе хендлеры отдают опубликованный снимок и источники не дёргают
+func TestHandlers_ReadSnapshot(t *testing.T) {
+	t.Cleanup(func() { snapshot.Store(nil) })
+
+	cfg := &config.CfgApp{CollectInterval: time.Minute, DisableBilling: true, PartialResults: true}
+	rs := m.ResultSetT{Support: []int{2, 15}}
+	r := m.ResultT{
+		State:    m.StatusDegraded,
+		Sections: map[string]string{config.SourceSupport: m.SectionStale, config.SourceMMS: m.SectionMissing, config.SourceBilling: m.SectionDisabled},
+		Errors:   []m.SectionError{{Section: config.SourceMMS, Code: m.ErrCodeTimeout, Message: "mms: deadline"}},
+	}
+	Publish(rs, r, time.Now().Add(-3*time.Second), cfg)
+	router := newRouter(nil, config.NewHolder(cfg))
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantErr  string
+	}{
+		{"/api/v1/support", http.StatusOK, ""},
+		{"/api/v1/mms", http.StatusServiceUnavailable, m.ErrCodeTimeout},
+		{"/api/v1/billing", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rr.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d", rr.Code, tt.wantCode)
+			}
+			var resp sectionResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("bad json: %v", err)
+			}
+			if resp.Code != tt.wantErr {
+				t.Fatalf("error code = %q, want %q", resp.Code, tt.wantErr)
+			}
+			if got := rr.Header().Get(headerDataAge); got != "3" {
+				t.Fatalf("%s = %q, want 3", headerDataAge, got)
+			}
+		})
+	}
+
+	gotRS, gotR, at := fetch(context.Background(), nil, cfg)
+	if !reflect.DeepEqual(gotRS, rs) || gotR.State != m.StatusDegraded || at.IsZero() {
+		t.Fatalf("fetch = %+v %+v %s", gotRS, gotR, at)
+	}
+}
+
+// снимок от прошлого конфига (до reload) хендлер не отдаёт
+func TestLatest_ConfigMismatch(t *testing.T) {
+	t.Cleanup(func() { snapshot.Store(nil) })
+
+	old := &config.CfgApp{CollectInterval: time.Minute}
+	Publish(m.ResultSetT{}, m.ResultT{State: m.StatusOK}, time.Now(), old)
+
+	if _, ok := latest(old); !ok {
+		t.Fatalf("snapshot for its own config must be served")
+	}
+	if _, ok := latest(&config.CfgApp{CollectInterval: time.Minute}); ok {
+		t.Fatalf("snapshot from previous config must not be served")
+	}
+	if _, ok := latest(&config.CfgApp{}); ok {
+		t.Fatalf("scheduler disabled — snapshot must be ignored")
+	}
+}
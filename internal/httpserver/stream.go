@@ -0,0 +1,320 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"main/config"
+	res "main/internal/mainfetcher"
+	m "main/internal/model"
+	"main/sl"
+
+	"golang.org/x/net/websocket"
+)
+
+/*
+Поток обновлений для live-страницы статуса, вместо опроса "/":
+
+	GET /api/v1/stream?sections=sms,incidents   — Server-Sent Events
+	GET /api/v1/stream/ws?sections=sms          — то же по WebSocket, JSON-сообщение {"event": ..., "data": ...}
+
+События:
+
+	snapshot — новый результат сбора: {"state": "degraded", "sections": {...}, "at": "..."}
+	section  — секция изменилась (данные или состояние): {"section": "sms", "state": "ok", "data": [...]}
+	           у несобранной — "code" и "error", как в ResultT.Errors
+
+sections — фильтр через запятую (имена как в /api/v1 или в result.sections), без него — все секции.
+Сразу после подключения приходит последний снимок и все секции фильтра. Раз в StreamHeartbeat (15s)
+в SSE пишется комментарий ": ping", в WebSocket — {"event": "ping"}, чтобы прокси не закрывали соединение.
+
+Снимки публикуют фоновый сбор (collect.interval) и сбор для "/", поэтому без планировщика поток
+обновляется только тогда, когда кто-то запрашивает "/".
+*/
+
+// События потока
+const (
+	eventSnapshot = "snapshot"
+	eventSection  = "section"
+	eventPing     = "ping"
+)
+
+// streamUpdate — снимок, подготовленный для потока: данные секций уже в JSON, чтобы каждый подписчик
+// не сериализовал их заново и мог сравнить с тем, что уже отправил.
+type streamUpdate struct {
+	at       time.Time
+	state    string
+	sections map[string]string
+	errors   map[string]m.SectionError
+	data     map[string]json.RawMessage
+}
+
+func newStreamUpdate(rs m.ResultSetT, r m.ResultT, at time.Time) *streamUpdate {
+	u := &streamUpdate{
+		at:       at,
+		state:    r.State,
+		sections: r.Sections,
+		errors:   make(map[string]m.SectionError, len(r.Errors)),
+		data:     make(map[string]json.RawMessage, len(config.Sources)),
+	}
+	for _, e := range r.Errors {
+		u.errors[e.Section] = e
+	}
+	for _, source := range config.Sources {
+		if st := r.Sections[source]; st != m.StatusOK && st != m.SectionStale {
+			continue
+		}
+		if b, err := json.Marshal(res.SectionData(rs, source)); err == nil {
+			u.data[source] = b
+		}
+	}
+	return u
+}
+
+// streamHub раздаёт снимки подписчикам. Канал подписчика на одно значение: медленный клиент получает
+// самый свежий снимок, промежуточные теряются — изменения секций он всё равно увидит (см. streamLoop).
+type streamHub struct {
+	mu   sync.Mutex
+	last *streamUpdate
+	subs map[chan *streamUpdate]struct{}
+}
+
+func newStreamHub() *streamHub {
+	return &streamHub{subs: make(map[chan *streamUpdate]struct{})}
+}
+
+var stream = newStreamHub()
+
+func (h *streamHub) publish(u *streamUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = u
+	for ch := range h.subs {
+		select {
+		case <-ch: // подписчик не забрал прошлый снимок — заменяем свежим
+		default:
+		}
+		ch <- u
+	}
+}
+
+// subscribe — канал снимков (сразу с последним, если он есть) и функция отписки.
+func (h *streamHub) subscribe() (<-chan *streamUpdate, func()) {
+	ch := make(chan *streamUpdate, 1)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.last != nil {
+		ch <- h.last
+	}
+	h.subs[ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs, ch)
+	}
+}
+
+type snapshotEvent struct {
+	State    string            `json:"state"`
+	Sections map[string]string `json:"sections"`
+	At       time.Time         `json:"at"`
+}
+
+type sectionEvent struct {
+	Section string          `json:"section"`
+	State   string          `json:"state"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Code    string          `json:"code,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// sendFn пишет одно событие клиенту (SSE или WebSocket).
+type sendFn func(event string, v any) error
+
+// streamLoop отправляет клиенту снимки из ch и ping раз в heartbeat, пока ctx жив и запись удаётся.
+func streamLoop(ctx context.Context, ch <-chan *streamUpdate, filter []string, heartbeat time.Duration, send sendFn) error {
+	sent := make(map[string]string, len(filter)) // секция → отпечаток последнего отправленного состояния
+	t := time.NewTicker(heartbeat)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			if err := send(eventPing, nil); err != nil {
+				return err
+			}
+		case u := <-ch:
+			if err := sendUpdate(u, filter, sent, send); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sendUpdate — событие snapshot и по событию section на каждую секцию фильтра, которая изменилась с прошлой отправки.
+func sendUpdate(u *streamUpdate, filter []string, sent map[string]string, send sendFn) error {
+	sections := make(map[string]string, len(filter))
+	for _, source := range filter {
+		sections[source] = u.sections[source]
+	}
+	if err := send(eventSnapshot, snapshotEvent{State: u.state, Sections: sections, At: u.at}); err != nil {
+		return err
+	}
+
+	for _, source := range filter {
+		ev := sectionEvent{Section: source, State: u.sections[source], Data: u.data[source]}
+		if e, ok := u.errors[source]; ok {
+			ev.Code, ev.Error = e.Code, e.Message
+		}
+		fp := ev.State + "|" + ev.Code + "|" + string(ev.Data)
+		if prev, ok := sent[source]; ok && prev == fp {
+			continue
+		}
+		if err := send(eventSection, ev); err != nil {
+			return err
+		}
+		sent[source] = fp
+	}
+	return nil
+}
+
+// streamFilter разбирает ?sections=; принимает имена из URL /api/v1 (incidents) и из result.sections (incident).
+func streamFilter(q string) ([]string, error) {
+	if q == "" {
+		return config.Sources, nil
+	}
+	var out []string
+	for _, name := range strings.Split(q, ",") {
+		name = strings.TrimSpace(name)
+		source := ""
+		for _, s := range apiSections {
+			if name == s.path || name == s.source {
+				source = s.source
+			}
+		}
+		if source == "" {
+			return nil, fmt.Errorf("unknown section %q", name)
+		}
+		out = append(out, source)
+	}
+	return out, nil
+}
+
+// makeHandleStream — SSE-поток обновлений.
+func makeHandleStream(logger *slog.Logger, cfg *config.Holder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := sl.FromContext(r.Context(), logger)
+		filter, err := streamFilter(r.URL.Query().Get("sections"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		// поток живёт дольше WriteTimeout сервера — снимаем дедлайн записи для этого соединения
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) && logger != nil {
+			logger.Warn("stream: reset write deadline", slog.Any("err", err))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no") // nginx не должен буферизовать поток
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		ch, unsubscribe := stream.subscribe()
+		defer unsubscribe()
+
+		err = streamLoop(r.Context(), ch, filter, cfg.Load().Heartbeat(), func(event string, v any) error {
+			if event == eventPing {
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return err
+				}
+				return rc.Flush()
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b); err != nil {
+				return err
+			}
+			return rc.Flush()
+		})
+		if err != nil && logger != nil {
+			logger.Debug("stream closed", slog.Any("err", err))
+		}
+	}
+}
+
+// wsMessage — сообщение WebSocket-потока.
+type wsMessage struct {
+	Event string `json:"event"`
+	Data  any    `json:"data,omitempty"`
+}
+
+// makeHandleStreamWS — тот же поток по WebSocket. Доступ закрывается так же, как к остальному API,
+// а Origin проверяет checkOrigin: браузер откроет WebSocket с любой страницы (CORS его не касается).
+func makeHandleStreamWS(logger *slog.Logger, cfg *config.Holder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := sl.FromContext(r.Context(), logger)
+		filter, err := streamFilter(r.URL.Query().Get("sections"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		websocket.Server{Handshake: checkOrigin(cfg.Load().StreamAllowedOrigins()), Handler: func(conn *websocket.Conn) {
+			// сервер выставил дедлайны на соединение до hijack — для долгого потока их снимаем
+			_ = conn.SetDeadline(time.Time{})
+
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			go func() {
+				// входящие сообщения не нужны, читаем только чтобы заметить закрытие соединения клиентом
+				var discard []byte
+				for websocket.Message.Receive(conn, &discard) == nil {
+				}
+				cancel()
+			}()
+
+			ch, unsubscribe := stream.subscribe()
+			defer unsubscribe()
+
+			err := streamLoop(ctx, ch, filter, cfg.Load().Heartbeat(), func(event string, v any) error {
+				return websocket.JSON.Send(conn, wsMessage{Event: event, Data: v})
+			})
+			if err != nil && logger != nil {
+				logger.Debug("ws stream closed", slog.Any("err", err))
+			}
+		}}.ServeHTTP(w, r)
+	}
+}
+
+// checkOrigin — handshake WebSocket: Origin с другим хостом, чем Host запроса, допускается только из allowed
+// (scheme://host[:port] в нижнем регистре, см. CfgApp.StreamAllowedOrigins). Ошибка — websocket.Server ответит 403.
+// Без Origin запрос не из браузера — подделать его чужой странице нельзя, пропускаем.
+func checkOrigin(allowed []string) func(*websocket.Config, *http.Request) error {
+	return func(wc *websocket.Config, r *http.Request) error {
+		origin, err := websocket.Origin(wc, r)
+		if err != nil {
+			return fmt.Errorf("bad origin: %w", err)
+		}
+		if origin == nil || strings.EqualFold(origin.Host, r.Host) {
+			return nil
+		}
+		if slices.Contains(allowed, strings.ToLower(origin.Scheme+"://"+origin.Host)) {
+			return nil
+		}
+		return fmt.Errorf("origin %s not allowed", origin)
+	}
+}
@@ -0,0 +1,242 @@
+package httpserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"main/config"
+	m "main/internal/model"
+
+	"golang.org/x/net/websocket"
+)
+
+// sseEvent — событие, прочитанное из потока; ping — комментарий ": ping".
+type sseEvent struct {
+	name string
+	data string
+}
+
+func readSSE(t *testing.T, r *bufio.Reader) <-chan sseEvent {
+	t.Helper()
+	out := make(chan sseEvent, 16)
+	go func() {
+		defer close(out)
+		var ev sseEvent
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case line == ": ping":
+				ev.name = eventPing
+			case strings.HasPrefix(line, "event: "):
+				ev.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				ev.data = strings.TrimPrefix(line, "data: ")
+			case line == "":
+				out <- ev
+				ev = sseEvent{}
+			}
+		}
+	}()
+	return out
+}
+
+func nextEvent(t *testing.T, events <-chan sseEvent, skipPing bool) sseEvent {
+	t.Helper()
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatalf("stream closed")
+			}
+			if skipPing && ev.name == eventPing {
+				continue
+			}
+			return ev
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no event in 2s")
+		}
+	}
+}
+
+func resetStream(t *testing.T) {
+	t.Helper()
+	stream = newStreamHub()
+	t.Cleanup(func() { stream = newStreamHub(); snapshot.Store(nil) })
+}
+
+func TestStream_SSE(t *testing.T) {
+	resetStream(t)
+	cfg := &config.CfgApp{StreamHeartbeat: 20 * time.Millisecond}
+	srv := httptest.NewServer(newRouter(nil, config.NewHolder(cfg)))
+	defer srv.Close()
+
+	r := m.ResultT{State: m.StatusDegraded,
+		Sections: map[string]string{config.SourceSupport: m.StatusOK, config.SourceMMS: m.SectionMissing},
+		Errors:   []m.SectionError{{Section: config.SourceMMS, Code: m.ErrCodeTimeout, Message: "deadline"}},
+	}
+	Publish(m.ResultSetT{Support: []int{1, 10}}, r, time.Now(), cfg)
+
+	resp, err := http.Get(srv.URL + "/api/v1/stream?sections=support,mms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	events := readSSE(t, bufio.NewReader(resp.Body))
+
+	// сразу — последний снимок и все секции фильтра
+	if ev := nextEvent(t, events, true); ev.name != eventSnapshot || !strings.Contains(ev.data, `"state":"degraded"`) {
+		t.Fatalf("first event = %+v", ev)
+	}
+	// секции — в порядке фильтра
+	var sec sectionEvent
+	ev := nextEvent(t, events, true)
+	if err := json.Unmarshal([]byte(ev.data), &sec); err != nil || sec.Section != config.SourceSupport || string(sec.Data) != "[1,10]" {
+		t.Fatalf("support event = %+v (%v)", ev, err)
+	}
+	ev = nextEvent(t, events, true)
+	if err := json.Unmarshal([]byte(ev.data), &sec); err != nil || sec.Section != config.SourceMMS || sec.Code != m.ErrCodeTimeout {
+		t.Fatalf("mms event = %+v (%v)", ev, err)
+	}
+
+	if ev := nextEvent(t, events, false); ev.name != eventPing {
+		t.Fatalf("expected heartbeat, got %+v", ev)
+	}
+
+	// изменилась только support: snapshot + одна section
+	Publish(m.ResultSetT{Support: []int{3, 20}}, r, time.Now(), cfg)
+	if ev := nextEvent(t, events, true); ev.name != eventSnapshot {
+		t.Fatalf("expected snapshot, got %+v", ev)
+	}
+	ev = nextEvent(t, events, true)
+	if err := json.Unmarshal([]byte(ev.data), &sec); err != nil || sec.Section != config.SourceSupport || string(sec.Data) != "[3,20]" {
+		t.Fatalf("changed support event = %+v (%v)", ev, err)
+	}
+
+	// ничего не изменилось — только snapshot
+	Publish(m.ResultSetT{Support: []int{3, 20}}, r, time.Now(), cfg)
+	if ev := nextEvent(t, events, true); ev.name != eventSnapshot {
+		t.Fatalf("expected snapshot, got %+v", ev)
+	}
+	select {
+	case ev := <-events:
+		if ev.name != eventPing {
+			t.Fatalf("unexpected event for unchanged sections: %+v", ev)
+		}
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestStream_BadFilter(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newRouter(nil, config.NewHolder(&config.CfgApp{})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/stream?sections=sms,fax", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("code = %d, want 400", rr.Code)
+	}
+}
+
+func TestStreamFilter(t *testing.T) {
+	got, err := streamFilter("incidents, sms,incident")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{config.SourceIncident, config.SourceSMS, config.SourceIncident}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("filter = %v, want %v", got, want)
+	}
+	if all, _ := streamFilter(""); !reflect.DeepEqual(all, config.Sources) {
+		t.Fatalf("empty filter = %v", all)
+	}
+}
+
+func TestStream_WebSocket(t *testing.T) {
+	resetStream(t)
+	cfg := &config.CfgApp{StreamHeartbeat: time.Hour}
+	srv := httptest.NewServer(newRouter(nil, config.NewHolder(cfg)))
+	defer srv.Close()
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/v1/stream/ws?sections=billing", "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	Publish(m.ResultSetT{}, m.ResultT{State: m.StatusDegraded, Sections: map[string]string{config.SourceBilling: m.SectionDisabled}}, time.Now(), cfg)
+
+	var msg struct {
+		Event string          `json:"event"`
+		Data  json.RawMessage `json:"data"`
+	}
+	for _, want := range []string{eventSnapshot, eventSection} {
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Event != want {
+			t.Fatalf("event = %q, want %q", msg.Event, want)
+		}
+	}
+	if !strings.Contains(string(msg.Data), `"state":"disabled"`) {
+		t.Fatalf("section data = %s", msg.Data)
+	}
+}
+
+// WebSocket с чужой страницы: Origin должен совпадать с хостом сервиса или быть в StreamOrigins
+func TestStream_WebSocketOrigin(t *testing.T) {
+	resetStream(t)
+	cfg := &config.CfgApp{StreamHeartbeat: 20 * time.Millisecond, StreamOrigins: "https://status.example.com"}
+	srv := httptest.NewServer(newRouter(nil, config.NewHolder(cfg)))
+	defer srv.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/stream/ws"
+
+	tests := []struct {
+		name   string
+		origin string
+		wantOK bool
+	}{
+		{"same host", srv.URL, true},
+		{"allowed origin", "https://STATUS.example.com", true},
+		{"foreign origin", "https://evil.example.com", false},
+		{"allowed host, other scheme", "http://status.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wc, err := websocket.NewConfig(wsURL, tt.origin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn, err := websocket.DialConfig(wc)
+			if (err == nil) != tt.wantOK {
+				t.Fatalf("dial err = %v, want ok = %v", err, tt.wantOK)
+			}
+			if err != nil {
+				return
+			}
+			// ждём первый ping: обработчик уже подписался на stream, и Cleanup не гоняется с ним за переменную
+			var msg wsMessage
+			if err := websocket.JSON.Receive(conn, &msg); err != nil {
+				t.Fatalf("receive: %v", err)
+			}
+			conn.Close()
+		})
+	}
+
+	// без Origin (не браузер) — пропускаем
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/stream/ws", nil)
+	req.Header.Set("Origin", "")
+	wc := &websocket.Config{Version: websocket.ProtocolVersionHybi13}
+	if err := checkOrigin(nil)(wc, req); err != nil {
+		t.Fatalf("request without Origin: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"main/config"
+)
+
+/*
+HTTPS (config/tls.go): включается http.tls.cert/key, вместе с ним — HTTP/2 (ALPN h2, net/http умеет сам).
+Настройки TLS, как и адрес, фиксируются при старте; сертификат и ключ перечитываются при ротации файлов
+(cert-manager, certbot) без перезапуска: раз в config.poll_interval при очередном рукопожатии сверяем
+размер и mtime файлов. Не загрузившаяся пара (файлы записаны наполовину) — оставляем прежний сертификат.
+*/
+
+// newTLSConfig — tls.Config сервера по конфигу старта.
+func newTLSConfig(logger *slog.Logger, cfg *config.CfgApp) (*tls.Config, error) {
+	certs, err := newCertReloader(logger, cfg.TLSCertFile, cfg.TLSKeyFile, cfg.PollInterval())
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg := &tls.Config{
+		MinVersion:     cfg.TLSVersion(),
+		CipherSuites:   cfg.TLSCipherSuites(),
+		GetCertificate: certs.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	if cfg.TLSClientCA != "" {
+		pem, err := os.ReadFile(cfg.TLSClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("client CA %s: no PEM certificates", cfg.TLSClientCA)
+		}
+		// сертификат необязателен: клиенты с ключами key/hmac ходят без него (auth.go)
+		tlsCfg.ClientCAs, tlsCfg.ClientAuth = pool, tls.VerifyClientCertIfGiven
+	}
+	return tlsCfg, nil
+}
+
+// certReloader отдаёт текущую пару сертификат/ключ и перечитывает её, когда файлы на диске изменились.
+type certReloader struct {
+	logger            *slog.Logger
+	certFile, keyFile string
+	every             time.Duration // как часто сверять файлы
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	stamp   string // размер и mtime обоих файлов при последней загрузке
+	checked time.Time
+}
+
+func newCertReloader(logger *slog.Logger, certFile, keyFile string, every time.Duration) (*certReloader, error) {
+	cr := &certReloader{logger: logger, certFile: certFile, keyFile: keyFile, every: every}
+	stamp, err := cr.fileStamp()
+	if err != nil {
+		return nil, err
+	}
+	if err := cr.load(stamp); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// GetCertificate — для tls.Config: вызывается на каждое рукопожатие.
+func (cr *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	if time.Since(cr.checked) >= cr.every {
+		cr.checked = time.Now()
+		stamp, err := cr.fileStamp()
+		if err == nil && stamp != cr.stamp {
+			err = cr.load(stamp)
+			if err == nil && cr.logger != nil {
+				cr.logger.Info("TLS certificate reloaded", slog.String("cert", cr.certFile))
+			}
+		}
+		if err != nil && cr.logger != nil {
+			cr.logger.Error("TLS certificate reload failed, keeping previous", slog.Any("err", err))
+		}
+	}
+	return cr.cert, nil
+}
+
+func (cr *certReloader) load(stamp string) error {
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS key pair: %w", err)
+	}
+	cr.cert, cr.stamp = &cert, stamp
+	return nil
+}
+
+func (cr *certReloader) fileStamp() (string, error) {
+	var stamp string
+	for _, path := range []string{cr.certFile, cr.keyFile} {
+		fi, err := os.Stat(path) // Stat идёт по симлинкам — ротация смонтированного Kubernetes-секрета тоже видна
+		if err != nil {
+			return "", err
+		}
+		stamp += fmt.Sprintf("%d/%d;", fi.Size(), fi.ModTime().UnixNano())
+	}
+	return stamp, nil
+}
@@ -0,0 +1,193 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"main/config"
+)
+
+// writeCert пишет самоподписанный сертификат (годится и как CA) и ключ в dir, возвращает пути.
+func writeCert(t *testing.T, dir, cn string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn},
+		DNSNames:              []string{cn},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, cn+".crt"), filepath.Join(dir, cn+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func certCN(t *testing.T, c *tls.Certificate) string {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(c.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+// serveTLS запускает serveOnListener с конфигом cfg и возвращает адрес; сервер останавливается в Cleanup.
+func serveTLS(t *testing.T, cfg *config.CfgApp) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	go func() { done <- serveOnListener(ctx, logger, config.NewHolder(cfg), ln) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("server error: %v", err)
+		}
+	})
+	return "https://" + ln.Addr().String()
+}
+
+func TestServe_TLS_HTTP2(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "server")
+	clientCert, clientKey := writeCert(t, dir, "reports.internal")
+	t.Setenv("DASH_KEY", "dash-secret")
+	base := serveTLS(t, &config.CfgApp{
+		TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCA: clientCert,
+		APIKeys: "dashboard:sms:env:DASH_KEY, reports:*:cn:reports.internal",
+	})
+
+	roots := x509.NewCertPool()
+	pemBytes, _ := os.ReadFile(certFile)
+	roots.AppendCertsFromPEM(pemBytes)
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certs},
+			ForceAttemptHTTP2: true,
+		}}
+	}
+
+	resp, err := client().Get(base + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("proto = %s, want HTTP/2", resp.Proto)
+	}
+
+	// без сертификата ключа нет — 401; с клиентским сертификатом из client_ca — ключ reports
+	resp, err = client().Get(base + "/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("no cert: code = %d, want 401", resp.StatusCode)
+	}
+	pair, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = client(pair).Get(base + "/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("client cert: code = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestServe_TLS_BadKeyPair(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	cfg := &config.CfgApp{TLSCertFile: filepath.Join(t.TempDir(), "missing.crt"), TLSKeyFile: "missing.key"}
+	if err := serveOnListener(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), config.NewHolder(cfg), ln); err == nil {
+		t.Fatalf("expected error for missing certificate")
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "old")
+	cr, err := newCertReloader(nil, certFile, keyFile, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func() string {
+		c, err := cr.GetCertificate(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return certCN(t, c)
+	}
+	if cn := get(); cn != "old" {
+		t.Fatalf("CN = %q", cn)
+	}
+
+	// наполовину записанная ротация — остаётся прежний сертификат
+	if err := os.WriteFile(certFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if cn := get(); cn != "old" {
+		t.Fatalf("broken files must keep previous certificate, got %q", cn)
+	}
+
+	// ротация: новые файлы на том же месте
+	newCert, newKey := writeCert(t, t.TempDir(), "new")
+	for src, dst := range map[string]string{newCert: certFile, newKey: keyFile} {
+		b, _ := os.ReadFile(src)
+		if err := os.WriteFile(dst, b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cn := get(); cn != "new" {
+		t.Fatalf("CN after rotation = %q, want new", cn)
+	}
+}
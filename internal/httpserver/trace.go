@@ -0,0 +1,36 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+
+	"main/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceRequest — самый внешний middleware роутера: server span на запрос (дочерний к входящему traceparent,
+// который разбирает глобальный пропагатор — W3C Trace Context, см. tracing.Setup).
+// Внутри — span-ы кэша, опроса источников и запросов к апстримам (traceparent уходит к ним из httpx).
+func traceRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeOf(r)
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.StartKind(ctx, trace.SpanKindServer, r.Method+" "+route,
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", r.URL.Path),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.code))
+		if rec.code >= http.StatusInternalServerError {
+			tracing.RecordError(span, errors.New(http.StatusText(rec.code)))
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package httpserver
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"main/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordSpans ставит на время теста SDK-провайдер с записью span-ов в память и W3C-пропагатор.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	return rec
+}
+
+// трасса запроса /api/v1/support: входящий traceparent → server span → кэш; сбор → запрос к апстриму — своя трасса со ссылкой на кэш
+func TestTraceRequest(t *testing.T) {
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		callerID = "00f067aa0ba902b7"
+	)
+	rec := recordSpans(t)
+
+	var upstreamParent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamParent = r.Header.Get("traceparent")
+		_, _ = w.Write([]byte(`[{"topic":"SMS delivery in EU","active_tickets":3}]`))
+	}))
+	defer upstream.Close()
+
+	var logs bytes.Buffer
+	cfg := &config.CfgApp{PathSupportData: upstream.URL}
+	router := newRouter(slog.New(slog.NewJSONHandler(&logs, nil)), config.NewHolder(cfg))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/support", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+callerID+"-01")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rr.Code, rr.Body.String())
+	}
+
+	spans := rec.Ended()
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range spans {
+		byName[s.Name()] = s
+	}
+	refresh, ok := byName["cache refresh section"]
+	if !ok {
+		t.Fatalf("no refresh span among %d spans", len(spans))
+	}
+	refreshTrace := refresh.SpanContext().TraceID().String()
+	if refreshTrace == traceID {
+		t.Fatalf("cache refresh must start its own trace")
+	}
+	// цепочка родителей: запрос — в трассе вызывающего, сбор — в своей трассе от "cache refresh section"
+	const root = "" // корень: родитель — span вызывающего (запрос) или нет родителя (refresh)
+	chain := []struct{ name, parent, trace string }{
+		{"GET /api/v1/support", root, traceID},
+		{"cache section", "GET /api/v1/support", traceID},
+		{"cache refresh section", root, refreshTrace},
+		{"GetSectionData", "cache refresh section", refreshTrace},
+		{"fetch support", "GetSectionData", refreshTrace},
+		{"GET supportdata.Fetch", "fetch support", refreshTrace},
+		{"jsonx decode", "GET supportdata.Fetch", refreshTrace},
+		{"BuildSortedSupport", "fetch support", refreshTrace},
+	}
+	for _, c := range chain {
+		s, ok := byName[c.name]
+		if !ok {
+			t.Fatalf("no span %q among %d spans", c.name, len(spans))
+		}
+		if got := s.SpanContext().TraceID().String(); got != c.trace {
+			t.Fatalf("span %q trace = %s, want %s", c.name, got, c.trace)
+		}
+		want := callerID
+		switch {
+		case c.parent != root:
+			want = byName[c.parent].SpanContext().SpanID().String()
+		case c.trace != traceID:
+			want = "0000000000000000"
+		}
+		if got := s.Parent().SpanID().String(); got != want {
+			t.Fatalf("span %q parent = %s, want %s (%s)", c.name, got, want, c.parent)
+		}
+	}
+	if server := byName["GET /api/v1/support"]; server.SpanKind() != trace.SpanKindServer {
+		t.Fatalf("server span kind = %s", server.SpanKind())
+	}
+	// refresh связан с обращением к кэшу, которое его запустило
+	cacheSpan := byName["cache section"].SpanContext()
+	if links := refresh.Links(); len(links) != 1 || !links[0].SpanContext.Equal(cacheSpan) {
+		t.Fatalf("refresh links = %+v, want the cache span %s", links, cacheSpan.SpanID())
+	}
+
+	client := byName["GET supportdata.Fetch"]
+	if want := "00-" + refreshTrace + "-" + client.SpanContext().SpanID().String() + "-01"; upstreamParent != want {
+		t.Fatalf("upstream traceparent = %q, want %q", upstreamParent, want)
+	}
+	lines := logLines(t, &logs)
+	if access := lines[len(lines)-1]; access["msg"] != "http request" || access["trace_id"] != traceID {
+		t.Fatalf("access log must carry trace_id: %v", access)
+	}
+}
@@ -3,20 +3,50 @@ package httpx
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"log/slog"
+
+	"main/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrStatus — источник ответил статусом не 2xx.
+var ErrStatus = errors.New("unexpected HTTP status")
+
 type Doer interface {
 	Do(*http.Request) (*http.Response, error)
 }
 
 type DecoderFunc[T any] func(r io.Reader) ([]T, error)
 
+// RequestOption дополняет запрос перед отправкой (например, заголовок Authorization).
+type RequestOption func(*http.Request) error
+
+// WithHeader выставляет заголовок name. Значение вычисляется на каждый запрос — секрет из файла
+// может ротироваться; пустое значение заголовок не ставит. Значение заголовка в лог не пишется.
+func WithHeader(name string, value func() (string, error)) RequestOption {
+	return func(req *http.Request) error {
+		v, err := value()
+		if err != nil {
+			return fmt.Errorf("header %s: %w", name, err)
+		}
+		if v != "" {
+			req.Header.Set(name, v)
+		}
+		return nil
+	}
+}
+
 // Общая функция: делает GET, проверяет статус, декодирует массив элементов.
 func FetchArray[T any](
 	ctx context.Context,
@@ -25,20 +55,97 @@ func FetchArray[T any](
 	url string,
 	decode DecoderFunc[T],
 	op string,
+	opts ...RequestOption,
 ) ([]T, error) {
-	l := log.With(slog.String("op", op), slog.String("url", url))
+	var items []T
+	err := get(ctx, log, client, url, op, opts, func(ctx context.Context, body io.Reader) (err error) {
+		// тело читается по мере разбора, поэтому span decode включает и чтение из сети
+		_, span := tracing.Start(ctx, "jsonx decode", attribute.String("op", op))
+		items, err = decode(body)
+		span.SetAttributes(attribute.Int("items", len(items)))
+		tracing.RecordError(span, err)
+		span.End()
+		if err != nil {
+			return fmt.Errorf("decode body: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// FetchBody делает GET и возвращает тело целиком — для источников с построчным/битовым форматом,
+// которые раньше читались только из файла. Тело больше maxBytes — ошибка (как и слишком большой файл).
+func FetchBody(
+	ctx context.Context,
+	log *slog.Logger,
+	client Doer,
+	url string,
+	maxBytes int64,
+	op string,
+	opts ...RequestOption,
+) ([]byte, error) {
+	var body []byte
+	err := get(ctx, log, client, url, op, opts, func(_ context.Context, r io.Reader) (err error) {
+		body, err = io.ReadAll(io.LimitReader(r, maxBytes+1))
+		if err != nil {
+			return fmt.Errorf("read body: %w", err)
+		}
+		if int64(len(body)) > maxBytes {
+			return fmt.Errorf("body too large: more than %d bytes", maxBytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// get — общий GET: строит запрос, применяет opts, проверяет статус и отдаёт тело в read
+// (вместе с контекстом span-а запроса — span-ы чтения будут его дочерними).
+func get(
+	ctx context.Context,
+	log *slog.Logger,
+	client Doer,
+	url string,
+	op string,
+	opts []RequestOption,
+	read func(ctx context.Context, body io.Reader) error,
+) (err error) {
+	l := log.With(slog.String("op", op), slog.String("url", redactURL(url)))
 	start := time.Now()
 
+	// client span запроса: его id уходит апстриму в traceparent
+	ctx, span := tracing.StartKind(ctx, trace.SpanKindClient, "GET "+op,
+		attribute.String("http.request.method", http.MethodGet), attribute.String("url.full", redactURL(url)))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil) //Если ctx будет отменён (graceful shutdown наверху), транспорт net/http прервёт операцию: Do или последующее чтение тела вернёт ошибку (типично context canceled).
 	if err != nil {
 		l.Error("build request", slog.Any("err", err))
-		return nil, fmt.Errorf("%s: build request: %w", op, err)
+		return fmt.Errorf("%s: build request: %w", op, err)
+	}
+	if id := RequestID(ctx); id != "" {
+		req.Header.Set(HeaderRequestID, id)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	for _, opt := range opts {
+		if err := opt(req); err != nil {
+			l.Error("prepare request", slog.Any("err", err))
+			return fmt.Errorf("%s: prepare request: %w", op, err)
+		}
 	}
 
 	res, err := client.Do(req)
 	if err != nil {
 		l.Error("do http-request", slog.Any("err", err))
-		return nil, fmt.Errorf("%s: do request: %w", op, err)
+		return fmt.Errorf("%s: do request: %w", op, err)
 	}
 	defer func() {
 		// гарантируем дренирование, чтобы не терять keep-alive
@@ -47,22 +154,30 @@ func FetchArray[T any](
 		_ = res.Body.Close()
 	}()
 
+	span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
 	if res.StatusCode < 200 || res.StatusCode > 299 {
-		err = fmt.Errorf("%s: unexpected HTTP status: %s (%d)", op, res.Status, res.StatusCode)
+		err = fmt.Errorf("%s: %w: %s (%d)", op, ErrStatus, res.Status, res.StatusCode)
 		l.Error("bad status", slog.Any("err", err), slog.Int("status_code", res.StatusCode))
-		return nil, err
+		return err
 	}
 
-	items, err := decode(res.Body)
-	if err != nil {
-		l.Error("decode body", slog.Any("err", err))
-		return nil, fmt.Errorf("%s: decode body: %w", op, err)
+	if err := read(ctx, res.Body); err != nil {
+		l.Error("read body", slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	l.Info("fetched",
 		slog.Duration("dur", time.Since(start)),
 		slog.Int("status_code", res.StatusCode),
 	)
+	return nil
+}
 
-	return items, nil
+// redactURL прячет пароль из userinfo, чтобы он не попал в лог.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Redacted()
 }
@@ -0,0 +1,20 @@
+package httpx
+
+import "context"
+
+// HeaderRequestID — заголовок с ID входящего запроса; тот же ID уходит во все запросы к источникам,
+// сделанные ради него, чтобы логи апстримов связывались с нашими.
+const HeaderRequestID = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID кладёт ID запроса в контекст: get выставит его заголовком HeaderRequestID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID — ID запроса из контекста ("" — нет).
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
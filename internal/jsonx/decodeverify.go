@@ -8,7 +8,10 @@ import (
 	"io"
 )
 
-var ErrTopLevelNotArray = errors.New("jsonx: expected top-level JSON array")
+var (
+	ErrTopLevelNotArray  = errors.New("jsonx: expected top-level JSON array")
+	ErrUnterminatedArray = errors.New("jsonx: unterminated array")
+)
 
 // Если тип умеет сам себя валидировать – пусть реализует Validate()
 type Validatable interface {
@@ -22,6 +25,15 @@ type Options[T any] struct {
 	// Если true — при ошибке элемента сразу возвращаем ошибку;
 	// по умолчанию false: пропускаем плохие элементы.
 	FailFast bool
+	// Необязательный колбэк на каждый пропущенный элемент (битый JSON, лишние поля, не прошёл валидацию) —
+	// для статистики; при FailFast не вызывается.
+	OnSkip func(err error)
+}
+
+func (o *Options[T]) skip(err error) {
+	if o != nil && o.OnSkip != nil {
+		o.OnSkip(err)
+	}
 }
 
 // DecodeArray: из []byte в []T, строгий разбор каждого элемента с DisallowUnknownFields.
@@ -63,6 +75,7 @@ func decodeArrayWithDecoder[T any](dec *json.Decoder, opt *Options[T]) ([]T, err
 			if opt != nil && opt.FailFast {
 				return nil, err
 			}
+			opt.skip(err)
 			continue
 		}
 
@@ -73,6 +86,7 @@ func decodeArrayWithDecoder[T any](dec *json.Decoder, opt *Options[T]) ([]T, err
 			if opt != nil && opt.FailFast {
 				return nil, err
 			}
+			opt.skip(err)
 			continue
 		}
 
@@ -81,6 +95,7 @@ func decodeArrayWithDecoder[T any](dec *json.Decoder, opt *Options[T]) ([]T, err
 				if opt != nil && opt.FailFast {
 					return nil, err
 				}
+				opt.skip(err)
 				continue
 			}
 		} else if opt != nil && opt.ValidateFunc != nil {
@@ -88,6 +103,7 @@ func decodeArrayWithDecoder[T any](dec *json.Decoder, opt *Options[T]) ([]T, err
 				if opt.FailFast {
 					return nil, err
 				}
+				opt.skip(err)
 				continue
 			}
 		}
@@ -101,7 +117,7 @@ func decodeArrayWithDecoder[T any](dec *json.Decoder, opt *Options[T]) ([]T, err
 		return nil, err
 	}
 	if d, ok := tok.(json.Delim); !ok || d != ']' {
-		return nil, fmt.Errorf("jsonx: unterminated array (offset %d)", dec.InputOffset())
+		return nil, fmt.Errorf("%w (offset %d)", ErrUnterminatedArray, dec.InputOffset())
 	}
 
 	return out, nil
@@ -94,6 +94,23 @@ func TestDecodeArray_MixedValidInvalid_KeepOnlyValid(t *testing.T) {
 	}
 }
 
+// OnSkip вызывается на каждый отброшенный элемент: невалидный и с лишним полем
+func TestDecodeArray_OnSkip_CountsRejected(t *testing.T) {
+	in := []byte(`[
+		{"topic":"SMS","active_tickets":-1},
+		{"topic":"MMS","active_tickets":9},
+		{"topic":"Billing","active_tickets":0,"extra":1}
+	]`)
+	skipped := 0
+	got, err := DecodeArray[SupportData](in, &Options[SupportData]{OnSkip: func(error) { skipped++ }})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || skipped != 2 {
+		t.Fatalf("got %d items, %d skipped; want 1 and 2", len(got), skipped)
+	}
+}
+
 func TestDecodeArray_EmptyArray(t *testing.T) {
 	in := []byte(`[]`)
 	got, err := DecodeArray[SupportData](in, nil)
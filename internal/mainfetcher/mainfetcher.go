@@ -3,12 +3,24 @@ package mainfetcher
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"log/slog"
 	"main/config"
+	"main/internal/health"
+	"main/internal/httpx"
+	"main/internal/jsonx"
+	"main/internal/tracing"
+	"net"
 	"net/http"
+	"os/exec"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,6 +33,7 @@ import (
 	"main/support"
 	voice "main/voicedata"
 
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -181,25 +194,109 @@ func goFetchSlice[T any](g *errgroup.Group, parentCtx context.Context, logger *s
 // 	return svcSupp.Fetch(ctx)
 // })
 
-type fetcher func(g *errgroup.Group, ctx context.Context) //обёртка-замыкание -- т.к. часть функций требует *http.Client, часть — нет, плюс передаются другие параметры
+type fetcher func(g *errgroup.Group, ctx context.Context) //обёртка-замыкание: GoFetch-и принимают разные параметры
 
-// «Связываем» конкретные GoFetch в единый тип, замыкая внешние зависимости
+// «Связываем» конкретные GoFetch в единый тип, замыкая внешние зависимости;
+// client — общий на весь сбор (пул соединений), в т.ч. для файловых секций, заданных http(s)://
 func makeFetcher(
-	fn func(*errgroup.Group, context.Context, *slog.Logger, time.Duration, *config.CfgApp, *m.ResultSetT, *sync.Mutex),
-	logger *slog.Logger, perReq time.Duration, cfg *config.CfgApp, rs *m.ResultSetT, mu *sync.Mutex,
+	fn func(*errgroup.Group, context.Context, *slog.Logger, time.Duration, *http.Client, *config.CfgApp, *m.ResultSetT, *sync.Mutex),
+	logger *slog.Logger, perReq time.Duration, client *http.Client, cfg *config.CfgApp, rs *m.ResultSetT, mu *sync.Mutex,
 ) fetcher {
 	return func(g *errgroup.Group, ctx context.Context) {
-		fn(g, ctx, logger, perReq, cfg, rs, mu)
+		fn(g, ctx, logger, perReq, client, cfg, rs, mu)
 	}
 }
 
-func makeFetcherWithClient(
-	fn func(*errgroup.Group, context.Context, *slog.Logger, time.Duration, *http.Client, *config.CfgApp, *m.ResultSetT, *sync.Mutex),
-	logger *slog.Logger, perReq time.Duration, client *http.Client, cfg *config.CfgApp, rs *m.ResultSetT, mu *sync.Mutex,
-) fetcher {
+// traceFetch оборачивает фетчер источника в span "fetch <source>" на всё время опроса: внутри — чтение файла/запрос
+// к апстриму, разбор и Build*, итог (кол-во записей или ошибку) отметит health.Record по span-у из ctx.
+// Задачи фетчера идут во вложенную группу, которую ждёт одна задача g, — лимит параллелизма g считает источники, как и раньше.
+func traceFetch(source string, f fetcher) fetcher {
 	return func(g *errgroup.Group, ctx context.Context) {
-		fn(g, ctx, logger, perReq, client, cfg, rs, mu)
+		g.Go(func() error {
+			ctx, span := tracing.Start(ctx, "fetch "+source, attribute.String("source", source))
+			defer span.End()
+			var sub errgroup.Group
+			f(&sub, ctx)
+			return sub.Wait()
+		})
+	}
+}
+
+// sourceFetcher — фетчер конкретного источника; имя нужно, чтобы пропускать выключенные в конфиге.
+type sourceFetcher struct {
+	source string
+	fetch  fetcher
+}
+
+// enabledFetchers оставляет только включённые в конфиге источники.
+func enabledFetchers(logger *slog.Logger, cfg *config.CfgApp, all []sourceFetcher) []fetcher {
+	fs := make([]fetcher, 0, len(all))
+	for _, sf := range all {
+		if !cfg.Enabled(sf.source) {
+			logger.Debug("source disabled, skip fetch", slog.String("source", sf.source))
+			continue
+		}
+		fs = append(fs, sf.fetch)
 	}
+	return fs
+}
+
+// sectionFetchers — фетчеры для GetSectionData; переменная, чтобы подменять в тестах.
+var sectionFetchers = defaultFetchers
+
+// defaultFetchers — фетчеры всех источников, пишущие в rs под mu.
+func defaultFetchers(logger *slog.Logger, cfg *config.CfgApp, client *http.Client, rs *m.ResultSetT, mu *sync.Mutex) []sourceFetcher {
+	// таймаут на каждый источник: свой из конфига, иначе общий FetchTimeout
+	timeout := cfg.SourceTimeout
+	fs := []sourceFetcher{
+		{config.SourceSMS, makeFetcher(sms.GoFetch, logger, timeout(config.SourceSMS), client, cfg, rs, mu)}, //тут параметры - «чем и куда писать»
+		{config.SourceVoice, makeFetcher(voice.GoFetch, logger, timeout(config.SourceVoice), client, cfg, rs, mu)},
+		{config.SourceEmail, makeFetcher(email.GoFetch, logger, timeout(config.SourceEmail), client, cfg, rs, mu)},
+		{config.SourceMMS, makeFetcher(mms.GoFetch, logger, timeout(config.SourceMMS), client, cfg, rs, mu)},
+		{config.SourceBilling, makeFetcher(bill.GoFetch, logger, timeout(config.SourceBilling), client, cfg, rs, mu)},
+		{config.SourceSupport, makeFetcher(support.GoFetch, logger, timeout(config.SourceSupport), client, cfg, rs, mu)},
+		{config.SourceIncident, makeFetcher(incident.GoFetch, logger, timeout(config.SourceIncident), client, cfg, rs, mu)},
+	}
+	for i := range fs {
+		fs[i].fetch = traceFetch(fs[i].source, fs[i].fetch)
+	}
+	return fs
+}
+
+// ErrSourceDisabled — запрошен источник, выключенный в конфиге.
+var ErrSourceDisabled = errors.New("source disabled")
+
+// GetSectionData собирает только один источник (для /api/v1/<секция>): запускается один фетчер,
+// в rs заполнена только его секция. Ошибка — m.SectionError, если секция пустая (источник не ответил/данные невалидны).
+func GetSectionData(parentCtx context.Context, logger *slog.Logger, cfg *config.CfgApp, source string) (rs m.ResultSetT, err error) {
+	if !cfg.Enabled(source) {
+		return rs, ErrSourceDisabled
+	}
+	parentCtx, span := tracing.Start(parentCtx, "GetSectionData", attribute.String("source", source))
+	defer span.End()
+
+	var mu sync.Mutex
+	client := &http.Client{Timeout: cfg.ClientTimeout()}
+
+	var report health.Report
+	found := false
+	g, groupCtx := errgroup.WithContext(health.WithReport(parentCtx, &report))
+	for _, sf := range sectionFetchers(logger, cfg, client, &rs, &mu) {
+		if sf.source == source {
+			sf.fetch(g, groupCtx)
+			found = true
+		}
+	}
+	if !found {
+		return rs, fmt.Errorf("unknown source %q", source)
+	}
+	_ = g.Wait()
+
+	if se, ok := sectionError(rs, source, &report); !ok {
+		tracing.RecordError(span, se)
+		return rs, se
+	}
+	return rs, nil
 }
 
 func GetResultData(parentCtx context.Context, logger *slog.Logger, cfg *config.CfgApp, custom ...fetcher) (rs m.ResultSetT, r m.ResultT) {
@@ -210,32 +307,27 @@ func GetResultData(parentCtx context.Context, logger *slog.Logger, cfg *config.C
 	                              любая Go() вернула ошибку ──┘
 
 	*/
+	// span всего сбора: span-ы источников (fetch <источник>) — его дочерние, параллельные
+	parentCtx, span := tracing.Start(parentCtx, "GetResultData")
+	defer span.End()
+
 	var mu sync.Mutex
 	// 1) один http.Client на весь процесс (reuse пула соединений)
-	client := &http.Client{Timeout: 5 * time.Second}
+	client := &http.Client{Timeout: cfg.ClientTimeout()}
 
 	// 2) конструируем сервисы с контекстным Fetch
 	//svcMms := mms.NewService(logger, cfg, client)
 
-	// 3) errgroup с лимитом параллелизма
-	g, groupCtx := errgroup.WithContext(parentCtx)
-	g.SetLimit(7) // лимит активных горутин -- TODO: или использовать pool - Для простого кейса лимита параллелизма SetLimit — идеально. Пул нужен, когда хочешь долгоживущих воркеров, очереди задач, приоритизацию и т.п.
-
-	perReqTimeout := 3 * time.Second
+	// 3) errgroup с лимитом параллелизма; report собирает ошибки фетчеров этого сбора (для ResultT.Errors)
+	var report health.Report
+	g, groupCtx := errgroup.WithContext(health.WithReport(parentCtx, &report))
+	g.SetLimit(cfg.Concurrency()) // лимит активных горутин -- TODO: или использовать pool - Для простого кейса лимита параллелизма SetLimit — идеально. Пул нужен, когда хочешь долгоживущих воркеров, очереди задач, приоритизацию и т.п.
 
 	var fs []fetcher
 	if len(custom) > 0 {
 		fs = custom
 	} else { //запуск Fetcher-ов по умолчанию корректный; custom для тестов
-		fs = []fetcher{
-			makeFetcher(sms.GoFetch, logger, perReqTimeout, cfg, &rs, &mu), //тут параметры - «чем и куда писать»
-			makeFetcher(voice.GoFetch, logger, perReqTimeout, cfg, &rs, &mu),
-			makeFetcher(email.GoFetch, logger, perReqTimeout, cfg, &rs, &mu),
-			makeFetcherWithClient(mms.GoFetch, logger, perReqTimeout, client, cfg, &rs, &mu),
-			makeFetcher(bill.GoFetch, logger, perReqTimeout, cfg, &rs, &mu),
-			makeFetcherWithClient(support.GoFetch, logger, perReqTimeout, client, cfg, &rs, &mu),
-			makeFetcherWithClient(incident.GoFetch, logger, perReqTimeout, client, cfg, &rs, &mu),
-		}
+		fs = enabledFetchers(logger, cfg, defaultFetchers(logger, cfg, client, &rs, &mu))
 	}
 
 	// 4) параллельные задачи
@@ -260,86 +352,323 @@ func GetResultData(parentCtx context.Context, logger *slog.Logger, cfg *config.C
 	// 5) ждём завершения всех фетчей
 	_ = g.Wait()
 
-	r = BuildResultT(rs)
+	_, buildSpan := tracing.Start(parentCtx, "BuildResultT")
+	r = BuildResultT(rs, cfg, &report)
+	buildSpan.SetAttributes(attribute.String("state", r.State))
+	buildSpan.End()
 
 	return rs, r
 
 }
 
-func validateResultSet(rs m.ResultSetT) error {
-	// SMS
-	if len(rs.SMS) == 0 {
-		return fmt.Errorf("sms empty")
-	}
-	for _, batch := range rs.SMS {
-		if len(batch) == 0 {
-			return fmt.Errorf("sms has empty batch")
-		}
+// validateResultSet проверяет, что все включённые источники дали данные; выключенные в cfg пропускаются
+// (cfg == nil — включены все). Порядок проверок — config.Sources, возвращается первая проблема.
+func validateResultSet(rs m.ResultSetT, cfg *config.CfgApp) error {
+	if errs := sectionErrors(rs, cfg, nil); len(errs) > 0 {
+		return errs[0]
 	}
+	return nil
+}
+
+// fetchErrors — откуда брать причину несобранной секции: *health.Report текущего сбора
+// или sectionErrs — ошибки, перенесённые из прошлых сборов (см. Merge).
+type fetchErrors interface {
+	Err(source string) error
+}
+
+// sectionErrs — причины по секциям из ResultT.Errors.
+type sectionErrs map[string]m.SectionError
 
-	// MMS
-	if len(rs.MMS) == 0 {
-		return fmt.Errorf("mms empty")
+func (e sectionErrs) Err(source string) error {
+	if se, ok := e[source]; ok {
+		return se
 	}
-	for _, batch := range rs.MMS {
-		if len(batch) == 0 {
-			return fmt.Errorf("mms has empty batch")
+	return nil
+}
+
+// sectionErrors — по ошибке на каждую включённую, но несобранную секцию, в порядке config.Sources.
+// Причина берётся из report (ошибка фетчера в этом сборе), иначе секция считается пустой.
+func sectionErrors(rs m.ResultSetT, cfg *config.CfgApp, report fetchErrors) []m.SectionError {
+	var errs []m.SectionError
+	for _, source := range config.Sources {
+		if !cfg.Enabled(source) {
+			continue
+		}
+		if se, ok := sectionError(rs, source, report); !ok {
+			errs = append(errs, se)
 		}
 	}
+	return errs
+}
 
-	// VoiceCall
-	if len(rs.VoiceCall) == 0 {
-		return fmt.Errorf("voice_call empty")
+// sectionError — собрана ли секция source; если нет — с кодом причины.
+func sectionError(rs m.ResultSetT, source string, report fetchErrors) (m.SectionError, bool) {
+	empty := checkSection(rs, source)
+	if empty == nil {
+		return m.SectionError{}, true
+	}
+	if report == nil {
+		report = sectionErrs(nil)
+	}
+	if err := report.Err(source); err != nil {
+		return m.SectionError{Section: source, Code: errorCode(err), Message: err.Error()}, false
 	}
+	// фетчер отработал без ошибки, но валидных записей нет
+	return m.SectionError{Section: source, Code: m.ErrCodeEmpty, Message: empty.Error()}, false
+}
 
-	// Email
-	if rs.Email == nil || len(rs.Email) == 0 {
-		return fmt.Errorf("email empty")
+// errorCode классифицирует ошибку фетчера для ResultT.Errors.
+func errorCode(err error) string {
+	var (
+		netErr    net.Error
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		numErr    *strconv.NumError
+		exitErr   *exec.ExitError
+		prevErr   m.SectionError
+	)
+	switch {
+	case errors.As(err, &prevErr): // причина из прошлого сбора — код уже известен
+		return prevErr.Code
+	case errors.Is(err, context.Canceled):
+		return m.ErrCodeCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return m.ErrCodeTimeout
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr), errors.As(err, &numErr),
+		errors.Is(err, jsonx.ErrTopLevelNotArray), errors.Is(err, jsonx.ErrUnterminatedArray),
+		errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, bill.ErrBadState):
+		return m.ErrCodeParse
+	case errors.As(err, &netErr), errors.Is(err, httpx.ErrStatus),
+		errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrPermission),
+		errors.Is(err, exec.ErrNotFound), errors.As(err, &exitErr):
+		return m.ErrCodeUnreachable
 	}
-	for _, buckets := range rs.Email {
-		if len(buckets) == 0 {
-			return fmt.Errorf("email has empty buckets")
+	return m.ErrCodeFailed
+}
+
+// checkSection — заполнена ли секция источника в rs.
+func checkSection(rs m.ResultSetT, source string) error {
+	switch source {
+	case config.SourceSMS:
+		if len(rs.SMS) == 0 {
+			return fmt.Errorf("sms empty")
 		}
-		for _, bucket := range buckets {
-			if len(bucket) == 0 {
-				return fmt.Errorf("email has empty bucket")
+		for _, batch := range rs.SMS {
+			if len(batch) == 0 {
+				return fmt.Errorf("sms has empty batch")
 			}
 		}
+
+	case config.SourceMMS:
+		if len(rs.MMS) == 0 {
+			return fmt.Errorf("mms empty")
+		}
+		for _, batch := range rs.MMS {
+			if len(batch) == 0 {
+				return fmt.Errorf("mms has empty batch")
+			}
+		}
+
+	case config.SourceVoice:
+		if len(rs.VoiceCall) == 0 {
+			return fmt.Errorf("voice_call empty")
+		}
+
+	case config.SourceEmail:
+		if len(rs.Email) == 0 {
+			return fmt.Errorf("email empty")
+		}
+		for _, buckets := range rs.Email {
+			if len(buckets) == 0 {
+				return fmt.Errorf("email has empty buckets")
+			}
+			for _, bucket := range buckets {
+				if len(bucket) == 0 {
+					return fmt.Errorf("email has empty bucket")
+				}
+			}
+		}
+
+	case config.SourceBilling:
+		// Billing — проверяем на нулевое значение структуры
+		if reflect.ValueOf(rs.Billing).IsZero() {
+			return fmt.Errorf("billing is zero")
+		}
+
+	case config.SourceSupport:
+		if len(rs.Support) == 0 {
+			return fmt.Errorf("support empty")
+		}
+
+	case config.SourceIncident:
+		if len(rs.Incidents) == 0 {
+			return fmt.Errorf("incident empty")
+		}
+
+	default:
+		return fmt.Errorf("unknown source %q", source)
 	}
+	return nil
+}
 
-	// Billing — проверяем на нулевое значение структуры
-	if reflect.ValueOf(rs.Billing).IsZero() {
-		return fmt.Errorf("billing is zero")
+// SectionData — данные одной секции rs (то, что отдаёт /api/v1/<секция>).
+func SectionData(rs m.ResultSetT, source string) any {
+	switch source {
+	case config.SourceSMS:
+		return rs.SMS
+	case config.SourceMMS:
+		return rs.MMS
+	case config.SourceVoice:
+		return rs.VoiceCall
+	case config.SourceEmail:
+		return rs.Email
+	case config.SourceBilling:
+		return rs.Billing
+	case config.SourceSupport:
+		return rs.Support
+	case config.SourceIncident:
+		return rs.Incidents
 	}
+	return nil
+}
 
-	// Support
-	if len(rs.Support) == 0 {
-		return fmt.Errorf("support empty")
+// BuildResult формирует r по заданным правилам; выключенные в cfg источники ошибкой не считаются.
+// report — ошибки фетчеров этого сбора (может быть nil): по ним в r.Errors проставляется код причины.
+// Data заполняется по режиму cfg.PartialResults (см. WithMode).
+func BuildResultT(rs m.ResultSetT, cfg *config.CfgApp, report fetchErrors) m.ResultT {
+	r := m.ResultT{Sections: make(map[string]string, len(config.Sources))}
+	for _, source := range config.Sources {
+		if !cfg.Enabled(source) {
+			r.Sections[source] = m.SectionDisabled
+		} else {
+			r.Sections[source] = m.StatusOK
+		}
 	}
 
-	// Incidents
-	if len(rs.Incidents) == 0 {
-		return fmt.Errorf("incident empty")
+	errs := sectionErrors(rs, cfg, report)
+	for _, e := range errs {
+		r.Sections[e.Section] = m.SectionMissing
+	}
+	switch {
+	case len(errs) == 0:
+		r.State = m.StatusOK
+	case len(errs) == countEnabled(cfg):
+		r.State = m.StatusFailed
+	default:
+		r.State = m.StatusDegraded
 	}
 
-	return nil
+	if len(errs) > 0 {
+		// есть пропуски: в Error — краткая сводка для людей, в Errors — по секциям для машин
+		failed := make([]string, 0, len(errs))
+		for _, e := range errs {
+			failed = append(failed, e.Section+" ("+e.Code+")")
+		}
+		r.Error = "Error on collect data: " + strings.Join(failed, ", ")
+		r.Errors = errs
+	}
+	r.Status = r.State == m.StatusOK
+
+	return WithMode(r, rs, cfg != nil && cfg.PartialResults)
 }
 
-// BuildResult формирует r по заданным правилам
-func BuildResultT(rs m.ResultSetT) m.ResultT {
-	if err := validateResultSet(rs); err != nil {
-		// есть пропуски
-		return m.ResultT{
-			Status: false,
-			// Data не заполняем (останется нулевым значением)
-			Error: "Error on collect data",
+// WithMode заполняет r.Data по режиму: strict (partial=false, как раньше) — весь rs только при State ok,
+// иначе Data пустой; partial — только собранные секции (Sections ok или stale) при любом State.
+// Нужен хендлеру, чтобы переключать режим параметром запроса поверх закэшированного результата.
+func WithMode(r m.ResultT, rs m.ResultSetT, partial bool) m.ResultT {
+	switch {
+	case r.State == m.StatusOK:
+		r.Data = rs
+	case partial:
+		r.Data = m.ResultSetT{}
+		for source, st := range r.Sections {
+			if st == m.StatusOK || st == m.SectionStale {
+				copySection(&r.Data, rs, source)
+			}
 		}
+	default:
+		r.Data = m.ResultSetT{} // Data не заполняем (останется нулевым значением)
 	}
+	return r
+}
 
-	// всё заполнено
-	return m.ResultT{
-		Status: true,
-		Data:   rs,
-		Error:  "",
+// KeepStale подставляет в свежий сбор (rs, r) последние хорошие значения секций из прошлого (prevRS, prevR)
+// там, где источник сейчас не ответил. Такие секции помечаются stale, причина остаётся в r.Errors;
+// State — degraded (данные есть, но не все свежие), Status — false.
+func KeepStale(rs m.ResultSetT, r m.ResultT, prevRS m.ResultSetT, prevR m.ResultT, cfg *config.CfgApp) (m.ResultSetT, m.ResultT) {
+	stale := false
+	for source, st := range r.Sections {
+		if st != m.SectionMissing {
+			continue
+		}
+		if prev := prevR.Sections[source]; prev != m.StatusOK && prev != m.SectionStale {
+			continue
+		}
+		copySection(&rs, prevRS, source)
+		r.Sections[source] = m.SectionStale
+		stale = true
+	}
+	if !stale {
+		return rs, r
+	}
+	r.State, r.Status = m.StatusDegraded, false
+	return rs, WithMode(r, rs, cfg != nil && cfg.PartialResults)
+}
+
+// Merge накладывает сбор части источников (due) на прошлый результат (prevRS, prevR): секции due и их ошибки
+// берутся из свежего (rs, r), остальные — из прошлого вместе со статусом и причиной. Источник due, который
+// сейчас не ответил, отдаёт последнее хорошее значение (stale, см. KeepStale). Нужен планировщику,
+// у которого источники опрашиваются с разными интервалами.
+func Merge(prevRS m.ResultSetT, prevR m.ResultT, rs m.ResultSetT, r m.ResultT, due []string, cfg *config.CfgApp) (m.ResultSetT, m.ResultT) {
+	merged := prevRS
+	errs := make(sectionErrs)
+	for _, e := range prevR.Errors {
+		errs[e.Section] = e
+	}
+	for _, source := range config.Sources {
+		switch {
+		case slices.Contains(due, source):
+			copySection(&merged, rs, source)
+			delete(errs, source)
+		case prevR.Sections[source] == m.SectionStale:
+			// stale-значение не должно стать ok: убираем, KeepStale вернёт его с прежней пометкой
+			copySection(&merged, m.ResultSetT{}, source)
+		}
+	}
+	for _, e := range r.Errors {
+		if slices.Contains(due, e.Section) {
+			errs[e.Section] = e
+		}
+	}
+	return KeepStale(merged, BuildResultT(merged, cfg, errs), prevRS, prevR, cfg)
+}
+
+func countEnabled(cfg *config.CfgApp) int {
+	n := 0
+	for _, source := range config.Sources {
+		if cfg.Enabled(source) {
+			n++
+		}
+	}
+	return n
+}
+
+// copySection переносит секцию source из src в dst.
+func copySection(dst *m.ResultSetT, src m.ResultSetT, source string) {
+	switch source {
+	case config.SourceSMS:
+		dst.SMS = src.SMS
+	case config.SourceMMS:
+		dst.MMS = src.MMS
+	case config.SourceVoice:
+		dst.VoiceCall = src.VoiceCall
+	case config.SourceEmail:
+		dst.Email = src.Email
+	case config.SourceBilling:
+		dst.Billing = src.Billing
+	case config.SourceSupport:
+		dst.Support = src.Support
+	case config.SourceIncident:
+		dst.Incidents = src.Incidents
 	}
 }
@@ -2,9 +2,25 @@ package mainfetcher
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	bill "main/billingstat"
+	"main/config"
+	"main/internal/health"
+	"main/internal/httpx"
+	"main/internal/jsonx"
 	m "main/internal/model"
+	"net"
+	"net/http"
+	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -140,7 +156,7 @@ func validResultSet(t *testing.T) m.ResultSetT {
 
 func TestBuildResultT_Ok(t *testing.T) {
 	rs := validResultSet(t)
-	got := BuildResultT(rs)
+	got := BuildResultT(rs, nil, nil)
 
 	if !got.Status {
 		t.Fatalf("Status=false, want true")
@@ -165,12 +181,16 @@ func TestBuildResultT_Error(t *testing.T) {
 		Incidents: []m.IncidentData{m.IncidentData{}},
 	}
 
-	got := BuildResultT(rs)
+	got := BuildResultT(rs, nil, nil)
 	if got.Status {
 		t.Fatalf("Status=true, want false")
 	}
-	if got.Error != "Error on collect data" {
-		t.Fatalf("Error=%q, want %q", got.Error, "Error on collect data")
+	if want := "Error on collect data: billing (empty)"; got.Error != want {
+		t.Fatalf("Error=%q, want %q", got.Error, want)
+	}
+	wantErrs := []m.SectionError{{Section: config.SourceBilling, Code: m.ErrCodeEmpty, Message: "billing is zero"}}
+	if !reflect.DeepEqual(got.Errors, wantErrs) {
+		t.Fatalf("Errors = %#v, want %#v", got.Errors, wantErrs)
 	}
 	// Data должен остаться нулевым значением структуры.
 	if !reflect.DeepEqual(got.Data, m.ResultSetT{}) {
@@ -178,11 +198,199 @@ func TestBuildResultT_Error(t *testing.T) {
 	}
 }
 
+// причина пустой секции берётся из ошибки фетчера в report, без ошибки — empty
+func TestBuildResultT_ErrorsFromReport(t *testing.T) {
+	rs := validResultSet(t)
+	rs.MMS, rs.Support, rs.Incidents = nil, nil, nil
+
+	var report health.Report
+	ctx := health.WithReport(context.Background(), &report)
+	health.Record(ctx, config.SourceMMS, time.Now(), 0, fmt.Errorf("mmsdata.Fetch: %w", context.DeadlineExceeded))
+	health.Record(ctx, config.SourceSupport, time.Now(), 0, fmt.Errorf("decode: %w", jsonx.ErrTopLevelNotArray))
+
+	got := BuildResultT(rs, nil, &report)
+	want := []m.SectionError{
+		{Section: config.SourceMMS, Code: m.ErrCodeTimeout, Message: "mmsdata.Fetch: context deadline exceeded"},
+		{Section: config.SourceSupport, Code: m.ErrCodeParse, Message: "decode: jsonx: expected top-level JSON array"},
+		{Section: config.SourceIncident, Code: m.ErrCodeEmpty, Message: "incident empty"},
+	}
+	if got.Status || !reflect.DeepEqual(got.Errors, want) {
+		t.Fatalf("got %+v,\nwant errors %+v", got, want)
+	}
+	if wantMsg := "Error on collect data: mms (timeout), support (parse), incident (empty)"; got.Error != wantMsg {
+		t.Fatalf("Error = %q, want %q", got.Error, wantMsg)
+	}
+}
+
+func TestBuildResultT_Modes(t *testing.T) {
+	full := validResultSet(t)
+	degraded := full
+	degraded.Incidents = nil // один источник не ответил
+
+	tests := []struct {
+		name        string
+		rs          m.ResultSetT
+		cfg         *config.CfgApp
+		wantState   string
+		wantStatus  bool
+		wantData    m.ResultSetT
+		wantSection map[string]string
+	}{
+		{"ok", full, nil, m.StatusOK, true, full, nil},
+		{"degraded strict", degraded, nil, m.StatusDegraded, false, m.ResultSetT{}, map[string]string{config.SourceIncident: m.SectionMissing}},
+		{"degraded partial", degraded, &config.CfgApp{PartialResults: true}, m.StatusDegraded, false, degraded,
+			map[string]string{config.SourceIncident: m.SectionMissing, config.SourceSMS: m.StatusOK}},
+		{"failed partial", m.ResultSetT{}, &config.CfgApp{PartialResults: true}, m.StatusFailed, false, m.ResultSetT{}, nil},
+		{"disabled is not missing", degraded, &config.CfgApp{DisableIncident: true}, m.StatusOK, true, degraded,
+			map[string]string{config.SourceIncident: m.SectionDisabled}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildResultT(tt.rs, tt.cfg, nil)
+			if got.State != tt.wantState || got.Status != tt.wantStatus {
+				t.Fatalf("State=%q Status=%v, want %q %v", got.State, got.Status, tt.wantState, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(got.Data, tt.wantData) {
+				t.Fatalf("Data mismatch:\n got: %#v\nwant: %#v", got.Data, tt.wantData)
+			}
+			if len(got.Sections) != len(config.Sources) {
+				t.Fatalf("Sections = %v, want all sources", got.Sections)
+			}
+			for source, want := range tt.wantSection {
+				if got.Sections[source] != want {
+					t.Fatalf("Sections[%s] = %q, want %q", source, got.Sections[source], want)
+				}
+			}
+		})
+	}
+}
+
+// partial оставляет только собранные секции: невалидная (пустой батч) в Data не попадает
+func TestWithMode_PartialDropsMissing(t *testing.T) {
+	rs := validResultSet(t)
+	rs.SMS = [][]m.SMSData{{}}
+	r := BuildResultT(rs, nil, nil)
+
+	got := WithMode(r, rs, true)
+	if got.Data.SMS != nil || len(got.Data.MMS) == 0 || got.State != m.StatusDegraded {
+		t.Fatalf("partial data: %+v", got)
+	}
+	if strict := WithMode(got, rs, false); !reflect.DeepEqual(strict.Data, m.ResultSetT{}) {
+		t.Fatalf("strict data must be empty: %+v", strict.Data)
+	}
+}
+
+// не ответивший источник отдаёт прошлое хорошее значение с пометкой stale
+func TestKeepStale(t *testing.T) {
+	prevRS := validResultSet(t)
+	prevR := BuildResultT(prevRS, nil, nil)
+
+	rs := validResultSet(t)
+	rs.MMS = nil
+	r := BuildResultT(rs, nil, nil)
+
+	gotRS, gotR := KeepStale(rs, r, prevRS, prevR, &config.CfgApp{PartialResults: true})
+	if !reflect.DeepEqual(gotRS.MMS, prevRS.MMS) {
+		t.Fatalf("MMS = %#v, want previous %#v", gotRS.MMS, prevRS.MMS)
+	}
+	if gotR.Sections[config.SourceMMS] != m.SectionStale || gotR.State != m.StatusDegraded || gotR.Status {
+		t.Fatalf("result = %+v", gotR)
+	}
+	if len(gotR.Errors) != 1 || gotR.Errors[0].Section != config.SourceMMS {
+		t.Fatalf("errors must keep the failure reason: %+v", gotR.Errors)
+	}
+	if !reflect.DeepEqual(gotR.Data.MMS, prevRS.MMS) {
+		t.Fatalf("partial data must include stale section: %#v", gotR.Data.MMS)
+	}
+
+	// прошлого хорошего значения нет — секция остаётся missing
+	_, r2 := KeepStale(rs, BuildResultT(rs, nil, nil), m.ResultSetT{}, m.ResultT{}, nil)
+	if r2.Sections[config.SourceMMS] != m.SectionMissing {
+		t.Fatalf("without previous value: %+v", r2.Sections)
+	}
+}
+
+// планировщик опрашивает источники по очереди: неопрошенные берутся из прошлого снимка вместе со статусом
+func TestMerge(t *testing.T) {
+	cfg := &config.CfgApp{}
+	prevRS := validResultSet(t)
+	prevR := BuildResultT(prevRS, cfg, nil)
+
+	// раунд 1: sms обновился, mms не ответил — stale с причиной timeout, остальные как были
+	due := []string{config.SourceSMS, config.SourceMMS}
+	fresh := validResultSet(t)
+	fresh.SMS = fresh.SMS[:1]
+	fresh.MMS = nil
+	r := BuildResultT(fresh, cfg.Only(due...), sectionErrs{config.SourceMMS: {Section: config.SourceMMS, Code: m.ErrCodeTimeout, Message: "deadline"}})
+	rs1, r1 := Merge(prevRS, prevR, fresh, r, due, cfg)
+	if len(rs1.SMS) != 1 || !reflect.DeepEqual(rs1.MMS, prevRS.MMS) || !reflect.DeepEqual(rs1.Billing, prevRS.Billing) {
+		t.Fatalf("merged rs = %+v", rs1)
+	}
+	if r1.Sections[config.SourceMMS] != m.SectionStale || r1.Sections[config.SourceVoice] != m.StatusOK || r1.State != m.StatusDegraded {
+		t.Fatalf("round 1 result = %+v", r1)
+	}
+	if len(r1.Errors) != 1 || r1.Errors[0].Code != m.ErrCodeTimeout {
+		t.Fatalf("round 1 errors = %+v", r1.Errors)
+	}
+
+	// раунд 2: mms не опрашивался — остаётся stale с прежней причиной
+	due = []string{config.SourceVoice}
+	rs2, r2 := Merge(rs1, r1, validResultSet(t), BuildResultT(validResultSet(t), cfg.Only(due...), nil), due, cfg)
+	if r2.Sections[config.SourceMMS] != m.SectionStale || len(r2.Errors) != 1 || r2.Errors[0].Code != m.ErrCodeTimeout {
+		t.Fatalf("round 2 result = %+v", r2)
+	}
+	if len(rs2.SMS) != 1 {
+		t.Fatalf("sms from round 1 lost: %+v", rs2.SMS)
+	}
+
+	// раунд 3: mms ответил — всё снова ok
+	due = []string{config.SourceMMS}
+	_, r3 := Merge(rs2, r2, validResultSet(t), BuildResultT(validResultSet(t), cfg.Only(due...), nil), due, cfg)
+	if r3.State != m.StatusOK || !r3.Status || len(r3.Errors) != 0 {
+		t.Fatalf("round 3 result = %+v", r3)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	_, numErr := strconv.Atoi("x")
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"canceled", fmt.Errorf("read: %w", context.Canceled), m.ErrCodeCanceled},
+		{"deadline", context.DeadlineExceeded, m.ErrCodeTimeout},
+		{"client timeout", &url.Error{Op: "Get", URL: "http://x", Err: timeoutErr{}}, m.ErrCodeTimeout},
+		{"refused", &url.Error{Op: "Get", URL: "http://x", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, m.ErrCodeUnreachable},
+		{"http status", fmt.Errorf("op: %w: 500", httpx.ErrStatus), m.ErrCodeUnreachable},
+		{"no file", &fs.PathError{Op: "open", Path: "sms.data", Err: fs.ErrNotExist}, m.ErrCodeUnreachable},
+		{"json", fmt.Errorf("decode body: %w", &json.SyntaxError{}), m.ErrCodeParse},
+		{"number", numErr, m.ErrCodeParse},
+		{"billing", fmt.Errorf("%w: invalid byte 'x'", bill.ErrBadState), m.ErrCodeParse},
+		{"other", errors.New("file too large: 10"), m.ErrCodeFailed},
+		{"previous round", m.SectionError{Section: "mms", Code: m.ErrCodeTimeout, Message: "deadline"}, m.ErrCodeTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorCode(tt.err); got != tt.want {
+				t.Fatalf("errorCode(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
 func TestValidateResultSet_Table(t *testing.T) {
 	type tc struct {
 		name    string
 		mutate  func(*m.ResultSetT)
-		wantErr string // подстрока ожидаемой ошибки (пустая = ошибок не должно быть)
+		cfg     *config.CfgApp // nil — все источники включены
+		wantErr string         // подстрока ожидаемой ошибки (пустая = ошибок не должно быть)
 	}
 
 	tests := []tc{
@@ -282,6 +490,32 @@ func TestValidateResultSet_Table(t *testing.T) {
 			},
 			wantErr: "incident empty",
 		},
+		// выключенные источники пустыми быть могут
+		{
+			name: "billing_zero_but_disabled",
+			mutate: func(rs *m.ResultSetT) {
+				rs.Billing = m.BillingData{}
+			},
+			cfg:     &config.CfgApp{DisableBilling: true},
+			wantErr: "",
+		},
+		{
+			name: "voice_and_email_disabled",
+			mutate: func(rs *m.ResultSetT) {
+				rs.VoiceCall = nil
+				rs.Email = nil
+			},
+			cfg:     &config.CfgApp{DisableVoice: true, DisableEmail: true},
+			wantErr: "",
+		},
+		{
+			name: "other_source_disabled_still_checked",
+			mutate: func(rs *m.ResultSetT) {
+				rs.Support = nil
+			},
+			cfg:     &config.CfgApp{DisableIncident: true},
+			wantErr: "support empty",
+		},
 	}
 
 	base := validResultSet(t)
@@ -292,7 +526,7 @@ func TestValidateResultSet_Table(t *testing.T) {
 			if tt.mutate != nil {
 				tt.mutate(&rs)
 			}
-			err := validateResultSet(rs)
+			err := validateResultSet(rs, tt.cfg)
 			if tt.wantErr == "" {
 				if err != nil {
 					t.Fatalf("unexpected error: %v", err)
@@ -308,3 +542,78 @@ func TestValidateResultSet_Table(t *testing.T) {
 		})
 	}
 }
+
+func TestEnabledFetchers_SkipsDisabled(t *testing.T) {
+	var calls atomic.Int32
+	all := []sourceFetcher{
+		{config.SourceSMS, okFetcher(0, &calls)},
+		{config.SourceVoice, okFetcher(0, &calls)},
+		{config.SourceBilling, okFetcher(0, &calls)},
+	}
+	cfg := &config.CfgApp{DisableVoice: true, DisableBilling: true}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	if got := len(enabledFetchers(logger, cfg, all)); got != 1 {
+		t.Fatalf("enabled fetchers = %d, want 1", got)
+	}
+	if got := len(enabledFetchers(logger, nil, all)); got != len(all) {
+		t.Fatalf("nil cfg: enabled fetchers = %d, want %d", got, len(all))
+	}
+}
+
+// GetSectionData запускает только фетчер запрошенной секции
+func TestGetSectionData(t *testing.T) {
+	orig := sectionFetchers
+	t.Cleanup(func() { sectionFetchers = orig })
+
+	var calls atomic.Int32
+	sectionFetchers = func(_ *slog.Logger, _ *config.CfgApp, _ *http.Client, rs *m.ResultSetT, mu *sync.Mutex) []sourceFetcher {
+		write := func(fill func()) fetcher {
+			return func(g *errgroup.Group, ctx context.Context) {
+				g.Go(func() error {
+					calls.Add(1)
+					mu.Lock()
+					fill()
+					mu.Unlock()
+					return nil
+				})
+			}
+		}
+		return []sourceFetcher{
+			{config.SourceSMS, write(func() { rs.SMS = [][]m.SMSData{{{}}} })},
+			{config.SourceVoice, write(func() {})}, // ничего не нашёл
+			{config.SourceIncident, write(func() { rs.Incidents = []m.IncidentData{{}} })},
+			{config.SourceMMS, func(g *errgroup.Group, ctx context.Context) {
+				g.Go(func() error {
+					health.Record(ctx, config.SourceMMS, time.Now(), 0, fmt.Errorf("mmsdata.Fetch: %w: 502 Bad Gateway (502)", httpx.ErrStatus))
+					return nil
+				})
+			}},
+		}
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	rs, err := GetSectionData(ctx, logger, nil, config.SourceIncident)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rs.Incidents) != 1 || rs.SMS != nil || calls.Load() != 1 {
+		t.Fatalf("only incident fetcher must run: rs=%+v calls=%d", rs, calls.Load())
+	}
+
+	var se m.SectionError
+	if _, err := GetSectionData(ctx, logger, nil, config.SourceVoice); !errors.As(err, &se) ||
+		err.Error() != "voice_call empty" || se.Code != m.ErrCodeEmpty {
+		t.Fatalf("empty section: err = %#v", err)
+	}
+	if _, err := GetSectionData(ctx, logger, nil, config.SourceMMS); !errors.As(err, &se) || se.Code != m.ErrCodeUnreachable {
+		t.Fatalf("failed section: err = %#v", err)
+	}
+	if _, err := GetSectionData(ctx, logger, &config.CfgApp{DisableSms: true}, config.SourceSMS); !errors.Is(err, ErrSourceDisabled) {
+		t.Fatalf("disabled section: err = %v", err)
+	}
+	if _, err := GetSectionData(ctx, logger, nil, "fax"); err == nil {
+		t.Fatalf("unknown section: expected error")
+	}
+}
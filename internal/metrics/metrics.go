@@ -0,0 +1,138 @@
+// Package metrics — метрики сервиса для Prometheus (GET /metrics): опрос источников, разбор записей,
+// кэш ответов и HTTP-запросы. Метрики глобальные, как и реестр internal/health: зарегистрированы
+// в prometheus.DefaultRegisterer, /metrics отдаёт их вместе со стандартными go_* и process_*.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Результат опроса источника (метка result у statecollector_fetch_total)
+const (
+	FetchSuccess = "success"
+	FetchFailure = "failure" // ошибка источника, в т.ч. таймаут
+	FetchCancel  = "cancel"  // запрос отменён клиентом
+)
+
+// Результат обращения к кэшу (метка result у statecollector_cache_requests_total)
+const (
+	CacheHit   = "hit"   // свежее значение
+	CacheStale = "stale" // устаревшее значение отдано, обновление — в фоне
+	CacheMiss  = "miss"  // значения нет, запрос ждёт сбор
+)
+
+// Причина отказа 429 (метка reason у statecollector_http_throttled_total)
+const (
+	ThrottleClient   = "client"    // лимит запросов с одного IP
+	ThrottleGlobal   = "global"    // лимит запросов на весь сервис
+	ThrottleInFlight = "in_flight" // слишком много запросов обрабатывается одновременно
+)
+
+// корзины длительностей, секунды: от быстрых файлов до медленных HTTP-источников
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var (
+	fetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "statecollector_fetch_duration_seconds", Help: "Duration of a source fetch.", Buckets: durationBuckets,
+	}, []string{"source"})
+	fetchTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "statecollector_fetch_total", Help: "Source fetches by result (success, failure, cancel).",
+	}, []string{"source", "result"})
+	recordsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "statecollector_records_total", Help: "Records read from a source: accepted or rejected by parsing/Validate().",
+	}, []string{"source", "result"})
+	cacheTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "statecollector_cache_requests_total", Help: "Response cache lookups by cache (full, section) and result (hit, stale, miss).",
+	}, []string{"cache", "result"})
+	httpTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "statecollector_http_requests_total", Help: "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "code"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "statecollector_http_request_duration_seconds", Help: "HTTP request duration by route.", Buckets: durationBuckets,
+	}, []string{"route"})
+	throttledTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "statecollector_http_throttled_total", Help: "HTTP requests rejected with 429 by reason (client, global, in_flight).",
+	}, []string{"reason"})
+
+	// droppedTotal — сэмплы, отброшенные из-за неверного набора меток (ошибка в коде вызова, а не повод падать)
+	droppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "statecollector_metrics_dropped_total", Help: "Metric samples dropped because of a label mismatch.",
+	})
+)
+
+// add увеличивает серию values счётчика c на v; v < 0 игнорируется (счётчик не убывает),
+// неверное число меток — сэмпл отбрасывается и учитывается в droppedTotal.
+func add(c *prometheus.CounterVec, v float64, values ...string) {
+	if v < 0 {
+		return
+	}
+	m, err := c.GetMetricWithLabelValues(values...)
+	if err != nil {
+		droppedTotal.Inc()
+		return
+	}
+	m.Add(v)
+}
+
+// observe — наблюдение v в серии values гистограммы h; неверные метки — как в add.
+func observe(h *prometheus.HistogramVec, v float64, values ...string) {
+	m, err := h.GetMetricWithLabelValues(values...)
+	if err != nil {
+		droppedTotal.Inc()
+		return
+	}
+	m.Observe(v)
+}
+
+// FetchResult — метка результата опроса по его ошибке.
+func FetchResult(err error) string {
+	switch {
+	case err == nil:
+		return FetchSuccess
+	case errors.Is(err, context.Canceled):
+		return FetchCancel
+	default:
+		return FetchFailure
+	}
+}
+
+// ObserveFetch учитывает опрос источника длительностью d с итогом err.
+func ObserveFetch(source string, d time.Duration, err error) {
+	observe(fetchDuration, d.Seconds(), source)
+	add(fetchTotal, 1, source, FetchResult(err))
+}
+
+// Records учитывает записи источника: принятые и отброшенные при разборе/валидации.
+func Records(source string, accepted, rejected int) {
+	add(recordsTotal, float64(accepted), source, "accepted")
+	add(recordsTotal, float64(rejected), source, "rejected")
+}
+
+// Cache учитывает обращение к кэшу ответов (cache: full — "/", section — /api/v1/...; result — CacheHit...).
+func Cache(cache, result string) {
+	add(cacheTotal, 1, cache, result)
+}
+
+// ObserveHTTP учитывает обработанный HTTP-запрос; route — шаблон маршрута, а не сырой путь (кардинальность).
+func ObserveHTTP(route, method string, code int, d time.Duration) {
+	add(httpTotal, 1, route, method, strconv.Itoa(code))
+	observe(httpDuration, d.Seconds(), route)
+}
+
+// Throttled учитывает запрос, отклонённый ограничением нагрузки (reason — ThrottleClient...).
+func Throttled(reason string) {
+	add(throttledTotal, 1, reason)
+}
+
+// Handler отдаёт все метрики в формате Prometheus (формат — по Accept, по умолчанию text exposition 0.0.4).
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
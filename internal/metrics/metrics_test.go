@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestFetchResult(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, FetchSuccess},
+		{fmt.Errorf("read: %w", context.Canceled), FetchCancel},
+		{context.DeadlineExceeded, FetchFailure}, // таймаут источника — его отказ
+		{errors.New("status 500"), FetchFailure},
+	}
+	for _, tt := range tests {
+		if got := FetchResult(tt.err); got != tt.want {
+			t.Errorf("FetchResult(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestObserveFetch(t *testing.T) {
+	ObserveFetch("observe_src", 20*time.Millisecond, nil)
+	ObserveFetch("observe_src", time.Second, context.Canceled)
+	Records("observe_src", 5, 2)
+	Records("observe_src", 1, -1) // счётчик не убывает
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"fetch success", testutil.ToFloat64(fetchTotal.WithLabelValues("observe_src", FetchSuccess)), 1},
+		{"fetch cancel", testutil.ToFloat64(fetchTotal.WithLabelValues("observe_src", FetchCancel)), 1},
+		{"records accepted", testutil.ToFloat64(recordsTotal.WithLabelValues("observe_src", "accepted")), 6},
+		{"records rejected", testutil.ToFloat64(recordsTotal.WithLabelValues("observe_src", "rejected")), 2},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+// неверное число меток не роняет сервис: сэмпл отбрасывается и учитывается
+func TestLabelMismatch_Dropped(t *testing.T) {
+	before := testutil.ToFloat64(droppedTotal)
+	add(fetchTotal, 1, "only_source")
+	observe(httpDuration, 0.1, "/x", "GET")
+	if got := testutil.ToFloat64(droppedTotal) - before; got != 2 {
+		t.Fatalf("dropped = %v, want 2", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ObserveHTTP("/handler_test", http.MethodGet, http.StatusOK, 30*time.Millisecond)
+	Throttled(ThrottleGlobal)
+
+	rr := httptest.NewRecorder()
+	Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("Content-Type = %q", rr.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{
+		"# TYPE statecollector_http_requests_total counter\n",
+		`statecollector_http_requests_total{code="200",method="GET",route="/handler_test"} 1`,
+		"# TYPE statecollector_http_request_duration_seconds histogram\n",
+		`statecollector_http_request_duration_seconds_bucket{route="/handler_test",le="0.05"} 1`,
+		`statecollector_http_request_duration_seconds_count{route="/handler_test"} 1`,
+		`statecollector_http_throttled_total{reason="global"} 1`,
+	} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, rr.Body.String())
+		}
+	}
+}
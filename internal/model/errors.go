@@ -0,0 +1,20 @@
+package model
+
+// Коды ошибок секции в ResultT.Errors
+const (
+	ErrCodeTimeout     = "timeout"     // источник не ответил за отведённое время
+	ErrCodeUnreachable = "unreachable" // нет соединения/файла/команды, HTTP-статус не 2xx
+	ErrCodeParse       = "parse"       // данные пришли, но не разбираются
+	ErrCodeEmpty       = "empty"       // данные разобраны, но валидных записей нет
+	ErrCodeCanceled    = "canceled"    // сбор отменён (клиент ушёл, остановка сервиса)
+	ErrCodeFailed      = "failed"      // прочие ошибки источника
+)
+
+// SectionError — почему секция не собрана; ResultT.Errors содержит по одной записи на секцию.
+type SectionError struct {
+	Section string `json:"section"` // имя источника, как в конфиге: sms, mms, voice, email, billing, support, incident
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e SectionError) Error() string { return e.Message }
@@ -1,9 +1,25 @@
 package model
 
+// Итог сбора в ResultT.State и состояние секции в ResultT.Sections
+const (
+	StatusOK        = "ok"       // State: собраны все включённые секции; Sections: секция собрана
+	StatusDegraded  = "degraded" // State: собрана часть секций
+	StatusFailed    = "failed"   // State: не собрано ничего
+	SectionMissing  = "missing"  // Sections: секция включена, но не собрана
+	SectionDisabled = "disabled" // Sections: источник выключен в конфиге
+	SectionStale    = "stale"    // Sections: источник не ответил, отдано прошлое хорошее значение
+)
+
 type ResultT struct {
 	Status bool       `json:"status"` // true, если все этапы сбора данных  прошли успешно, false во всех остальных случаях
-	Data   ResultSetT `json:"data"`   // заполнен, если все этапы сбора данных прошли успешно, nil во всех остальных случаях
+	Data   ResultSetT `json:"data"`   // strict: заполнен, только если все этапы сбора прошли успешно; partial: все собранные секции
 	Error  string     `json:"error"`  // пустая строка если все этапы сбора данных прошли успешно, в случае ошибки заполнено текстом ошибки (детали ниже)
+	// по записи на каждую несобранную секцию (код и причина), пусто если всё собрано
+	Errors []SectionError `json:"errors,omitempty"`
+	// ok | degraded | failed — в отличие от Status различает «собрано частично» и «не собрано ничего»
+	State string `json:"state,omitempty"`
+	// источник → ok | missing | disabled | stale
+	Sections map[string]string `json:"sections,omitempty"`
 }
 
 type ResultSetT struct {
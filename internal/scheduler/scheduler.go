@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"main/config"
+	res "main/internal/mainfetcher"
+	m "main/internal/model"
+)
+
+/*
+Фоновый сбор по расписанию: данные собираются не на HTTP-запрос, а раз в collect.interval,
+хендлеры отдают последний опубликованный снимок. Медленный источник не задерживает ответ,
+а всплеск запросов не множит опросы апстримов.
+
+	collect:  { interval: 30s, jitter: 5s }
+	billing:  { interval: 5m }   # у источника может быть свой интервал
+
+Каждый раунд опрашиваются только источники, у которых подошёл срок; их результат накладывается
+на прошлый снимок (mainfetcher.Merge), не ответивший источник отдаёт последнее хорошее значение (stale).
+Следующий срок источника — interval + случайная добавка до jitter, чтобы опросы не шли синхронно.
+После reload конфига расписание сбрасывается и первый раунд опрашивает все источники.
+*/
+
+// Publisher получает новый снимок: данные, результат, время самого старого опроса в снимке и конфиг,
+// с которым он собран (по нему сервер отличает снимок от устаревшего после reload).
+type Publisher func(rs m.ResultSetT, r m.ResultT, at time.Time, cfg *config.CfgApp)
+
+// fetch — сбор источников; переменная, чтобы подменять в тестах.
+var fetch = func(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) (m.ResultSetT, m.ResultT) {
+	return res.GetResultData(ctx, logger, cfg)
+}
+
+// recheck — как часто планировщик просыпается без дела, чтобы заметить reload конфига.
+var recheck = time.Second
+
+// Run опрашивает источники по расписанию из текущего конфига holder и отдаёт снимки в publish.
+// Пока фоновый сбор выключен (collect.interval = 0), только следит за конфигом. Работает до отмены ctx.
+func Run(ctx context.Context, logger *slog.Logger, holder *config.Holder, publish Publisher) {
+	var s state
+	for {
+		if cur := holder.Load(); cur != s.cfg {
+			s = newState(cur)
+			if cur.Scheduled() {
+				logger.Info("scheduled collection enabled",
+					slog.Duration("interval", cur.CollectInterval), slog.Duration("jitter", cur.CollectJitter))
+			}
+		}
+
+		wait := recheck
+		if s.cfg.Scheduled() {
+			if due := s.due(time.Now()); len(due) > 0 {
+				s.collect(ctx, logger, due)
+				if ctx.Err() != nil {
+					return // остановка сервиса: недособранный раунд не публикуем
+				}
+				publish(s.rs, s.r, s.oldest(), s.cfg)
+			}
+			if next := s.earliest(); !next.IsZero() { // все источники выключены — только следим за конфигом
+				wait = min(wait, time.Until(next))
+			}
+		}
+
+		t := time.NewTimer(max(wait, 0))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// state — расписание и накопленный снимок для одного конфига.
+type state struct {
+	cfg  *config.CfgApp
+	next map[string]time.Time // когда опрашивать источник; нет записи — сразу
+	last map[string]time.Time // когда источник опрошен в последний раз
+	rs   m.ResultSetT
+	r    m.ResultT
+}
+
+func newState(cfg *config.CfgApp) state {
+	return state{cfg: cfg, next: make(map[string]time.Time), last: make(map[string]time.Time)}
+}
+
+// due — включённые источники, у которых подошёл срок, в порядке config.Sources.
+func (s *state) due(now time.Time) []string {
+	var out []string
+	for _, source := range config.Sources {
+		if s.cfg.Enabled(source) && !now.Before(s.next[source]) {
+			out = append(out, source)
+		}
+	}
+	return out
+}
+
+// collect опрашивает источники due, накладывает результат на снимок и назначает им следующий срок.
+func (s *state) collect(ctx context.Context, logger *slog.Logger, due []string) {
+	start := time.Now()
+	rs, r := fetch(ctx, logger, s.cfg.Only(due...))
+	s.rs, s.r = res.Merge(s.rs, s.r, rs, r, due, s.cfg)
+
+	done := time.Now()
+	for _, source := range due {
+		s.last[source] = done
+		s.next[source] = done.Add(s.cfg.SourceInterval(source) + jitter(s.cfg.CollectJitter))
+	}
+	logger.Debug("scheduled collection done",
+		slog.Any("sources", due), slog.String("state", s.r.State), slog.Duration("took", done.Sub(start)))
+}
+
+// earliest — ближайший срок опроса среди включённых источников.
+func (s *state) earliest() time.Time {
+	var t time.Time
+	for _, source := range config.Sources {
+		if !s.cfg.Enabled(source) {
+			continue
+		}
+		if n := s.next[source]; t.IsZero() || n.Before(t) {
+			t = n
+		}
+	}
+	return t
+}
+
+// oldest — время самого давнего опроса среди включённых источников: возраст снимка не меньше этого.
+func (s *state) oldest() time.Time {
+	var t time.Time
+	for _, source := range config.Sources {
+		if !s.cfg.Enabled(source) {
+			continue
+		}
+		if l := s.last[source]; t.IsZero() || l.Before(t) {
+			t = l
+		}
+	}
+	return t
+}
+
+// jitter — случайная добавка [0, limit) к интервалу.
+func jitter(limit time.Duration) time.Duration {
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit)
+}
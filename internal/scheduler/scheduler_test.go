@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"main/config"
+	res "main/internal/mainfetcher"
+	m "main/internal/model"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// mockFetch подменяет сбор: запоминает, какие источники были включены в каждом раунде.
+func mockFetch(t *testing.T) func() [][]string {
+	t.Helper()
+	var (
+		mu     sync.Mutex
+		rounds [][]string
+	)
+	orig := fetch
+	fetch = func(_ context.Context, _ *slog.Logger, cfg *config.CfgApp) (m.ResultSetT, m.ResultT) {
+		var on []string
+		for _, source := range config.Sources {
+			if cfg.Enabled(source) {
+				on = append(on, source)
+			}
+		}
+		mu.Lock()
+		rounds = append(rounds, on)
+		mu.Unlock()
+		return m.ResultSetT{}, res.BuildResultT(m.ResultSetT{}, cfg, nil)
+	}
+	t.Cleanup(func() { fetch = orig })
+	return func() [][]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(rounds)
+	}
+}
+
+func TestState_PerSourceIntervals(t *testing.T) {
+	rounds := mockFetch(t)
+	cfg := &config.CfgApp{CollectInterval: time.Hour, IntervalSms: time.Second, DisableBilling: true}
+	s := newState(cfg)
+
+	now := time.Now()
+	due := s.due(now)
+	if len(due) != len(config.Sources)-1 || slices.Contains(due, config.SourceBilling) {
+		t.Fatalf("first round must poll all enabled sources, got %v", due)
+	}
+	s.collect(context.Background(), discard, due)
+
+	if got := s.due(now.Add(30 * time.Second)); !slices.Equal(got, []string{config.SourceSMS}) {
+		t.Fatalf("after 30s due = %v, want [sms]", got)
+	}
+	if got := s.due(now.Add(2 * time.Hour)); len(got) != len(config.Sources)-1 {
+		t.Fatalf("after 2h due = %v", got)
+	}
+	if e := s.earliest(); e.Sub(now) > 2*time.Second {
+		t.Fatalf("earliest = %s from now, want sms interval", e.Sub(now))
+	}
+	if got := rounds(); len(got) != 1 || slices.Contains(got[0], config.SourceBilling) {
+		t.Fatalf("fetch rounds = %v", got)
+	}
+	// в снимке выключенный источник так и остаётся disabled, несобранные — с причиной
+	if s.r.Sections[config.SourceBilling] != m.SectionDisabled || len(s.r.Errors) != len(config.Sources)-1 {
+		t.Fatalf("snapshot result = %+v", s.r)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if jitter(0) != 0 {
+		t.Fatalf("zero jitter must add nothing")
+	}
+	for range 100 {
+		if j := jitter(time.Second); j < 0 || j >= time.Second {
+			t.Fatalf("jitter = %s, want [0, 1s)", j)
+		}
+	}
+}
+
+type publishedCfg struct {
+	cfg *config.CfgApp
+	at  time.Time
+}
+
+func TestRun_PublishesAndFollowsReload(t *testing.T) {
+	rounds := mockFetch(t)
+	origRecheck := recheck
+	recheck = 5 * time.Millisecond
+	t.Cleanup(func() { recheck = origRecheck })
+
+	off := &config.CfgApp{}
+	holder := config.NewHolder(off)
+	pub := make(chan publishedCfg, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(ctx, discard, holder, func(_ m.ResultSetT, _ m.ResultT, at time.Time, cfg *config.CfgApp) {
+			pub <- publishedCfg{cfg, at}
+		})
+	}()
+
+	// collect.interval = 0 — планировщик ничего не собирает
+	time.Sleep(30 * time.Millisecond)
+	if n := len(rounds()); n != 0 {
+		t.Fatalf("scheduler disabled, but fetched %d times", n)
+	}
+
+	on := &config.CfgApp{CollectInterval: time.Hour}
+	holder.Store(on)
+	select {
+	case p := <-pub:
+		if p.cfg != on || p.at.IsZero() {
+			t.Fatalf("published %+v", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no snapshot after enabling scheduler")
+	}
+
+	// reload: расписание сбрасывается, первый раунд опять по всем источникам
+	reloaded := &config.CfgApp{CollectInterval: time.Hour, DisableSms: true}
+	holder.Store(reloaded)
+	select {
+	case p := <-pub:
+		if p.cfg != reloaded {
+			t.Fatalf("published with stale cfg")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no snapshot after reload")
+	}
+	got := rounds()
+	if len(got) != 2 || len(got[1]) != len(config.Sources)-1 || slices.Contains(got[1], config.SourceSMS) {
+		t.Fatalf("fetch rounds = %v", got)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Run did not stop on ctx cancel")
+	}
+}
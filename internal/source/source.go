@@ -0,0 +1,153 @@
+// Package source читает данные секции по адресу из конфига (file://, http(s)://, exec://, см. config.Location)
+// и отдаёт их существующим парсерам: построчным (sms/voice/email), битовой маске billing и jsonx-декодеру
+// (mms/support/incident). Формат данных секции от способа доставки не зависит.
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+
+	"main/config"
+	"main/internal/fileutil"
+	"main/internal/httpx"
+	"main/internal/metrics"
+	"main/internal/tracing"
+	"main/sl"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RunCommand запускает команду и возвращает её stdout; переменная — чтобы подменять в тестах.
+var RunCommand = runCommand
+
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...) // без shell: аргументы передаются как есть
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// Read возвращает содержимое источника целиком: один файл, тело GET-ответа или stdout команды.
+// Размер ограничен limit, как и файлы (cfg.MaxFile() снимка конфига, с которым идёт сбор). opts применяются только к HTTP.
+func Read(ctx context.Context, logger *slog.Logger, client httpx.Doer, loc, op string, limit int64, opts ...httpx.RequestOption) ([]byte, error) {
+	if limit <= 0 {
+		limit = config.DefaultMaxFileSize
+	}
+	scheme, target, _ := config.Location(loc)
+	switch scheme {
+	case config.SchemeHTTP:
+		return httpx.FetchBody(ctx, logger, client, target, limit, op, opts...)
+
+	case config.SchemeExec:
+		args := strings.Fields(target)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%s: empty command", op)
+		}
+		out, err := RunCommand(ctx, args[0], args[1:]...)
+		if err != nil {
+			logger.Error("Error by running command "+args[0], slog.String("op", op), sl.Err(err))
+			return nil, fmt.Errorf("%s: exec %s: %w", op, args[0], err)
+		}
+		if len(out) == 0 {
+			return nil, fmt.Errorf("%s: exec %s: empty output", op, args[0])
+		}
+		if int64(len(out)) > limit {
+			return nil, fmt.Errorf("%s: exec %s: output too large: %d", op, args[0], len(out))
+		}
+		return out, nil
+
+	default: // file
+		paths, err := fileutil.ExpandPaths(logger, target)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if len(paths) != 1 {
+			return nil, fmt.Errorf("%s: want exactly one file, %q matches %d", op, target, len(paths))
+		}
+		rf, err := fileutil.ReadFile(ctx, paths[0], limit)
+		if err != nil {
+			logger.Error("Error by opening file "+paths[0], slog.String("op", op), sl.Err(err))
+			return nil, err
+		}
+		return rf, nil
+	}
+}
+
+// Lines — построчный источник (sms/voice/email). Файлы читаются через fileutil.ReadLines (списки, glob),
+// HTTP и exec — одним куском; статистика разбора пишется в лог и в метрики записей (name — имя источника).
+// limit — лимит размера файла/тела/вывода, как в Read.
+func Lines[T any](ctx context.Context, logger *slog.Logger, client httpx.Doer, name, loc string, limit int64, parse func(line string) (T, bool)) ([]T, error) {
+	scheme, target, _ := config.Location(loc)
+	if scheme == config.SchemeFile {
+		out, stats, err := fileutil.ReadLines(ctx, logger, name, target, limit, parse)
+		if err == nil {
+			valid, skipped := 0, 0
+			for _, st := range stats {
+				valid += st.Valid
+				skipped += st.Skipped
+			}
+			metrics.Records(name, valid, skipped)
+		}
+		return out, err
+	}
+
+	data, err := Read(ctx, logger, client, loc, name+" fetch", limit)
+	if err != nil {
+		return nil, err
+	}
+	// по отмене ничего не разбираем и не публикуем
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	st := fileutil.FileStats{Path: describe(scheme, target)}
+	out := fileutil.ParseLines(data, nil, &st, parse)
+	st.Log(logger, name)
+	metrics.Records(name, st.Valid, st.Skipped)
+	return out, nil
+}
+
+// JSONArray — источник с JSON-массивом (mms/support/incident). HTTP идёт через httpx.FetchArray как раньше
+// (со статусом, заголовками opts и стриминговым декодером), file и exec — тот же decode по содержимому
+// с лимитом размера limit (как в Read).
+func JSONArray[T any](ctx context.Context, logger *slog.Logger, client httpx.Doer, loc string, decode httpx.DecoderFunc[T], op string, limit int64, opts ...httpx.RequestOption) ([]T, error) {
+	scheme, target, _ := config.Location(loc)
+	if scheme == config.SchemeHTTP {
+		return httpx.FetchArray(ctx, logger, client, target, decode, op, opts...)
+	}
+
+	data, err := Read(ctx, logger, client, loc, op, limit)
+	if err != nil {
+		return nil, err
+	}
+	_, span := tracing.Start(ctx, "jsonx decode", attribute.String("op", op))
+	items, err := decode(bytes.NewReader(data))
+	span.SetAttributes(attribute.Int("items", len(items)))
+	tracing.RecordError(span, err)
+	span.End()
+	if err != nil {
+		logger.Error("decode", slog.String("op", op), slog.String("source", describe(scheme, target)), sl.Err(err))
+		return nil, fmt.Errorf("%s: decode: %w", op, err)
+	}
+	return items, nil
+}
+
+// describe — чем был источник, для логов: путь файла или имя команды (аргументы могут содержать лишнее).
+func describe(scheme, target string) string {
+	if scheme == config.SchemeExec {
+		if args := strings.Fields(target); len(args) > 0 {
+			return "exec://" + args[0]
+		}
+	}
+	return target
+}
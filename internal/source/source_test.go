@@ -0,0 +1,164 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"main/internal/fileutil"
+	"main/internal/jsonx"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// parseWord — построчный парсер для тестов: строка "bad" считается повреждённой
+func parseWord(line string) (string, bool) { return line, line != "bad" }
+
+type item struct {
+	Name string `json:"name"`
+}
+
+func decodeItems(r io.Reader) ([]item, error) {
+	return jsonx.DecodeArrayFromReader[item](r, &jsonx.Options[item]{})
+}
+
+// mockFiles подменяет FileOpener на время теста
+func mockFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	orig := fileutil.FileOpener
+	t.Cleanup(func() { fileutil.FileOpener = orig })
+	fileutil.FileOpener = func(path string, _ int64) ([]byte, error) {
+		if data, ok := files[path]; ok {
+			return []byte(data), nil
+		}
+		return nil, errors.New("no such file")
+	}
+}
+
+// mockCommand подменяет RunCommand и запоминает, что запускали
+func mockCommand(t *testing.T, out string, err error) *[]string {
+	t.Helper()
+	orig := RunCommand
+	t.Cleanup(func() { RunCommand = orig })
+	var got []string
+	RunCommand = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		got = append([]string{name}, args...)
+		return []byte(out), err
+	}
+	return &got
+}
+
+func TestLines_Backends(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "h1\nbad\nh2\n")
+	}))
+	defer srv.Close()
+
+	mockFiles(t, map[string]string{"a.data": "f1\nbad", "b.data": "f2"})
+	ran := mockCommand(t, "e1\ne2\nbad\n", nil)
+
+	tests := []struct {
+		name string
+		loc  string
+		want []string
+	}{
+		{"plain path list", "a.data, b.data", []string{"f1", "f2"}},
+		{"file scheme", "file://a.data", []string{"f1"}},
+		{"http", srv.URL + "/sms", []string{"h1", "h2"}},
+		{"exec", "exec:///usr/local/bin/get-sms --region eu", []string{"e1", "e2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lines(context.Background(), testLogger, srv.Client(), "sms", tt.loc, 0, parseWord)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if want := []string{"/usr/local/bin/get-sms", "--region", "eu"}; !reflect.DeepEqual(*ran, want) {
+		t.Fatalf("command = %q, want %q", *ran, want)
+	}
+}
+
+func TestJSONArray_Backends(t *testing.T) {
+	const body = `[{"name":"x"},{"name":"y"}]`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}))
+	defer srv.Close()
+	mockFiles(t, map[string]string{"/data/mms.json": body})
+	mockCommand(t, body, nil)
+
+	want := []item{{"x"}, {"y"}}
+	for _, loc := range []string{srv.URL, "file:///data/mms.json", "exec://cat /data/mms.json"} {
+		t.Run(loc, func(t *testing.T) {
+			got, err := JSONArray(context.Background(), testLogger, srv.Client(), loc, decodeItems, "mms.Fetch", 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	mockCommand(t, "", nil)
+	if _, err := Read(ctx, testLogger, nil, "exec://get-billing", "billing", 0); err == nil || !strings.Contains(err.Error(), "empty output") {
+		t.Fatalf("exec empty output: err = %v", err)
+	}
+
+	mockCommand(t, "", errors.New("exit status 2"))
+	if _, err := Read(ctx, testLogger, nil, "exec://get-billing", "billing", 0); err == nil || !strings.Contains(err.Error(), "exit status 2") {
+		t.Fatalf("exec failure: err = %v", err)
+	}
+
+	mockFiles(t, map[string]string{"a.data": "1", "b.data": "2"})
+	if _, err := Read(ctx, testLogger, nil, "a.data, b.data", "billing", 0); err == nil || !strings.Contains(err.Error(), "want exactly one file") {
+		t.Fatalf("several files: err = %v", err)
+	}
+
+	// лимит — переданный вызывающим (cfg.MaxFile()), а не DefaultMaxFile
+	mockCommand(t, "0123456789", nil)
+	if _, err := Read(ctx, testLogger, nil, "exec://get-billing", "billing", 8); err == nil || !strings.Contains(err.Error(), "output too large") {
+		t.Fatalf("too large output: err = %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "0123456789")
+	}))
+	defer srv.Close()
+	if _, err := Read(ctx, testLogger, srv.Client(), srv.URL, "billing", 8); err == nil || !strings.Contains(err.Error(), "body too large") {
+		t.Fatalf("too large body: err = %v", err)
+	}
+	if got, err := Read(ctx, testLogger, srv.Client(), srv.URL, "billing", 10); err != nil || string(got) != "0123456789" {
+		t.Fatalf("body within limit: got %q, err = %v", got, err)
+	}
+}
+
+// настоящая команда без shell: аргументы передаются как есть
+func TestRunCommand_Real(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo not found")
+	}
+	got, err := Read(context.Background(), testLogger, nil, "exec://echo US;36;1576;Rond", "sms", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(string(got)) != "US;36;1576;Rond" {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,86 @@
+// Package tracing — трассировка сбора данных на OpenTelemetry: span на HTTP-запрос, обращение к кэшу,
+// опрос каждого источника, чтение файлов, запросы к апстримам, разбор JSON и Build*-преобразования.
+// Setup ставит глобальный TracerProvider (SDK с OTLP/HTTP-экспортёром) и W3C-пропагатор; без endpoint-а провайдер
+// no-op: span-ы не записываются, а входящий traceparent проходит к апстримам как есть.
+package tracing
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// scopeName — instrumentation scope наших span-ов.
+const scopeName = "statecollector"
+
+// Config — настройки трассировки (config: tracing.*).
+type Config struct {
+	Endpoint    string  // OTLP/HTTP endpoint span-ов, например http://otel-collector:4318/v1/traces; пусто — no-op
+	Service     string  // service.name в ресурсе span-ов
+	SampleRatio float64 // доля записываемых трасс, 0..1 (решение — по trace id, у корня трассы)
+}
+
+// Setup включает трассировку по cfg: без Endpoint (или с неверным) — no-op провайдер, shutdown ничего не делает.
+// Возвращённый shutdown отправляет накопленные span-ы и останавливает экспорт.
+func Setup(logger *slog.Logger, cfg Config) (shutdown func(context.Context) error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	noopShutdown := func(context.Context) error { return nil }
+	if cfg.Endpoint == "" {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		return noopShutdown
+	}
+	exp, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		logger.Warn("tracing disabled: bad exporter config", slog.Any("err", err))
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		return noopShutdown
+	}
+	// ошибки экспорта — в наш лог; span-ы теряются, сбору это не мешает
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("tracing export failed", slog.Any("err", err))
+	}))
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.Service))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	logger.Info("tracing enabled", slog.String("endpoint", cfg.Endpoint), slog.Float64("sample_ratio", cfg.SampleRatio))
+	return tp.Shutdown
+}
+
+// Start начинает внутренний span name — дочерний к span-у из ctx (или к входящему traceparent).
+// Провайдер берётся глобальный на момент вызова, так что подмена в тестах действует сразу. Вызывающий обязан вызвать End.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return StartKind(ctx, trace.SpanKindInternal, name, attrs...)
+}
+
+// StartKind — то же с видом span-а (SpanKindServer для входящего запроса, SpanKindClient для апстрима).
+func StartKind(ctx context.Context, kind trace.SpanKind, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(scopeName).Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// StartRoot начинает span name корнем новой трассы со ссылкой (link) на span вызывающего — для работы,
+// общей для нескольких запросов (фоновое обновление кэша), чтобы она не числилась внутри одного из них.
+func StartRoot(ctx context.Context, name string, link trace.Link, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(scopeName).Start(ctx, name, trace.WithNewRoot(), trace.WithLinks(link), trace.WithAttributes(attrs...))
+}
+
+// RecordError помечает span ошибкой (событие exception и status Error); nil ничего не меняет.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
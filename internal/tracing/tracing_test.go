@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestSetup_NoEndpoint_PassesTraceParent(t *testing.T) {
+	shutdown := Setup(discard, Config{})
+	defer func() { _ = shutdown(context.Background()) }()
+
+	const parent = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	in := http.Header{"Traceparent": {parent}}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(in))
+
+	ctx, span := Start(ctx, "noop")
+	defer span.End()
+	if span.IsRecording() {
+		t.Error("no-op span must not record")
+	}
+	out := http.Header{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(out))
+	if got := out.Get("traceparent"); got != parent {
+		t.Errorf("traceparent = %q, want %q", got, parent)
+	}
+}
+
+func TestSetup_ExportsOTLP(t *testing.T) {
+	var posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/traces" {
+			posts.Add(1)
+		}
+	}))
+	defer srv.Close()
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	shutdown := Setup(discard, Config{Endpoint: srv.URL + "/v1/traces", Service: "test", SampleRatio: 1})
+	_, span := Start(context.Background(), "root")
+	if !span.IsRecording() {
+		t.Fatal("span must record with ratio 1")
+	}
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if posts.Load() == 0 {
+		t.Error("spans were not exported to the OTLP endpoint")
+	}
+}
+
+// sample_ratio 0 — трассировка включена, но ни одна трасса не записывается и не уходит в коллектор
+func TestSetup_ZeroRatioRecordsNothing(t *testing.T) {
+	var posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { posts.Add(1) }))
+	defer srv.Close()
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	shutdown := Setup(discard, Config{Endpoint: srv.URL + "/v1/traces", Service: "test", SampleRatio: 0})
+	for range 100 {
+		_, span := Start(context.Background(), "root")
+		if span.IsRecording() {
+			t.Fatal("span must not record with ratio 0")
+		}
+		span.End()
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if n := posts.Load(); n != 0 {
+		t.Errorf("exported %d batches, want none", n)
+	}
+}
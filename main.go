@@ -6,12 +6,15 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"log/slog"
 
 	//менеджер горутин удобен, когда нужно запустить несколько задач параллельно, дождаться их завершения и аккуратно обойтись с ошибками и отменой по контексту.
 	"main/config"
 	s "main/internal/httpserver"
+	"main/internal/scheduler"
+	"main/internal/tracing"
 )
 
 // LogCfg описывает параметры логирования, которые удобнее всего задавать флагами/ENV.
@@ -24,6 +27,7 @@ type LogCfg struct {
 // запукать в терминале bash
 //
 //	$ go run . -log.format=json -log.level=debug    go run . -log.format=text -log.level=debug
+//	$ go run . -config=config.yaml -http.addr=0.0.0.0:8282
 func readLogCfg() LogCfg {
 	var cfg LogCfg
 	flag.StringVar(&cfg.Format, "log.format", "text", "log output format: text|json")
@@ -38,11 +42,15 @@ func init() {
 }
 
 func main() {
-	//Конфига флагов запуска сервиса
+	// подкоманды config validate|print — выполняются и завершают процесс, сервис не стартует
+	configCmd()
+
+	//Конфига флагов запуска сервиса: флаги полей CfgApp регистрируем до flag.Parse() в readLogCfg
+	cfgFlags := config.RegisterFlags(flag.CommandLine)
 	appFlags := readLogCfg()
 
-	// 1. загружаем конфиг
-	cfgApp, err := config.Load("config.cfg")
+	// 1. загружаем конфиг: defaults < файл (-config) < STATECOLLECTOR_* < флаги
+	cfgApp, err := config.Resolve(os.LookupEnv, cfgFlags)
 	if err != nil {
 		// логгера ещё нет, поэтому просто stderr + выход
 		_, _ = os.Stderr.WriteString("state_Collector config load error: " + err.Error() + "\n")
@@ -63,18 +71,44 @@ func main() {
 	//logger.Debug("logging started")
 	logger.Info("state_Collector starting", slog.String("Version", "1.06"))
 
+	// трассировка (tracing.*): без endpoint — no-op; настройки фиксируются при старте
+	shutdownTracing := tracing.Setup(logger, tracing.Config{
+		Endpoint:    cfgApp.TraceEndpoint,
+		Service:     cfgApp.TraceServiceName(),
+		SampleRatio: cfgApp.TraceSampling(),
+	})
+
 	// Главная работа сервиса.
-	if err := run(ctx, logger, cfgApp); err != nil {
+	if err := run(ctx, logger, config.NewHolder(cfgApp), cfgFlags); err != nil {
 		logger.Error("collector failed", slog.Any("err", err))
 	}
 
+	// отправляем накопленные span-ы; ctx уже отменён сигналом, поэтому свой таймаут
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Warn("tracing flush failed", slog.Any("err", err))
+	}
+
 	logger.Info("state_Collector stopped")
 }
 
 // run — «бизнес-логика», умеет останавливаться по ctx.Done().
-func run(parentCtx context.Context, logger *slog.Logger, cfg *config.CfgApp) error {
+func run(parentCtx context.Context, logger *slog.Logger, cfg *config.Holder, cfgFlags *config.Flags) error {
 
-	s.HttpServer(parentCtx, logger, cfg)
+	// hot reload: по изменению файла конфига и по SIGHUP; с теми же env/флагами, что и при старте
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go config.Watch(parentCtx, logger, cfg, cfgFlags.Path(os.LookupEnv), cfg.Load().PollInterval(), hup,
+		func() (*config.CfgApp, error) { return config.Resolve(os.LookupEnv, cfgFlags) })
+
+	// фоновый сбор по расписанию (collect.interval); при 0 данные собираются на запрос, как раньше
+	go scheduler.Run(parentCtx, logger, cfg, s.Publish)
+
+	if err := s.HttpServer(parentCtx, logger, cfg); err != nil {
+		return err
+	}
 	<-parentCtx.Done()
 	logger.Debug("state_Collector.run(): ctx cancelled — graceful exit")
 	return nil
@@ -4,13 +4,14 @@ import (
 	"context"
 	"io"
 	"net/http"
-	"time"
 
 	"log/slog"
 	"main/config"
 	"main/internal/httpx"
 	"main/internal/jsonx"
+	"main/internal/metrics"
 	m "main/internal/model"
+	"main/internal/source"
 )
 
 // В продакшене передавайте http.Client извне, чтобы реиспользовать пул соединений.
@@ -22,24 +23,38 @@ type Service struct {
 
 func NewService(log *slog.Logger, cfg *config.CfgApp, client *http.Client) *Service {
 	if client == nil {
-		client = &http.Client{Timeout: 5 * time.Second}
+		client = &http.Client{Timeout: cfg.ClientTimeout()}
 	}
 	return &Service{log: log, cfg: cfg, client: client}
 }
 
 func (s *Service) Fetch(ctx context.Context) ([]m.MMSData, error) {
+	rejected := 0 // элементы, отброшенные декодером (битые/не прошли Validate) — для метрик
 	decode := func(r io.Reader) ([]m.MMSData, error) {
-		return jsonx.DecodeArrayFromReader[m.MMSData](r, &jsonx.Options[m.MMSData]{})
+		return jsonx.DecodeArrayFromReader[m.MMSData](r, &jsonx.Options[m.MMSData]{OnSkip: func(error) { rejected++ }})
 	}
 
-	return httpx.FetchArray[m.MMSData](
+	// адрес — http(s):// (как раньше), file:// или exec:// с тем же JSON-массивом, см. config.Location
+	items, err := source.JSONArray[m.MMSData](
 		ctx,
 		s.log,
 		s.client,
 		s.cfg.PathMmsData,
 		decode,
 		"mmsdata.Fetch",
+		s.cfg.MaxFile(),
+		httpx.WithHeader("Authorization", s.authorization),
 	)
+	if err == nil {
+		metrics.Records(config.SourceMMS, len(items), rejected)
+	}
+	return items, err
+}
+
+// authorization — заголовок Authorization по настройкам AuthMms (секрет читается на каждый запрос).
+func (s *Service) authorization() (string, error) {
+	h, err := s.cfg.AuthMms.Header()
+	return h.Reveal(), err
 }
 
 /*
@@ -78,7 +93,7 @@ func (v MMSData) Validate() error {
 
 func NewService(log *slog.Logger, cfg *config.CfgApp, client *http.Client) *Service {
 	if client == nil {
-		client = &http.Client{Timeout: 5 * time.Second}
+		client = &http.Client{Timeout: cfg.ClientTimeout()}
 	}
 	return &Service{log: log, cfg: cfg, client: client}
 }
@@ -6,7 +6,9 @@ import (
 	"log/slog"
 	"main/config"
 	countries "main/internal/alpha2"
+	"main/internal/health"
 	m "main/internal/model"
+	"main/internal/tracing"
 	"net/http"
 	"slices"
 	"strings"
@@ -44,6 +46,7 @@ func GoFetch(
 
 		nonSortedData, err := s.Fetch(ctx)
 		if err != nil {
+			health.Record(ctx, config.SourceMMS, start, 0, err)
 			// отличаем отмену от реальной ошибки
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				logger.Info("mms cancelled", slog.Duration("dur", time.Since(start)))
@@ -56,13 +59,16 @@ func GoFetch(
 		// перед публикацией ещё раз убеждаемся, что не отменено
 		select {
 		case <-ctx.Done():
+			health.Record(ctx, config.SourceMMS, start, 0, ctx.Err())
 			logger.Info("mms cancelled before publish", slog.Duration("dur", time.Since(start)))
 			return nil
 		default:
 		}
 
 		//все что ниже продолжит выполнение как по default
+		_, buildSpan := tracing.Start(ctx, "BuildSortedMMS")
 		sortedData := BuildSortedMMS(nonSortedData) // [][]MMSData
+		buildSpan.End()
 
 		// сохранить результат с защитой от гонок
 		mu.Lock()
@@ -75,6 +81,7 @@ func GoFetch(
 			total += len(part)
 		}
 
+		health.Record(ctx, config.SourceMMS, start, len(nonSortedData), nil)
 		logger.Info("mms fetched",
 			slog.Int("count", total),
 			slog.Duration("dur", time.Since(start)),
@@ -0,0 +1,24 @@
+package sl
+
+import (
+	"context"
+	"log/slog"
+)
+
+// логгер запроса в контексте: middleware HTTP-сервера кладёт туда логгер с request_id,
+// хендлеры и сбор данных пишут через него, и все строки одного запроса связываются по ID
+
+type loggerKey struct{}
+
+// WithLogger кладёт логгер в контекст.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext — логгер из контекста, иначе fallback.
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && l != nil {
+		return l
+	}
+	return fallback
+}
@@ -4,11 +4,10 @@ import (
 	"context"
 	"log/slog"
 	"main/config"
-	"main/internal/fileutil"
 	m "main/internal/model"
+	"main/internal/source"
 	"main/internal/textutil"
-	"main/sl"
-	"strings"
+	"net/http"
 )
 
 /*
@@ -26,43 +25,28 @@ import (
 Итог переносим в SMSData struct
 */
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=readfile
-func Fetch(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) ([]m.SMSData, error) {
+func Fetch(ctx context.Context, logger *slog.Logger, client *http.Client, cfg *config.CfgApp) ([]m.SMSData, error) {
 
-	path := cfg.FileSms
-
-	// Читаем целиком (маленький файл)
-	rf, err := fileutil.FileOpener(path)
-	if err != nil {
-		logger.Error("Error by open/read file "+path, sl.Err(err))
-		return nil, err
-	}
-
-	/*Почему останавливаемся тут
+	// FileSms — один путь или несколько путей/glob-шаблонов через запятую (sms-*.data), либо http(s):// / exec://
+	// с тем же построчным форматом (см. config.Location); файлы читаются целиком (маленькие), строки склеиваются,
+	// статистика разбора — в лог.
+	/*По отмене source.Lines останавливается сразу после чтения.
 	Ранний выход без «публикации». Даже если парсинг и валидация быстрые, по отмене лучше вернуть ошибку и не делать больше ничего.
 	Тогда вызывающий код (горутина) не будет логировать “fetched” и не будет публиковать результат.
 	*/
-	if err := ctx.Err(); err != nil {
-		return nil, err
-	}
-
-	//преобразовать байты в массив строк, разделитель новая строка, затем разделитель ;
-	lines := strings.Split(string(rf), "\n")
-
-	out := make([]m.SMSData, 0, len(lines)) //данные SMS будут срезе, len = , cap =	//так чтобы не было пустых элементов в срезе, cap сразу чтоб не переназначалась каждый раз память
-
-	for _, line := range lines {
+	out, err := source.Lines(ctx, logger, client, config.SourceSMS, cfg.FileSms, cfg.MaxFile(), func(line string) (m.SMSData, bool) {
 		splitted, ok := textutil.SplitN(line, ';', cfg.QuantSMSDataCol) //перешли на более дешевый метод SplitN. было: SMSDataLine := strings.Split(line, ";")
 		if !ok {
-			continue
+			return m.SMSData{}, false
 		}
 		s := m.SMSData{Country: splitted[0], Bandwidth: splitted[1], ResponseTime: splitted[2], Provider: splitted[3]}
 
 		//if validate.ColumnsChecker(SMSDataLine, quantSMSDataCol) { //проверка на соответствие критериям 1
 
-		if err := s.Validate(); err == nil { //проверка на соответствие критериям 2,3,4,5
-			out = append(out, s)
-		}
-
+		return s, s.Validate() == nil //проверка на соответствие критериям 2,3,4,5
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return out, nil
@@ -8,6 +8,8 @@ import (
 	"main/config"
 	"main/internal/fileutil"
 	m "main/internal/model"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -55,13 +57,13 @@ CA;855;1059;Rond`
 BL;68;1594;Kildy
 RU;86;297;Rond`
 
-	fileutil.FileOpener = func(_ string) ([]byte, error) {
+	fileutil.FileOpener = func(_ string, _ int64) ([]byte, error) {
 		return []byte(sample), nil
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Second)
 	defer cancel()
 
-	got, err := Fetch(ctx, testLogger, makeCfg())
+	got, err := Fetch(ctx, testLogger, nil, makeCfg())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -90,13 +92,13 @@ func TestGet_SampleFile_empty(t *testing.T) {
 
 	const sample = ``
 
-	fileutil.FileOpener = func(_ string) ([]byte, error) {
+	fileutil.FileOpener = func(_ string, _ int64) ([]byte, error) {
 		return []byte(sample), nil
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Second)
 	defer cancel()
 
-	got, err := Fetch(ctx, testLogger, makeCfg())
+	got, err := Fetch(ctx, testLogger, nil, makeCfg())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -221,14 +223,14 @@ DK;12;1454;Topolo`,
 		tt := tt // pin внутри цикла
 		t.Run(tt.name, func(t *testing.T) {
 			// подменяем «чтение файла»
-			fileutil.FileOpener = func(_ string) ([]byte, error) {
+			fileutil.FileOpener = func(_ string, _ int64) ([]byte, error) {
 				return []byte(tt.sample), nil
 			}
 
 			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Second)
 			defer cancel()
 
-			got, err := Fetch(ctx, testLogger, makeCfg())
+			got, err := Fetch(ctx, testLogger, nil, makeCfg())
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -257,3 +259,50 @@ DK;12;1454;Topolo`,
 		})
 	}
 }
+
+// несколько файлов (по файлу на провайдера): строки склеиваются, нечитаемый файл не роняет источник
+func TestGet_MultipleFiles(t *testing.T) {
+	orig := fileutil.FileOpener
+	defer func() { fileutil.FileOpener = orig }()
+
+	files := map[string]string{
+		"sms-Topolo.data": "GB;88;1892;Topolo\nbroken line",
+		"sms-Rond.data":   "US;36;1576;Rond",
+	}
+	fileutil.FileOpener = func(path string, _ int64) ([]byte, error) {
+		if data, ok := files[path]; ok {
+			return []byte(data), nil
+		}
+		return nil, fmt.Errorf("open %s: no such file", path)
+	}
+
+	cfg := makeCfg()
+	cfg.FileSms = "sms-Topolo.data, sms-Rond.data, sms-Kildy.data"
+
+	got, err := Fetch(context.Background(), testLogger, nil, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out := SMSDataSliceToString(got); out != "GB;88;1892;Topolo\nUS;36;1576;Rond" {
+		t.Fatalf("merged result mismatch: %q", out)
+	}
+}
+
+// sms по https:// идёт через переданный клиент: сертификат тестового сервера знает только srv.Client()
+func TestGet_SharedClient(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "US;36;1576;Rond\n")
+	}))
+	defer srv.Close()
+
+	cfg := makeCfg()
+	cfg.FileSms = srv.URL + "/sms.data"
+
+	got, err := Fetch(context.Background(), testLogger, srv.Client(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out := SMSDataSliceToString(got); out != "US;36;1576;Rond" {
+		t.Fatalf("result mismatch: %q", out)
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"log/slog"
 	"main/config"
 	countries "main/internal/alpha2"
+	"main/internal/health"
 	m "main/internal/model"
+	"main/internal/tracing"
+	"net/http"
 	"slices"
 	"strings"
 	"sync"
@@ -22,6 +25,7 @@ func GoFetch(
 	groupCtx context.Context,
 	logger *slog.Logger,
 	timeout time.Duration,
+	client *http.Client,
 	cfg *config.CfgApp,
 	rs *m.ResultSetT,
 	mu *sync.Mutex,
@@ -37,8 +41,9 @@ func GoFetch(
 
 		start := time.Now()
 
-		nonSortedData, err := Fetch(ctx, logger, cfg) // []sms.SMSData
+		nonSortedData, err := Fetch(ctx, logger, client, cfg) // []sms.SMSData
 		if err != nil {
+			health.Record(ctx, config.SourceSMS, start, 0, err)
 			// отличаем отмену от реальной ошибки
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				logger.Info("sms cancelled", slog.Duration("dur", time.Since(start)))
@@ -51,12 +56,15 @@ func GoFetch(
 		// перед публикацией ещё раз убеждаемся, что не отменено
 		select {
 		case <-ctx.Done():
+			health.Record(ctx, config.SourceSMS, start, 0, ctx.Err())
 			logger.Info("sms cancelled before publish", slog.Duration("dur", time.Since(start)))
 			return nil
 		default:
 		}
 		//все что ниже продолжит выполнение как по default
+		_, buildSpan := tracing.Start(ctx, "BuildSortedSMS")
 		sortedData := BuildSortedSMS(nonSortedData) // [][]sms.SMSData
+		buildSpan.End()
 
 		// сохранить результат с защитой от гонок
 		mu.Lock()
@@ -69,6 +77,7 @@ func GoFetch(
 			total += len(part)
 		}
 
+		health.Record(ctx, config.SourceSMS, start, len(nonSortedData), nil)
 		logger.Info("sms fetched",
 			slog.Int("count", total),
 			slog.Duration("dur", time.Since(start)),
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"log/slog"
 	"main/config"
+	"main/internal/health"
 	m "main/internal/model"
+	"main/internal/tracing"
 	"math"
 	"net/http"
 	"sync"
@@ -50,6 +52,7 @@ func GoFetch(
 
 		nonSortedData, err := s.Fetch(ctx)
 		if err != nil {
+			health.Record(ctx, config.SourceSupport, start, 0, err)
 			// отличаем отмену от реальной ошибки
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				logger.Info("support cancelled", slog.Duration("dur", time.Since(start)))
@@ -62,19 +65,23 @@ func GoFetch(
 		// перед публикацией ещё раз убеждаемся, что не отменено
 		select {
 		case <-ctx.Done():
+			health.Record(ctx, config.SourceSupport, start, 0, ctx.Err())
 			logger.Info("support cancelled before publish", slog.Duration("dur", time.Since(start)))
 			return nil
 		default:
 		}
 
 		//все что ниже продолжит выполнение как по default
-		sortedData := BuildSortedSupport(nonSortedData)
+		_, buildSpan := tracing.Start(ctx, "BuildSortedSupport")
+		sortedData := BuildSortedSupport(nonSortedData, cfg.SupportThroughput())
+		buildSpan.End()
 
 		// сохранить результат с защитой от гонок
 		mu.Lock()
 		rs.Support = sortedData
 		mu.Unlock()
 
+		health.Record(ctx, config.SourceSupport, start, len(nonSortedData), nil)
 		logger.Info("support fetched",
 			slog.Duration("dur", time.Since(start)),
 		)
@@ -84,13 +91,16 @@ func GoFetch(
 }
 
 // BuildSortedSupport считает интегральную нагрузку саппорта и потенциальное время ожидания.
+// teamThroughputPerHour — сколько тикетов в час закрывает вся команда (config: support.throughput_per_hour, по умолчанию 18).
 // Возвращает []int{loadLevel, waitMinutes}:
 //
 //	loadLevel: 1 (<9 тикетов), 2 (9..16), 3 (>16)
 //	waitMinutes: потенциальное время ожидания ответа на новый тикет (минуты)
-func BuildSortedSupport(data []m.SupportData) []int {
-	const teamThroughputPerHour = 18.0
-	const minutesPerTicket = 60.0 / teamThroughputPerHour // ~3.33 мин/тикет (вся команда)
+func BuildSortedSupport(data []m.SupportData, teamThroughputPerHour float64) []int {
+	if teamThroughputPerHour <= 0 {
+		teamThroughputPerHour = config.DefaultSupportThroughput
+	}
+	minutesPerTicket := 60.0 / teamThroughputPerHour // ~3.33 мин/тикет (вся команда при 18/час)
 
 	// суммируем только валидные значения
 	totalOpen := 0
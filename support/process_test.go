@@ -93,7 +93,7 @@ func TestBuildSortedSupport(t *testing.T) {
 	for _, tc := range tests {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			got := BuildSortedSupport(tc.input)
+			got := BuildSortedSupport(tc.input, config.DefaultSupportThroughput)
 			want := []int{tc.wantLoad, tc.wantWait}
 
 			if !reflect.DeepEqual(got, want) {
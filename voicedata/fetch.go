@@ -4,12 +4,11 @@ import (
 	"context"
 	"log/slog"
 	"main/config"
-	"main/internal/fileutil"
 	m "main/internal/model"
+	"main/internal/source"
 	"main/internal/textutil"
-	"main/sl"
+	"net/http"
 	"strconv"
-	"strings"
 )
 
 /*
@@ -33,75 +32,62 @@ import (
 	10.Все числа с плавающей точкой должны быть приведены к типу float32
 */
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=readfile
-func Fetch(ctx context.Context, logger *slog.Logger, cfg *config.CfgApp) ([]m.VoiceCallData, error) {
-
-	// файл c voice
-	path := cfg.FileVoiceCall
-	rf, err := fileutil.FileOpener(path)
+func Fetch(ctx context.Context, logger *slog.Logger, client *http.Client, cfg *config.CfgApp) ([]m.VoiceCallData, error) {
 
+	// файл(ы) c voice: один путь или несколько путей/glob-шаблонов через запятую, строки всех файлов склеиваются;
+	// либо http(s):// / exec:// с тем же форматом (см. config.Location).
+	// По отмене source.Lines останавливается сразу после чтения, чтобы вызывающий код не публиковал результат.
+	data, err := source.Lines(ctx, logger, client, config.SourceVoice, cfg.FileVoiceCall, cfg.MaxFile(), func(line string) (m.VoiceCallData, bool) {
+		return parseLine(line, cfg.QuantVoiceDataCol)
+	})
 	if err != nil {
-		logger.Error("Error by opening file "+path, sl.Err(err))
 		return nil, err
 	}
 
-	/*Почему останавливаемся тут
-	Ранний выход без «публикации». Даже если парсинг и валидация быстрые, по отмене лучше вернуть ошибку и не делать больше ничего.
-	Тогда вызывающий код (горутина) не будет логировать “fetched” и не будет публиковать результат.
-	*/
-	if err := ctx.Err(); err != nil {
-		return nil, err
-	}
-
-	//преобразовать байты в массив строк, разделитель новая строка, затем разделитель ;
-	VoiceDataLines := strings.Split(string(rf), "\n")
-
-	VoiceDatas := make([]m.VoiceCallData, 0, len(VoiceDataLines)) //данные будут срезе, len = , cap =	//так чтобы не было пустых элементов в срезе, cap сразу чтоб не переназначалась каждый раз память
+	return data, nil
 
-	for _, line := range VoiceDataLines {
-		splitted, ok := textutil.SplitN(line, ';', cfg.QuantVoiceDataCol) //перешли на более дешевый метод SplitN.
-		if !ok {
-			continue
-		}
-
-		ConnectionStability, err := strconv.ParseFloat(splitted[4], 32)
-		//проверка на соответствие критерия 5 - поле не цифра
-		if err != nil {
-			continue
-		}
-		TTFB, err := strconv.Atoi(splitted[5])
-		//проверка на соответствие критерия 5 - поле не цифра
-		if err != nil {
-			continue
-		}
-		VoicePurity, err := strconv.Atoi(splitted[6])
-		//проверка на соответствие критерия 5 - поле не цифра
-		if err != nil {
-			continue
-		}
-		MedianOfCallsTime, err := strconv.Atoi(splitted[7])
-		//проверка на соответствие критерия 5 - поле не цифра
-		if err != nil {
-			continue
-		}
-
-		//заполняем структуру провайдера
-		s := m.VoiceCallData{
-			Country:             splitted[0],
-			Bandwidth:           splitted[1],
-			ResponseTime:        splitted[2],
-			Provider:            splitted[3],
-			ConnectionStability: float32(ConnectionStability),
-			TTFB:                TTFB,
-			VoicePurity:         VoicePurity,
-			MedianOfCallsTime:   MedianOfCallsTime,
-		}
+}
 
-		if err := s.Validate(); err == nil { //проверка на соответствие критериям 4, 6, 7
-			VoiceDatas = append(VoiceDatas, s)
-		}
+// parseLine разбирает одну строку voice-файла; false — строка повреждена или не прошла проверку.
+func parseLine(line string, quantCol int) (m.VoiceCallData, bool) {
+	//разделитель ;
+	splitted, ok := textutil.SplitN(line, ';', quantCol) //перешли на более дешевый метод SplitN.
+	if !ok {
+		return m.VoiceCallData{}, false
+	}
 
+	ConnectionStability, err := strconv.ParseFloat(splitted[4], 32)
+	//проверка на соответствие критерия 5 - поле не цифра
+	if err != nil {
+		return m.VoiceCallData{}, false
+	}
+	TTFB, err := strconv.Atoi(splitted[5])
+	//проверка на соответствие критерия 5 - поле не цифра
+	if err != nil {
+		return m.VoiceCallData{}, false
+	}
+	VoicePurity, err := strconv.Atoi(splitted[6])
+	//проверка на соответствие критерия 5 - поле не цифра
+	if err != nil {
+		return m.VoiceCallData{}, false
+	}
+	MedianOfCallsTime, err := strconv.Atoi(splitted[7])
+	//проверка на соответствие критерия 5 - поле не цифра
+	if err != nil {
+		return m.VoiceCallData{}, false
 	}
 
-	return VoiceDatas, nil
+	//заполняем структуру провайдера
+	s := m.VoiceCallData{
+		Country:             splitted[0],
+		Bandwidth:           splitted[1],
+		ResponseTime:        splitted[2],
+		Provider:            splitted[3],
+		ConnectionStability: float32(ConnectionStability),
+		TTFB:                TTFB,
+		VoicePurity:         VoicePurity,
+		MedianOfCallsTime:   MedianOfCallsTime,
+	}
 
+	return s, s.Validate() == nil //проверка на соответствие критериям 4, 6, 7
 }
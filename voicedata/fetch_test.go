@@ -65,14 +65,14 @@ DK;11;743;JustPhone;0.67;82;74;41`
 	const wantStr = `BG;40;609;E-Voice;0.86;160;36;5
 DK;11;743;JustPhone;0.67;82;74;41`
 
-	fileutil.FileOpener = func(_ string) ([]byte, error) {
+	fileutil.FileOpener = func(_ string, _ int64) ([]byte, error) {
 		return []byte(sample), nil
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Second)
 	defer cancel()
 
-	got, err := Fetch(ctx, testLogger(), makeCfg())
+	got, err := Fetch(ctx, testLogger(), nil, makeCfg())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -199,7 +199,7 @@ CA;8;1059;E-Voice;0.7;100;70;50`,
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			fileutil.FileOpener = func(_ string) ([]byte, error) {
+			fileutil.FileOpener = func(_ string, _ int64) ([]byte, error) {
 				// нормализуем комментарии в тестовых данных: отрежем " // ..."
 				lines := strings.Split(tt.sample, "\n")
 				for i := range lines {
@@ -216,7 +216,7 @@ CA;8;1059;E-Voice;0.7;100;70;50`,
 			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Second)
 			defer cancel()
 
-			got, err := Fetch(ctx, testLogger(), makeCfg())
+			got, err := Fetch(ctx, testLogger(), nil, makeCfg())
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"log/slog"
 	"main/config"
+	"main/internal/health"
 	m "main/internal/model"
+	"net/http"
 	"sync"
 	"time"
 
@@ -19,6 +21,7 @@ func GoFetch(
 	parentCtx context.Context,
 	logger *slog.Logger,
 	timeout time.Duration,
+	client *http.Client,
 	cfg *config.CfgApp,
 	rs *m.ResultSetT,
 	mu *sync.Mutex,
@@ -34,8 +37,9 @@ func GoFetch(
 
 		start := time.Now()
 
-		data, err := Fetch(ctx, logger, cfg) // []VoiceCallData
+		data, err := Fetch(ctx, logger, client, cfg) // []VoiceCallData
 		if err != nil {
+			health.Record(ctx, config.SourceVoice, start, 0, err)
 			// отличаем отмену от реальной ошибки
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				logger.Info("voice cancelled", slog.Duration("dur", time.Since(start)))
@@ -48,6 +52,7 @@ func GoFetch(
 		// перед публикацией ещё раз убеждаемся, что не отменено
 		select {
 		case <-ctx.Done():
+			health.Record(ctx, config.SourceVoice, start, 0, ctx.Err())
 			logger.Info("voice cancelled before publish", slog.Duration("dur", time.Since(start)))
 			return nil
 		default:
@@ -58,6 +63,7 @@ func GoFetch(
 		rs.VoiceCall = data
 		mu.Unlock()
 
+		health.Record(ctx, config.SourceVoice, start, len(data), nil)
 		logger.Info("voice fetched",
 			slog.Int("count", len(data)),
 			slog.Duration("dur", time.Since(start)),
@@ -27,7 +27,7 @@ DK;11;743;JustPhone;0.67;82;74;41`
 	const wantStr = `BG;40;609;E-Voice;0.86;160;36;5
 DK;11;743;JustPhone;0.67;82;74;41`
 
-	fileutil.FileOpener = func(_ string) ([]byte, error) { return []byte(sample), nil }
+	fileutil.FileOpener = func(_ string, _ int64) ([]byte, error) { return []byte(sample), nil }
 
 	// убедимся, что валидаторы подтянулись (как и в fetch_test.go)
 	_ = v.Struct(struct{}{})
@@ -39,7 +39,7 @@ DK;11;743;JustPhone;0.67;82;74;41`
 	)
 	ctx := context.Background()
 
-	GoFetch(&g, ctx, testLogger(), 0, makeCfg(), &rs, &mu)
+	GoFetch(&g, ctx, testLogger(), 0, nil, makeCfg(), &rs, &mu)
 
 	if err := g.Wait(); err != nil {
 		t.Fatalf("unexpected group error: %v", err)
@@ -58,7 +58,7 @@ func TestGoFetchVoice_Timeout_BeforePublish(t *testing.T) {
 	// Дадим задержку в «файле», чтобы внутри GoFetchSMS успел сработать timeout контекста,
 	// и ветка "cancelled before publish" не записала результат в rs.
 	const sample = `RU;86;297;TransparentCalls;0.9;120;80;30`
-	fileutil.FileOpener = func(_ string) ([]byte, error) {
+	fileutil.FileOpener = func(_ string, _ int64) ([]byte, error) {
 		time.Sleep(100 * time.Millisecond) // дольше, чем timeout ниже
 		return []byte(sample), nil
 	}
@@ -72,7 +72,7 @@ func TestGoFetchVoice_Timeout_BeforePublish(t *testing.T) {
 	)
 	ctx := context.Background()
 
-	GoFetch(&g, ctx, testLogger(), 5*time.Millisecond, makeCfg(), &rs, &mu)
+	GoFetch(&g, ctx, testLogger(), 5*time.Millisecond, nil, makeCfg(), &rs, &mu)
 
 	if err := g.Wait(); err != nil {
 		t.Fatalf("unexpected group error: %v", err)
@@ -88,7 +88,7 @@ func TestGoFetchVoice_FetchError_NoPublish(t *testing.T) {
 	defer func() { fileutil.FileOpener = origOpen }()
 
 	// Симулируем ошибку чтения файла (Fetch вернёт err != nil, не связанную с ctx)
-	fileutil.FileOpener = func(_ string) ([]byte, error) {
+	fileutil.FileOpener = func(_ string, _ int64) ([]byte, error) {
 		return nil, errors.New("boom")
 	}
 
@@ -101,7 +101,7 @@ func TestGoFetchVoice_FetchError_NoPublish(t *testing.T) {
 	)
 	ctx := context.Background()
 
-	GoFetch(&g, ctx, testLogger(), 0, makeCfg(), &rs, &mu)
+	GoFetch(&g, ctx, testLogger(), 0, nil, makeCfg(), &rs, &mu)
 
 	if err := g.Wait(); err != nil {
 		t.Fatalf("unexpected group error: %v", err)
@@ -118,7 +118,7 @@ func TestGoFetchVoice_ParentContextAlreadyCancelled_NoPublish(t *testing.T) {
 
 	// Быстрый валидный ответ из "файла"
 	const sample = `RU;86;297;TransparentCalls;0.9;120;80;30`
-	fileutil.FileOpener = func(_ string) ([]byte, error) { return []byte(sample), nil }
+	fileutil.FileOpener = func(_ string, _ int64) ([]byte, error) { return []byte(sample), nil }
 
 	// Прогреем валидаторы, как в остальных тестах
 	_ = v.Struct(struct{}{})
@@ -134,7 +134,7 @@ func TestGoFetchVoice_ParentContextAlreadyCancelled_NoPublish(t *testing.T) {
 	cancel()
 
 	// timeout = 0, чтобы проверять именно реакцию на отменённый parent
-	GoFetch(&g, ctx, testLogger(), 0, makeCfg(), &rs, &mu)
+	GoFetch(&g, ctx, testLogger(), 0, nil, makeCfg(), &rs, &mu)
 
 	if err := g.Wait(); err != nil {
 		t.Fatalf("unexpected group error: %v", err)